@@ -0,0 +1,56 @@
+package ga
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// coolings are the built in cooling schedules of the anneal strategy by
+// name, each returns the temperature after n generations from t0 with the
+// rate of cooling
+var coolings = map[string]func(t0 float64, rate float64, n int) float64{
+	// the temperature is multiplied by the rate every generation
+	"exponential": func(t0 float64, rate float64, n int) float64 {
+		return t0 * math.Pow(rate, float64(n))
+	},
+	// the rate is taken off the temperature every generation until it's 0
+	"linear": func(t0 float64, rate float64, n int) float64 {
+		return max(0, t0-rate*float64(n))
+	},
+	// the temperature falls slower and slower, the higher the rate the
+	// quicker
+	"logarithmic": func(t0 float64, rate float64, n int) float64 {
+		return t0 / (1 + rate*math.Log1p(float64(n)))
+	},
+}
+
+// CoolingNames returns the names of the built in cooling schedules
+func CoolingNames() []string {
+	names := make([]string, 0, len(coolings))
+	for name := range coolings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// temperature of the anneal strategy while breeding the given generation
+func (cfg Config) temperature(generations int) float64 {
+	return coolings[cfg.Cooling](cfg.Temperature, cfg.CoolingRate, generations-1)
+}
+
+// anneal every genome of the population on its own: a mutated copy of it
+// takes its place if it's at least as fit, or with a chance that falls the
+// less fit it is and the cooler it's got otherwise
+func (cfg Config) anneal(_ []Genome, population []Genome, seed int64, generations int) generation {
+	t := cfg.temperature(generations)
+	return cfg.climb(population, seed, generations, func(fitness int64, rng *rand.Rand) int64 {
+		// a less fit copy is taken when -t ln u is more than how much less
+		// fit it is
+		if t > 0 {
+			fitness += int64(min(-t*math.Log(rng.Float64()), math.MaxInt64/2))
+		}
+		return fitness
+	})
+}
@@ -0,0 +1,116 @@
+package ga
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Backend scores whole batches of candidate images against a target it's
+// given once. It's the way to run fitness evaluation somewhere else than on
+// the CPU, like on a GPU where uploading the target for every candidate would
+// cost more than the scoring saves.
+type Backend interface {
+	// Score scores every candidate into the score with the same index
+	Score(candidates []*image.RGBA, scores []int64) error
+	// Close releases what the backend holds
+	Close() error
+}
+
+// BackendMaker makes a backend that scores candidates against the target the
+// same way as the fitness. It returns ErrBackendUnavailable when the backend
+// can't run on this machine, e.g. when there's no GPU.
+type BackendMaker func(target *image.RGBA, fitness Fitness) (Backend, error)
+
+// ErrBackendUnavailable is returned by a BackendMaker that can't run here
+var ErrBackendUnavailable = errors.New("backend unavailable")
+
+// backends are the registered backends by name
+var (
+	backendMu sync.RWMutex
+	backends  = map[string]BackendMaker{
+		"cpu": newCPUBackend,
+	}
+)
+
+// RegisterBackend makes a backend available to NewBackend under the given
+// name. Backends that need cgo or drivers register themselves from an init
+// function in a file behind a build tag.
+func RegisterBackend(name string, maker BackendMaker) {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	backends[name] = maker
+}
+
+// BackendNames returns the names of the registered backends
+func BackendNames() []string {
+	backendMu.RLock()
+	defer backendMu.RUnlock()
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewBackend makes the backend with the given name for the target and the
+// fitness. When it's unavailable the cpu backend is made instead, the name of
+// the backend that was made is returned with it.
+func NewBackend(name string, target *image.RGBA, fitness Fitness) (Backend, string, error) {
+	backendMu.RLock()
+	maker, ok := backends[name]
+	backendMu.RUnlock()
+	if !ok {
+		return nil, "", fmt.Errorf("unknown backend %q, use one of %s", name, strings.Join(BackendNames(), ", "))
+	}
+	b, err := maker(target, fitness)
+	if errors.Is(err, ErrBackendUnavailable) && name != "cpu" {
+		b, err = newCPUBackend(target, fitness)
+		name = "cpu"
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return b, name, nil
+}
+
+// cpuBackend scores the candidates of a batch concurrently on the CPU
+type cpuBackend struct {
+	target  *image.RGBA
+	fitness Fitness
+}
+
+func newCPUBackend(target *image.RGBA, fitness Fitness) (Backend, error) {
+	return cpuBackend{target: target, fitness: fitness}, nil
+}
+
+// Score the candidates with a worker for every CPU
+func (b cpuBackend) Score(candidates []*image.RGBA, scores []int64) error {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				scores[i] = b.fitness.Score(candidates[i], b.target)
+			}
+		}()
+	}
+	for i := range candidates {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return nil
+}
+
+// Close does nothing as there's nothing to release
+func (b cpuBackend) Close() error {
+	return nil
+}
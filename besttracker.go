@@ -0,0 +1,107 @@
+package ga
+
+import (
+	"math"
+	"slices"
+)
+
+// BestTracker follows the fittest genome of a run, the one with the lowest
+// fitness, and how the fitness of each generation is spread. The fittest
+// genome is kept even once it has left the population.
+type BestTracker struct {
+	// OnImprove is called with the generation and the genome whenever a
+	// generation has a genome fitter than any of the generations before it,
+	// it can be nil
+	OnImprove func(generation int, best Genome)
+	best      Genome
+	spread    Spread
+}
+
+// Spread is how the fitness of a generation is spread, to tell whether the
+// population is still looking around or has collapsed onto one kind of genome
+type Spread struct {
+	// Best, Median and Worst are the fitness of the fittest, the median and
+	// the least fit genomes
+	Best   int64
+	Median int64
+	Worst  int64
+	Mean   float64
+	StdDev float64
+	// Diversity is the fraction of the genomes with a fitness no other
+	// genome has as well, from 0 when they're all as fit as each other to 1
+	// when none are
+	Diversity float64
+}
+
+// Track the population of the generation, and return whether it has a genome
+// fitter than the fittest one so far. The fitness of the fittest genome so
+// far is asked for again, so it's compared with the same measure as the
+// population when a genome's fitness can change during a run.
+func (t *BestTracker) Track(population []Genome, generation int) bool {
+	if len(population) == 0 {
+		return false
+	}
+	fitness := make([]int64, len(population))
+	best := 0
+	for i, g := range population {
+		fitness[i] = g.Fitness()
+		if fitness[i] < fitness[best] {
+			best = i
+		}
+	}
+	t.spread = spread(fitness)
+	if t.best != nil && t.best.Fitness() <= t.spread.Best {
+		return false
+	}
+	t.best = population[best]
+	if t.OnImprove != nil {
+		t.OnImprove(generation, t.best)
+	}
+	return true
+}
+
+// Best returns the fittest genome tracked so far, nil if nothing was tracked
+func (t *BestTracker) Best() Genome {
+	return t.best
+}
+
+// Spread returns how the fitness of the last generation tracked is spread
+func (t *BestTracker) Spread() Spread {
+	return t.spread
+}
+
+// how the fitness is spread, which is sorted
+func spread(fitness []int64) Spread {
+	slices.Sort(fitness)
+	n := len(fitness)
+	s := Spread{Best: fitness[0], Median: fitness[n/2], Worst: fitness[n-1]}
+	for _, f := range fitness {
+		s.Mean += float64(f)
+	}
+	s.Mean /= float64(n)
+	unique := 0
+	for i, f := range fitness {
+		d := float64(f) - s.Mean
+		s.StdDev += d * d
+		if (i == 0 || fitness[i-1] != f) && (i == n-1 || fitness[i+1] != f) {
+			unique++
+		}
+	}
+	s.StdDev = math.Sqrt(s.StdDev / float64(n))
+	if n > 1 {
+		s.Diversity = float64(unique) / float64(n)
+	}
+	return s
+}
+
+// the fittest genome of the population, the first of those as fit as each
+// other
+func fittest(population []Genome) Genome {
+	best := population[0]
+	for _, g := range population[1:] {
+		if g.Fitness() < best.Fitness() {
+			best = g
+		}
+	}
+	return best
+}
@@ -0,0 +1,49 @@
+package ga
+
+import (
+	"image"
+)
+
+// Blur returns a copy of the image with every pixel the average of the
+// pixels within radius of it, with a box blur across the rows and then down
+// the columns. The edges of the image are repeated outwards. A radius of 0
+// returns a plain copy.
+func Blur(img *image.RGBA, radius int) *image.RGBA {
+	src := ToRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	across := image.NewRGBA(image.Rect(0, 0, w, h))
+	blurLines(src.Pix, across.Pix, h, w, src.Stride, 4, across.Stride, 4, radius)
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	blurLines(across.Pix, dst.Pix, w, h, 4, across.Stride, 4, dst.Stride, radius)
+	return dst
+}
+
+// blur n lines of length pixels each from src into dst. The lines start
+// srcLine bytes apart in src and dstLine bytes apart in dst, and their
+// pixels are srcPixel and dstPixel bytes apart.
+func blurLines(src []uint8, dst []uint8, n int, length int, srcLine int, srcPixel int, dstLine int, dstPixel int, radius int) {
+	size := 2*radius + 1
+	for l := 0; l < n; l++ {
+		at := func(i int) int {
+			return l*srcLine + clamp(i, 0, length-1)*srcPixel
+		}
+		// the sum of the window around the pixel, which slides along the line
+		var sum [4]int
+		for i := -radius; i <= radius; i++ {
+			p := at(i)
+			for c := 0; c < 4; c++ {
+				sum[c] += int(src[p+c])
+			}
+		}
+		for i := 0; i < length; i++ {
+			d := l*dstLine + i*dstPixel
+			for c := 0; c < 4; c++ {
+				dst[d+c] = uint8((sum[c] + size/2) / size)
+			}
+			in, out := at(i+radius+1), at(i-radius)
+			for c := 0; c < 4; c++ {
+				sum[c] += int(src[in+c]) - int(src[out+c])
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package ga
+
+import (
+	"container/list"
+	"sync"
+)
+
+// FitnessCache remembers the fitness of recently evaluated genomes by a hash
+// of the genome, so that a genome that comes up again doesn't have to be
+// evaluated again. When it's full the least recently used fitness is
+// dropped. It's safe for concurrent use.
+type FitnessCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[uint64]*list.Element
+	// order has the most recently used entry at the front
+	order *list.List
+}
+
+// a cached fitness
+type cacheEntry struct {
+	key     uint64
+	fitness int64
+}
+
+// NewFitnessCache returns a cache of up to size fitness values
+func NewFitnessCache(size int) *FitnessCache {
+	return &FitnessCache{
+		size:    size,
+		entries: make(map[uint64]*list.Element, size),
+		order:   list.New(),
+	}
+}
+
+// Get returns the fitness cached for the hash of a genome, if there is one
+func (c *FitnessCache) Get(key uint64) (int64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return 0, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*cacheEntry).fitness, true
+}
+
+// Put caches the fitness for the hash of a genome
+func (c *FitnessCache) Put(key uint64, fitness int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*cacheEntry).fitness = fitness
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, fitness: fitness})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// Clear empties the cache, for when the way fitness is calculated changes
+func (c *FitnessCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[uint64]*list.Element, c.size)
+	c.order.Init()
+}
@@ -0,0 +1,136 @@
+package ga
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strconv"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// FitnessChart collects the best and mean fitness of every generation of a
+// run to draw them as a line chart
+type FitnessChart struct {
+	generations []int
+	best        []int64
+	mean        []float64
+}
+
+// the colors of a chart
+var (
+	chartBackground = color.RGBA{255, 255, 255, 255}
+	chartAxes       = color.RGBA{96, 96, 96, 255}
+	chartText       = color.RGBA{32, 32, 32, 255}
+	chartBest       = color.RGBA{31, 119, 180, 255}
+	chartMean       = color.RGBA{255, 127, 14, 255}
+)
+
+// the space around the plot of a chart for the labels, in pixels
+const (
+	chartLeft   = 70
+	chartRight  = 20
+	chartTop    = 20
+	chartBottom = 30
+)
+
+// Add the best and mean fitness of the generation of the stats
+func (c *FitnessChart) Add(stats Stats) {
+	c.generations = append(c.generations, stats.Generations)
+	c.best = append(c.best, stats.Best)
+	c.mean = append(c.mean, stats.Mean)
+}
+
+// Draw the chart on a w x h image, the generations go across and the fitness
+// up, with the best fitness in blue and the mean in orange
+func (c *FitnessChart) Draw(w int, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Rect, image.NewUniform(chartBackground), image.Point{}, draw.Src)
+	plot := image.Rect(chartLeft, chartTop, w-chartRight, h-chartBottom)
+	if plot.Dx() < 2 || plot.Dy() < 2 {
+		return img
+	}
+	drawLine(img, plot.Min.X, plot.Min.Y, plot.Min.X, plot.Max.Y, chartAxes)
+	drawLine(img, plot.Min.X, plot.Max.Y, plot.Max.X, plot.Max.Y, chartAxes)
+	if len(c.generations) == 0 {
+		return img
+	}
+
+	first, last := c.generations[0], c.generations[len(c.generations)-1]
+	low, high := float64(c.best[0]), c.mean[0]
+	for i := range c.generations {
+		low = min(low, float64(c.best[i]), c.mean[i])
+		high = max(high, float64(c.best[i]), c.mean[i])
+	}
+	x := func(generation int) int {
+		return plot.Min.X + (generation-first)*(plot.Dx()-1)/max(last-first, 1)
+	}
+	y := func(fitness float64) int {
+		if high == low {
+			return plot.Min.Y + plot.Dy()/2
+		}
+		return plot.Max.Y - 1 - int((fitness-low)/(high-low)*float64(plot.Dy()-1))
+	}
+	for i := 1; i < len(c.generations); i++ {
+		drawLine(img, x(c.generations[i-1]), y(c.mean[i-1]), x(c.generations[i]), y(c.mean[i]), chartMean)
+		drawLine(img, x(c.generations[i-1]), y(float64(c.best[i-1])), x(c.generations[i]), y(float64(c.best[i])), chartBest)
+	}
+
+	// the range of each axis and a legend
+	face := basicfont.Face7x13
+	label := func(s string, px int, py int, col color.RGBA) {
+		d := font.Drawer{Dst: img, Src: image.NewUniform(col), Face: face, Dot: fixed.P(px, py)}
+		d.DrawString(s)
+	}
+	width := func(s string) int {
+		return font.MeasureString(face, s).Ceil()
+	}
+	top, bottom := strconv.FormatInt(int64(high), 10), strconv.FormatInt(int64(low), 10)
+	label(top, plot.Min.X-6-width(top), plot.Min.Y+10, chartText)
+	label(bottom, plot.Min.X-6-width(bottom), plot.Max.Y, chartText)
+	start, end := strconv.Itoa(first), strconv.Itoa(last)
+	label(start, plot.Min.X, plot.Max.Y+16, chartText)
+	label(end, plot.Max.X-width(end), plot.Max.Y+16, chartText)
+	label("generation", (plot.Min.X+plot.Max.X-width("generation"))/2, plot.Max.Y+16, chartText)
+	label("best", plot.Max.X-width("best mean"), plot.Min.Y+10, chartBest)
+	label("mean", plot.Max.X-width("mean"), plot.Min.Y+10, chartMean)
+	return img
+}
+
+// draw a line from (x0, y0) to (x1, y1) with Bresenham's algorithm
+func drawLine(img *image.RGBA, x0 int, y0 int, x1 int, y1 int, col color.RGBA) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		img.SetRGBA(x0, y0, col)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// the absolute value of v
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
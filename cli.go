@@ -0,0 +1,656 @@
+package ga
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"log/slog"
+	"math/rand"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// RegisterRunFlags registers the flags of the parameters of the config on
+// the flag set, with the values the config has as their defaults
+func RegisterRunFlags(fs *flag.FlagSet, cfg *RunConfig) {
+	fs.Float64Var(&cfg.MutationRate, "mutation-rate", cfg.MutationRate, "chance of each gene mutating")
+	fs.StringVar(&cfg.MutationSchedule, "mutation-schedule", cfg.MutationSchedule, "anneal the mutation rate from -mutation-start times -mutation-rate down to it with one of "+strings.Join(ScheduleNames(), ", ")+", or keep it fixed if empty")
+	fs.Float64Var(&cfg.MutationStart, "mutation-start", cfg.MutationStart, "what -mutation-schedule multiplies the mutation rate by at the start")
+	fs.IntVar(&cfg.MutationGenerations, "mutation-generations", cfg.MutationGenerations, "number of generations -mutation-schedule takes to anneal the mutation rate")
+	fs.IntVar(&cfg.Hypermutation, "hypermutation", cfg.Hypermutation, "multiply the mutation rate after this many generations without the fitness improving, 0 never does")
+	fs.Float64Var(&cfg.HypermutationFactor, "hypermutation-factor", cfg.HypermutationFactor, "what -hypermutation multiplies the mutation rate by")
+	fs.IntVar(&cfg.HypermutationBurst, "hypermutation-burst", cfg.HypermutationBurst, "number of generations the mutation rate takes to decay back after -hypermutation")
+	fs.Float64Var(&cfg.AdaptiveMutation, "adaptive-mutation", cfg.AdaptiveMutation, "adapt the mutation rate by this factor every generation so about a fifth of the children improve on their parents, e.g. 1.1 (0 keeps it fixed)")
+	fs.IntVar(&cfg.PopSize, "pop", cfg.PopSize, "size of the population")
+	fs.IntVar(&cfg.PoolSize, "pool", cfg.PoolSize, "max size of the breeding pool")
+	fs.Int64Var(&cfg.FitnessLimit, "fitness-limit", cfg.FitnessLimit, "stop once the fitness is below this")
+	fs.IntVar(&cfg.MaxGenerations, "max-generations", cfg.MaxGenerations, "stop after this many generations, counting those of a resumed checkpoint (0 means no limit)")
+	fs.StringVar(&cfg.Fitness, "fitness", cfg.Fitness, "how to compare the evolved image with the target: "+strings.Join(FitnessNames(), ", "))
+	fs.IntVar(&cfg.SampleRate, "sample-rate", cfg.SampleRate, "only compare every nth pixel when calculating fitness, 1 compares every pixel")
+	fs.Int64Var(&cfg.ExactBelow, "exact-below", cfg.ExactBelow, "compare every pixel again once the fitness is below this, 0 means never")
+	fs.IntVar(&cfg.Pyramid, "pyramid", cfg.Pyramid, "compare the images halved this many times at the start and at twice the size each time the fitness improves, 0 compares them at full size")
+	fs.Float64Var(&cfg.PyramidStep, "pyramid-step", cfg.PyramidStep, "fraction the fitness has to improve by before comparing the images at the next size up with -pyramid")
+	fs.IntVar(&cfg.CacheSize, "cache-size", cfg.CacheSize, "number of fitness values remembered so identical organisms aren't evaluated again, 0 turns the cache off")
+	fs.StringVar(&cfg.Backend, "backend", cfg.Backend, "score each generation's children as one batch on this backend, one of "+strings.Join(BackendNames(), ", ")+", or on their own if empty")
+	fs.StringVar(&cfg.Selection, "selection", cfg.Selection, "how to pick the organisms that breed: "+strings.Join(SelectorNames(), ", "))
+	fs.IntVar(&cfg.TournamentSize, "tournament-size", cfg.TournamentSize, "number of organisms in each tournament with -selection tournament")
+	fs.StringVar(&cfg.Crossover, "crossover", cfg.Crossover, "how a child takes its genes from its parents: "+strings.Join(CrossoverNames(), ", "))
+	fs.Float64Var(&cfg.BlendAlpha, "blend-alpha", cfg.BlendAlpha, "how far past its parents a child's colors and positions can be with -crossover blend, as a fraction of the distance between them")
+	fs.IntVar(&cfg.Elite, "elite", cfg.Elite, "number of the fittest organisms carried over unchanged into each new generation")
+	fs.IntVar(&cfg.SteadyState, "steady-state", cfg.SteadyState, "breed only this many children each generation, which replace the least fit organisms if they're fitter, 0 breeds whole generations")
+	fs.StringVar(&cfg.Strategy, "strategy", cfg.Strategy, "how each generation is bred: "+strings.Join(StrategyNames(), ", ")+", plus and comma being the (μ+λ) and (μ,λ) evolution strategies with μ the population size and hill-climb the (1+1) hill climber, which climbs every organism on its own, -pop 1 climbs one")
+	fs.IntVar(&cfg.Lambda, "lambda", cfg.Lambda, "number of children bred each generation with -strategy plus or comma, 0 breeds as many as the population size")
+	fs.Float64Var(&cfg.Temperature, "temperature", cfg.Temperature, "temperature -strategy anneal starts at, a copy of an organism this much less fit taking its place with a chance of 1/e. Every organism is annealed on its own, -pop 1 anneals one")
+	fs.StringVar(&cfg.Cooling, "cooling", cfg.Cooling, "how the temperature cools down every generation with -strategy anneal: "+strings.Join(CoolingNames(), ", "))
+	fs.Float64Var(&cfg.CoolingRate, "cooling-rate", cfg.CoolingRate, "rate the temperature cools down at with -strategy anneal, the factor it's multiplied by every generation with exponential cooling")
+	fs.IntVar(&cfg.Stagnation, "stagnation", cfg.Stagnation, "replace the least fit organisms with new random ones after this many generations without the fitness improving, 0 never does")
+	fs.Float64Var(&cfg.Restart, "restart", cfg.Restart, "fraction of the population replaced with -stagnation, 1 keeps only the -elite")
+	fs.IntVar(&cfg.Islands, "islands", cfg.Islands, "split the population into this many islands that evolve side by side, 0 or 1 evolves it as a whole")
+	fs.IntVar(&cfg.MigrationInterval, "migration-interval", cfg.MigrationInterval, "number of generations between migrations from each island to the next with -islands")
+	fs.IntVar(&cfg.Migrants, "migrants", cfg.Migrants, "number of the fittest organisms of each island copied to other islands at each migration")
+	fs.StringVar(&cfg.Topology, "topology", cfg.Topology, "which islands the migrants of each island go to: "+strings.Join(TopologyNames(), ", "))
+	fs.Int64Var(&cfg.Seed, "seed", cfg.Seed, "make every random number of the run from this seed so the run can be repeated exactly, 0 picks a seed and prints it")
+	fs.StringVar(&cfg.RNG, "rng", cfg.RNG, "source of random numbers, one of "+strings.Join(RandSourceNames(), ", ")+", xoshiro is quicker, empty uses go")
+}
+
+// CLI is the command line of a demo that evolves an image. It registers the
+// flags of the run and of what's shown and saved of it, loads the target and
+// shows and saves the run as it goes. G is what the demo saves of a genome in
+// a checkpoint.
+//
+// The demo sets the hooks, calls Start once the flags are parsed, passes
+// Progress, Improved and Checkpoint on to its run and evolves it with Run.
+type CLI[G any] struct {
+	// Image returns the picture of a genome of the run
+	Image func(g Genome) *image.RGBA
+	// SaveGenome saves what the demo keeps of the best genome to the
+	// directory on top of its picture, it prints what it can't save
+	SaveGenome func(dir string, best Genome)
+	// SaveCheckpoint saves the checkpoint to the file
+	SaveCheckpoint func(filePath string, c Checkpoint[G]) error
+	// SaveHallOfFame saves the genomes of the hall of fame entries to the
+	// directory, fittest first, and returns the paths of their pictures
+	SaveHallOfFame func(dir string, entries []Fame) ([]string, error)
+	// OutDir is the directory everything is saved to
+	OutDir string
+	// Original is the size of the target before it's shrunk with
+	// -max-dimension, Start sets it
+	Original image.Point
+
+	fs  *flag.FlagSet
+	cfg *RunConfig
+	// the progress is printed and the best genome saved every every
+	// generations
+	every int
+
+	configPath      string
+	targetPath      string
+	maxDim          int
+	framesDir       string
+	frameEvery      int
+	gifPath         string
+	gifDelay        int
+	videoPath       string
+	videoFPS        int
+	statsPath       string
+	chart           bool
+	chartEvery      int
+	metricsAddr     string
+	pprofAddr       string
+	showProgress    bool
+	showDashboard   bool
+	serveAddr       string
+	controlAddr     string
+	showWindow      bool
+	imageProtocol   string
+	noPreview       bool
+	quiet           bool
+	logFormat       string
+	logLevelName    string
+	eventsPath      string
+	timeout         time.Duration
+	showHeatmap     bool
+	hallOfFame      int
+	checkpointEvery int
+	weightMask      string
+	edgeWeight      float64
+
+	target *image.RGBA
+	level  slog.Level
+	logger *slog.Logger
+	ctx    context.Context
+	// what the run is shown and saved with, nil for the ones that aren't
+	// asked for
+	anim         *Animation
+	video        *Video
+	statsCSV     *StatsCSV
+	events       *EventLog
+	fitnessChart *FitnessChart
+	metrics      *Metrics
+	bar          *ProgressBar
+	webUI        *WebUI
+	dashboard    *Dashboard
+	window       *Window
+	// the best genome of the last generation, for the dashboard to save
+	current Genome
+	// the last checkpoint, kept so it can be saved if the run is stopped
+	last Checkpoint[G]
+}
+
+// NewCLI registers the flags of the run with the parameters of the config
+// and of the output on the flag set. The progress is printed and the best
+// genome saved every every generations, and that's how many generations
+// apart the frames are unless -frame-every says otherwise.
+func NewCLI[G any](fs *flag.FlagSet, cfg *RunConfig, every int) *CLI[G] {
+	c := &CLI[G]{fs: fs, cfg: cfg, every: every}
+	RegisterRunFlags(fs, cfg)
+	fs.StringVar(&c.configPath, "config", "", "YAML or TOML file with the options to run with, flags on the command line override it")
+	fs.StringVar(&c.targetPath, "target", "./ml.png", "image to evolve towards")
+	fs.IntVar(&c.maxDim, "max-dimension", 0, "shrink the target so neither side is longer than this before evolving, 0 keeps its size")
+	fs.StringVar(&c.OutDir, "out", ".", "directory to save evolved.png, genome.gob and heatmap.png to")
+	fs.StringVar(&c.framesDir, "frames", "", "directory to save numbered PNG frames of the evolving image to")
+	fs.IntVar(&c.frameEvery, "frame-every", every, "number of generations between frames of -frames, -gif and -video")
+	fs.StringVar(&c.gifPath, "gif", "", "save an animated GIF of the evolution at the end of the run, made from the saved frames with -frames")
+	fs.IntVar(&c.gifDelay, "gif-delay", 10, "delay between GIF frames in 100ths of a second")
+	fs.StringVar(&c.videoPath, "video", "", "encode a timelapse video of the evolution with ffmpeg, e.g. out.mp4")
+	fs.IntVar(&c.videoFPS, "video-fps", 30, "frames per second of the -video")
+	fs.StringVar(&c.statsPath, "stats-csv", "", "append a row of stats for every generation to this CSV file, e.g. run.csv")
+	fs.BoolVar(&c.chart, "chart", false, "save a chart of the best and mean fitness of every generation to fitness.png at the end of the run")
+	fs.IntVar(&c.chartEvery, "chart-every", 0, "also save the -chart every n generations, 0 means only at the end")
+	fs.StringVar(&c.metricsAddr, "metrics", "", "serve Prometheus metrics of the run on /metrics of this address, e.g. :9090")
+	fs.StringVar(&c.pprofAddr, "pprof", "", "serve the profiles of net/http/pprof on /debug/pprof/ of this address during the run, e.g. :6060")
+	fs.BoolVar(&c.showProgress, "progress", false, fmt.Sprintf("show a progress bar with the generations a second and an estimate of the time left instead of printing the progress every %d generations", every))
+	fs.BoolVar(&c.showDashboard, "tui", false, "show a full screen dashboard of the run with a preview of the best image, p pauses, s saves and q quits")
+	fs.StringVar(&c.serveAddr, "serve", "", "serve a page with the best image, a fitness chart and the parameters of the run, updated every generation, on this address, e.g. :8080, with the best image as an MJPEG stream on /stream.mjpeg and a PNG on /best.png")
+	fs.StringVar(&c.controlAddr, "control", "", "serve a REST API to pause and resume the run, change its mutation-rate and pool-size and save a checkpoint on this address, e.g. :8081")
+	fs.BoolVar(&c.showWindow, "gui", false, "show the best image and the stats of the run in a desktop window, for terminals that cannot show images, needs a build with -tags gui")
+	fs.StringVar(&c.imageProtocol, "preview", "auto", "how to show images on the terminal, auto detects what the terminal can show, or one of "+strings.Join(ImageProtocolNames(), ", ")+", kitty works in kitty, sixel in xterm, mlterm, foot and WezTerm, and blocks in any terminal with 24 bit color")
+	fs.BoolVar(&c.noPreview, "no-preview", false, "show no images on the terminal, same as -preview none")
+	fs.BoolVar(&c.quiet, "quiet", false, "only print a line when the fitness improves, without images, instead of the progress of the run")
+	fs.StringVar(&c.logFormat, "log-format", "", "log the progress of the run as text or json records instead of showing it for people to read")
+	fs.StringVar(&c.logLevelName, "log-level", "info", "how much progress to log, one of "+strings.Join(LogLevelNames(), ", ")+", debug logs every generation")
+	fs.StringVar(&c.eventsPath, "events", "", "append JSON lines of what happens during the run, every generation, improvement, checkpoint and the end of the run, to this file, or - for stdout")
+	fs.DurationVar(&c.timeout, "timeout", 0, "stop evolving after this long, e.g. 30m (0 means no limit)")
+	fs.DurationVar(&c.timeout, "max-duration", 0, "same as -timeout")
+	fs.BoolVar(&c.showHeatmap, "heatmap", false, "also save a heatmap of where the evolved image differs from the target")
+	fs.IntVar(&c.hallOfFame, "hall-of-fame", 0, "keep the n fittest organisms of the whole run and save them to hall_of_fame in -out at the end, 0 keeps none")
+	fs.IntVar(&c.checkpointEvery, "checkpoint-every", 0, "save the whole population to checkpoint.gob every n generations, 0 means only when the run is stopped early")
+	fs.StringVar(&c.weightMask, "weight-mask", "", "grayscale image the size of the target, brighter pixels count more towards the fitness and black ones not at all, works with the diff and lab fitness")
+	fs.Float64Var(&c.edgeWeight, "edge-weight", 0, "how many times more the strongest edges of the target count towards the fitness than its flat regions, 0 means edges count the same")
+	return c
+}
+
+// Start loads the config file, sets up the logging and the output
+// directories and loads the target, shrunk to -max-dimension, with the
+// weights of its pixels. It's called once the flags are parsed.
+func (c *CLI[G]) Start() (*image.RGBA, error) {
+	if c.configPath != "" {
+		err := LoadConfigFile(c.configPath, c.fs)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load config file: %w", err)
+		}
+	}
+	if c.frameEvery < 1 {
+		return nil, errors.New("cannot save frames: -frame-every must be at least 1")
+	}
+	if c.hallOfFame < 0 {
+		return nil, errors.New("cannot keep a hall of fame: the size cannot be negative")
+	}
+	if c.edgeWeight < 0 {
+		return nil, errors.New("cannot weight edges: the edge weight cannot be negative")
+	}
+	if c.quiet {
+		// the improvements are printed on their own
+		c.logLevelName, c.noPreview = "quiet", true
+	}
+	if c.noPreview {
+		c.imageProtocol = "none"
+	}
+	var err error
+	c.level, err = ParseLogLevel(c.logLevelName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot log: %w", err)
+	}
+	err = SetImageProtocol(c.imageProtocol)
+	if err != nil {
+		return nil, fmt.Errorf("cannot show images: %w", err)
+	}
+	if c.logFormat != "" {
+		c.logger, err = NewLogger(os.Stdout, c.logFormat, c.level)
+		if err != nil {
+			return nil, fmt.Errorf("cannot log: %w", err)
+		}
+	}
+	err = os.MkdirAll(c.OutDir, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create output directory: %w", err)
+	}
+	if c.framesDir != "" {
+		err := os.MkdirAll(c.framesDir, 0755)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create frames directory: %w", err)
+		}
+	}
+
+	target, err := Load(c.targetPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load target image: %w", err)
+	}
+	c.Original = target.Rect.Size()
+	if c.maxDim > 0 {
+		target = Downscale(target, c.maxDim)
+	}
+	if c.weightMask != "" {
+		c.cfg.Weights, err = LoadWeights(c.weightMask, target)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load weight mask: %w", err)
+		}
+	}
+	if c.edgeWeight > 0 {
+		// edges weigh on top of the mask
+		edges := EdgeWeights(target, c.edgeWeight)
+		if c.cfg.Weights == nil {
+			c.cfg.Weights = edges
+		} else {
+			for i := range c.cfg.Weights {
+				c.cfg.Weights[i] *= edges[i]
+			}
+		}
+	}
+	c.target = target
+	return target, nil
+}
+
+// save the picture and genome of the best genome, and the heatmap if asked
+// for
+func (c *CLI[G]) saveBest(best Genome) {
+	img := c.Image(best)
+	err := Save(filepath.Join(c.OutDir, "evolved.png"), img)
+	if err != nil {
+		fmt.Println("Cannot save evolved image:", err)
+	}
+	c.SaveGenome(c.OutDir, best)
+	if c.showHeatmap {
+		err = Save(filepath.Join(c.OutDir, "heatmap.png"), Heatmap(img, c.target))
+		if err != nil {
+			fmt.Println("Cannot save heatmap:", err)
+		}
+	}
+}
+
+// stop logging events if they can't be written
+func (c *CLI[G]) logEvent(err error) {
+	if err != nil {
+		fmt.Println("Cannot log events:", err)
+		c.events.Close()
+		c.events = nil
+	}
+}
+
+// stop adding to the video if ffmpeg goes away
+func (c *CLI[G]) addVideoFrame(rgba *image.RGBA) {
+	err := c.video.Add(rgba)
+	if err != nil {
+		fmt.Println("Cannot add video frame:", err)
+		c.video.Close()
+		c.video = nil
+	}
+}
+
+// save the fitness chart
+func (c *CLI[G]) saveChart() {
+	err := Save(filepath.Join(c.OutDir, "fitness.png"), c.fitnessChart.Draw(800, 400))
+	if err != nil {
+		fmt.Println("Cannot save fitness chart:", err)
+	}
+}
+
+// save the last checkpoint
+func (c *CLI[G]) saveLast() bool {
+	err := c.SaveCheckpoint(filepath.Join(c.OutDir, "checkpoint.gob"), c.last)
+	if err != nil {
+		fmt.Println("Cannot save checkpoint:", err)
+		return false
+	}
+	return true
+}
+
+// Improved prints and logs that the fitness improved
+func (c *CLI[G]) Improved(generation int, best Genome) {
+	if c.quiet && c.logger == nil {
+		fmt.Printf("Generation %d: fitness improved to %d\n", generation, best.Fitness())
+	}
+	if c.events != nil {
+		c.logEvent(c.events.Improvement(generation, best.Fitness()))
+	}
+}
+
+// Checkpoint keeps the checkpoint, and saves it every -checkpoint-every
+// generations
+func (c *CLI[G]) Checkpoint(cp Checkpoint[G]) {
+	c.last = cp
+	if c.checkpointEvery > 0 && cp.Generation%c.checkpointEvery == 0 {
+		if c.saveLast() && c.events != nil {
+			c.logEvent(c.events.Checkpoint(cp.Generation, filepath.Join(c.OutDir, "checkpoint.gob")))
+		}
+	}
+}
+
+// Progress shows the stats of the generation just bred and its best genome,
+// and saves what's asked for of them
+func (c *CLI[G]) Progress(stats RunStats, best Genome) {
+	img := c.Image(best)
+	if c.webUI != nil {
+		c.webUI.Update(stats.Stats, stats.MutationRate, img)
+	}
+	if c.window != nil {
+		c.window.Update(stats.Stats, stats.MutationRate, img)
+	}
+	if c.dashboard != nil {
+		c.current = best
+		c.dashboard.Update(stats.Stats, stats.MutationRate, img)
+	}
+	if c.bar != nil {
+		c.bar.Update(stats.Stats)
+	}
+	if c.metrics != nil {
+		c.metrics.Observe(stats.Stats)
+	}
+	if c.fitnessChart != nil {
+		c.fitnessChart.Add(stats.Stats)
+		if c.chartEvery > 0 && stats.Generations%c.chartEvery == 0 {
+			c.saveChart()
+		}
+	}
+	if c.events != nil {
+		c.logEvent(c.events.Generation(stats.Stats))
+	}
+	// stop logging stats if they can't be written
+	if c.statsCSV != nil {
+		err := c.statsCSV.Add(stats.Stats, stats.MutationRate)
+		if err != nil {
+			fmt.Println("Cannot log stats:", err)
+			c.statsCSV.Close()
+			c.statsCSV = nil
+		}
+	}
+	// every generation is logged at the debug level and every every
+	// generations at the info level
+	at := slog.LevelDebug
+	if stats.Generations%c.every == 0 {
+		at = slog.LevelInfo
+		c.saveBest(best)
+	}
+	if c.logger != nil {
+		c.logger.Log(c.ctx, at, "generation", append(StatsAttrs(stats.Stats), slog.Float64("mutation_rate", stats.MutationRate))...)
+	} else if c.bar == nil && c.dashboard == nil && c.level <= at {
+		fmt.Printf("\nTime taken so far: %s | generation: %d | fitness: %d | mean: %.0f ± %.0f | diversity: %.2f | pool size: %d",
+			stats.Elapsed, stats.Generations, stats.Fitness, stats.Mean, stats.StdDev, stats.Diversity, stats.PoolSize)
+		fmt.Println()
+		if at == slog.LevelInfo && c.window == nil {
+			PrintImage(img.SubImage(img.Rect))
+		}
+	}
+	if stats.Generations%c.frameEvery != 0 {
+		return
+	}
+	if c.framesDir != "" {
+		err := SaveFrame(c.framesDir, stats.Generations, img)
+		if err != nil {
+			fmt.Println("Cannot write frame:", err)
+		}
+	}
+	if c.anim != nil {
+		c.anim.Add(img)
+	}
+	if c.video != nil {
+		c.addVideoFrame(img)
+	}
+}
+
+// Run sets up what the run is shown and saved with, evolves it with evolve
+// until it's done, the process is interrupted or -timeout runs out, and
+// saves and prints how it went. A seed is picked and printed for the run
+// unless it's resumed from a checkpoint, which has its own. It returns the
+// best genome found.
+func (c *CLI[G]) Run(resumed bool, evolve func(ctx context.Context) (Genome, Stats, error)) (Genome, error) {
+	// stop gracefully on Ctrl-C, when the process is terminated or when the
+	// timeout runs out
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	c.ctx = ctx
+
+	// the seed is picked here rather than by Evolve so it can be told
+	if c.cfg.Seed == 0 && !resumed {
+		c.cfg.Seed = rand.Int63()
+		if c.logger != nil {
+			c.logger.Info("seed", slog.Int64("seed", c.cfg.Seed))
+		} else if c.level <= slog.LevelInfo {
+			fmt.Printf("Seed: %d, run with -seed %d to repeat the run\n", c.cfg.Seed, c.cfg.Seed)
+		}
+	}
+	if c.logger == nil && c.level <= slog.LevelInfo && !c.showWindow {
+		PrintImage(c.target.SubImage(c.target.Rect))
+	}
+
+	w, h := c.target.Rect.Dx(), c.target.Rect.Dy()
+	// without -frames the GIF is collected in memory as the run goes
+	if c.gifPath != "" && c.framesDir == "" {
+		c.anim = NewAnimation(c.gifDelay)
+	}
+	var err error
+	if c.videoPath != "" {
+		c.video, err = NewVideo(c.videoPath, w, h, c.videoFPS)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create video: %w", err)
+		}
+	}
+	if c.statsPath != "" {
+		c.statsCSV, err = NewStatsCSV(c.statsPath)
+		if err != nil {
+			return nil, fmt.Errorf("cannot log stats: %w", err)
+		}
+		defer c.statsCSV.Close()
+	}
+	if c.eventsPath != "" {
+		c.events, err = NewEventLog(c.eventsPath)
+		if err != nil {
+			return nil, fmt.Errorf("cannot log events: %w", err)
+		}
+		defer c.events.Close()
+	}
+	if c.chart {
+		c.fitnessChart = &FitnessChart{}
+	}
+	if c.pprofAddr != "" {
+		err = ServePprof(c.pprofAddr)
+		if err != nil {
+			return nil, fmt.Errorf("cannot serve profiles: %w", err)
+		}
+	}
+	if c.metricsAddr != "" {
+		c.metrics = NewMetrics()
+		err = c.metrics.Serve(c.metricsAddr)
+		if err != nil {
+			return nil, fmt.Errorf("cannot serve metrics: %w", err)
+		}
+	}
+	// the progress bar takes the place of the progress printed for people
+	if c.showProgress && !c.showDashboard && c.logger == nil && c.level <= slog.LevelInfo {
+		c.bar = NewProgressBar(os.Stdout, c.cfg.FitnessLimit, c.cfg.MaxGenerations)
+	}
+	// the dashboard and the web page show the flags the run was started
+	// with
+	var params []string
+	c.fs.Visit(func(f *flag.Flag) {
+		params = append(params, f.Name+" "+f.Value.String())
+	})
+	if c.serveAddr != "" {
+		c.webUI = NewWebUI(params)
+		err = c.webUI.Serve(c.serveAddr)
+		if err != nil {
+			return nil, fmt.Errorf("cannot serve web page: %w", err)
+		}
+	}
+	// the dashboard takes the place of the progress printed for people too
+	if c.showDashboard && c.logger == nil {
+		c.dashboard = NewDashboard(params)
+		c.dashboard.OnSave = func() {
+			c.saveBest(c.current)
+		}
+		c.dashboard.OnQuit = stop
+	}
+	if c.showWindow {
+		c.window, err = NewWindow("ga "+filepath.Base(c.targetPath), w, h)
+		if err != nil {
+			return nil, fmt.Errorf("cannot show window: %w", err)
+		}
+		c.window.OnClose = stop
+	}
+	if c.controlAddr != "" {
+		c.cfg.Control = NewControl()
+		c.cfg.Control.Action("checkpoint", func() error {
+			if c.last.Population == nil {
+				return errors.New("there's no checkpoint before the first generation")
+			}
+			if !c.saveLast() {
+				return errors.New("cannot save checkpoint")
+			}
+			if c.events != nil {
+				c.logEvent(c.events.Checkpoint(c.last.Generation, filepath.Join(c.OutDir, "checkpoint.gob")))
+			}
+			return nil
+		})
+		err = ServeControl(c.controlAddr, c.cfg.Control)
+		if err != nil {
+			return nil, fmt.Errorf("cannot serve control API: %w", err)
+		}
+	}
+	if c.hallOfFame > 0 {
+		c.cfg.HallOfFame = NewHallOfFame(c.hallOfFame)
+	}
+
+	var best Genome
+	var stats Stats
+	run := func() {
+		best, stats, err = evolve(ctx)
+	}
+	if c.window != nil {
+		// the window is shown on the main goroutine, the run goes on
+		// another until it's done
+		err := c.window.Run(run)
+		if err != nil {
+			fmt.Println("Cannot show window:", err)
+		}
+	} else {
+		run()
+	}
+	if c.dashboard != nil {
+		c.dashboard.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	// stopping the signals cancels the context too, so find out why the run
+	// stopped first
+	stopped := ctx.Err()
+	// a second Ctrl-C while we're saving kills the program as usual
+	stop()
+
+	c.saveBest(best)
+	if c.fitnessChart != nil {
+		c.saveChart()
+	}
+	if stopped == nil && c.cfg.MaxGenerations > 0 && stats.Generations > c.cfg.MaxGenerations {
+		stopped = fmt.Errorf("bred %d generations", c.cfg.MaxGenerations)
+	}
+	if c.bar != nil {
+		c.bar.Done()
+	}
+	saved := false
+	if stopped != nil && c.last.Population != nil {
+		saved = c.saveLast()
+	}
+	if c.logger != nil {
+		attrs := StatsAttrs(stats)
+		if stopped != nil {
+			attrs = append(attrs, slog.String("stopped", stopped.Error()))
+		}
+		if saved {
+			attrs = append(attrs, slog.String("checkpoint", filepath.Join(c.OutDir, "checkpoint.gob")))
+		}
+		c.logger.Info("finished", attrs...)
+	} else if c.level <= slog.LevelInfo {
+		if stopped != nil {
+			fmt.Printf("\nStopped early: %s", stopped)
+		}
+		if saved {
+			fmt.Printf("\nSaved checkpoint at generation %d, continue with -resume %s", c.last.Generation,
+				filepath.Join(c.OutDir, "checkpoint.gob"))
+		}
+		fmt.Printf("\nTotal time taken: %s | generations: %d | fitness: %d\n", stats.Elapsed, stats.Generations, stats.Fitness)
+	}
+	// the events go after the last line, in case they go to stdout
+	if c.events != nil && saved {
+		c.logEvent(c.events.Checkpoint(c.last.Generation, filepath.Join(c.OutDir, "checkpoint.gob")))
+	}
+	if c.events != nil {
+		reason := "reached the fitness limit"
+		if stopped != nil {
+			reason = stopped.Error()
+		}
+		c.logEvent(c.events.Finished(stats, reason))
+	}
+	if c.cfg.HallOfFame != nil {
+		// list when each of the fittest was found
+		entries := c.cfg.HallOfFame.Entries()
+		paths, err := c.SaveHallOfFame(filepath.Join(c.OutDir, "hall_of_fame"), entries)
+		if err != nil {
+			fmt.Println("Cannot save hall of fame:", err)
+		} else {
+			fmt.Println("Hall of fame:")
+			for i, e := range entries {
+				fmt.Printf("%d. fitness %d, found in generation %d after %s: %s\n", i+1, e.Fitness, e.Generation, e.Elapsed, paths[i])
+			}
+		}
+	}
+
+	if c.framesDir != "" && c.gifPath != "" {
+		skipped, err := AssembleGIF(c.framesDir, c.gifPath, c.gifDelay)
+		for _, err := range skipped {
+			fmt.Println("Skipping frame:", err)
+		}
+		if err != nil {
+			fmt.Println("Cannot create GIF:", err)
+		}
+	}
+	// the video and the GIF end on the final image
+	if c.video != nil {
+		err := c.video.Add(c.Image(best))
+		if closeErr := c.video.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			fmt.Println("Cannot create video:", err)
+		}
+	}
+	if c.anim != nil {
+		c.anim.Add(c.Image(best))
+		err := c.anim.Save(c.gifPath)
+		if err != nil {
+			fmt.Println("Cannot create GIF:", err)
+		}
+	}
+	return best, nil
+}
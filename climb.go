@@ -0,0 +1,63 @@
+package ga
+
+import "math/rand"
+
+// climb every genome of the population on its own with the (1+1) hill
+// climber: a mutated copy of it takes its place only if it's fitter
+func (cfg Config) hillClimb(_ []Genome, population []Genome, seed int64, generations int) generation {
+	return cfg.climb(population, seed, generations, func(fitness int64, rng *rand.Rand) int64 {
+		return fitness - 1
+	})
+}
+
+// breed a mutated copy of every genome of the population, which takes its
+// place if its fitness is no more than limit of the genome's fitness. limit
+// is called concurrently with random numbers of the copy's own. Only copies
+// that take their place have to have an exact fitness, so none of the next
+// generation is estimated. The copies are bred on the same pool of workers
+// as naturalSelection's children.
+func (cfg Config) climb(population []Genome, seed int64, generations int, limit func(fitness int64, rng *rand.Rand) int64) generation {
+	rngs := cfg.childRands(seed, generations)
+	children := make([]Genome, len(population))
+	limits := make([]int64, len(population))
+	fitness := make([]int64, len(population))
+
+	parallel(0, len(population), func(i int) {
+		parent := population[i]
+		rng := rngs(i)
+		limits[i] = limit(parent.Fitness(), rng)
+		// a genome crossed over with itself is a copy of it
+		child := parent.Crossover(parent, rng)
+		child.Mutate(rng)
+		switch bounded, ok := child.(BoundedGenome); {
+		case cfg.Evaluate != nil:
+			// later, with the other children
+		case ok:
+			fitness[i], _ = bounded.FitnessBelow(limits[i])
+		default:
+			fitness[i] = child.Fitness()
+		}
+		children[i] = child
+	})
+	if cfg.Evaluate != nil {
+		cfg.Evaluate(children)
+		for i, child := range children {
+			fitness[i] = child.Fitness()
+		}
+	}
+
+	g := generation{population: make([]Genome, len(population)), poolSize: len(population), children: len(children)}
+	for i, child := range children {
+		if fitness[i] < population[i].Fitness() {
+			g.improved++
+		}
+		if fitness[i] <= limits[i] {
+			g.population[i] = child
+			g.replaced = append(g.replaced, population[i])
+		} else {
+			g.population[i] = population[i]
+			g.replaced = append(g.replaced, child)
+		}
+	}
+	return g
+}
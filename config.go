@@ -0,0 +1,63 @@
+package ga
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadConfigFile sets the flags in fs from a YAML or TOML file, picked by the
+// file's extension. The keys of the file are the flag names without the
+// dash, e.g.
+//
+//	mutation-rate: 0.01
+//	pop: 200
+//
+// Flags that were already set on the command line keep their values, so
+// they override the file.
+func LoadConfigFile(filePath string, fs *flag.FlagSet) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("cannot read file: %w", err)
+	}
+
+	values := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return fmt.Errorf("%s is not a .yaml, .yml or .toml file", filePath)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot decode file: %w", err)
+	}
+
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	for name, value := range values {
+		if fs.Lookup(name) == nil {
+			return fmt.Errorf("unknown option %q in %s", name, filePath)
+		}
+		if set[name] {
+			continue
+		}
+		switch value.(type) {
+		case []interface{}, map[string]interface{}:
+			return fmt.Errorf("option %q in %s must be a single value", name, filePath)
+		}
+		err = fs.Set(name, fmt.Sprint(value))
+		if err != nil {
+			return fmt.Errorf("option %q in %s: %w", name, filePath, err)
+		}
+	}
+	return nil
+}
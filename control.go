@@ -0,0 +1,266 @@
+package ga
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Control steers a run from other goroutines while it goes, e.g. from the
+// REST API served by ServeControl. It pauses the run, and changes its
+// settings and runs its actions between two generations, so they never race
+// with breeding. Evolve adds the pool-size setting of the run it's given the
+// control of.
+type Control struct {
+	mu       sync.Mutex
+	changed  *sync.Cond
+	paused   bool
+	queue    []func()
+	settings map[string]setting
+	actions  map[string]func() error
+}
+
+// a setting of a run, which is read and changed between generations
+type setting struct {
+	get func() float64
+	set func(value float64) error
+}
+
+// NewControl makes the control of a run
+func NewControl() *Control {
+	c := &Control{settings: map[string]setting{}, actions: map[string]func() error{}}
+	c.changed = sync.NewCond(&c.mu)
+	return c
+}
+
+// Setting adds a setting of the run with the name, e.g. mutation-rate, read
+// with get and changed with set between generations
+func (c *Control) Setting(name string, get func() float64, set func(value float64) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.settings[name] = setting{get: get, set: set}
+}
+
+// Action adds an action with the name, e.g. checkpoint, run between
+// generations
+func (c *Control) Action(name string, action func() error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.actions[name] = action
+}
+
+// Pause the run after the generation being bred
+func (c *Control) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paused = true
+}
+
+// Resume the run if it's paused
+func (c *Control) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paused = false
+	c.changed.Broadcast()
+}
+
+// Paused returns whether the run is paused
+func (c *Control) Paused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused
+}
+
+// Do runs f between two generations, even while the run is paused, and
+// returns its error. It waits for the run to get to the end of the
+// generation being bred, or for the context to be done.
+func (c *Control) Do(ctx context.Context, f func() error) error {
+	done := make(chan error, 1)
+	c.mu.Lock()
+	c.queue = append(c.queue, func() {
+		done <- f()
+	})
+	c.changed.Broadcast()
+	c.mu.Unlock()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Settings returns the value of every setting by name
+func (c *Control) Settings(ctx context.Context) (map[string]float64, error) {
+	values := map[string]float64{}
+	err := c.Do(ctx, func() error {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for name, s := range c.settings {
+			values[name] = s.get()
+		}
+		return nil
+	})
+	return values, err
+}
+
+// Set changes the setting with the name to the value
+func (c *Control) Set(ctx context.Context, name string, value float64) error {
+	c.mu.Lock()
+	s, ok := c.settings[name]
+	known := sortedNames(c.settings)
+	c.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("unknown setting %q, use one of %s", name, known)
+	}
+	return c.Do(ctx, func() error {
+		return s.set(value)
+	})
+}
+
+// Run runs the action with the name
+func (c *Control) Run(ctx context.Context, name string) error {
+	c.mu.Lock()
+	action, ok := c.actions[name]
+	known := sortedNames(c.actions)
+	c.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("unknown action %q, use one of %s", name, known)
+	}
+	return c.Do(ctx, action)
+}
+
+// the names of the settings or actions, sorted and separated by commas
+func sortedNames[V any](m map[string]V) string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// between is called by the run between two generations: it runs what was
+// queued and waits while the run is paused, until the context is done
+func (c *Control) between(ctx context.Context) {
+	if c == nil {
+		return
+	}
+	stop := context.AfterFunc(ctx, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.changed.Broadcast()
+	})
+	defer stop()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		for len(c.queue) > 0 {
+			f := c.queue[0]
+			c.queue = c.queue[1:]
+			c.mu.Unlock()
+			f()
+			c.mu.Lock()
+		}
+		if !c.paused || ctx.Err() != nil {
+			return
+		}
+		c.changed.Wait()
+	}
+}
+
+// the pool-size setting of a run, which changes the size of the pool and
+// selectors
+func (cfg *Config) poolSizeSetting(smallest int) (func() float64, func(value float64) error) {
+	get := func() float64 {
+		return float64(cfg.PoolSize)
+	}
+	set := func(value float64) error {
+		size := int(value)
+		if float64(size) != value || size < 1 || size >= smallest {
+			return fmt.Errorf("pool size must be a whole number between 1 and %d", smallest-1)
+		}
+		switch cfg.Selector.(type) {
+		case PoolSelector:
+			cfg.Selector = PoolSelector{Size: size}
+		case TruncationSelector:
+			cfg.Selector = TruncationSelector{Size: size}
+		default:
+			return errors.New("the selector has no pool size")
+		}
+		cfg.PoolSize = size
+		return nil
+	}
+	return get, set
+}
+
+// ServeControl serves a REST API to steer the run with the control on the
+// address, e.g. :8081, until the program exits:
+//
+//	GET  /control                   whether the run is paused and its settings
+//	POST /control/pause             pause the run
+//	POST /control/resume            resume the run
+//	PUT  /control/settings/{name}   change a setting to the number in the body
+//	POST /control/actions/{name}    run an action, e.g. checkpoint
+//
+// There's no authentication, the API is meant for a trusted network.
+func ServeControl(addr string, c *Control) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("cannot listen: %w", err)
+	}
+	reply := func(w http.ResponseWriter, err error) {
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /control", func(w http.ResponseWriter, r *http.Request) {
+		settings, err := c.Settings(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			Paused   bool               `json:"paused"`
+			Settings map[string]float64 `json:"settings"`
+		}{c.Paused(), settings})
+	})
+	mux.HandleFunc("POST /control/pause", func(w http.ResponseWriter, r *http.Request) {
+		c.Pause()
+		reply(w, nil)
+	})
+	mux.HandleFunc("POST /control/resume", func(w http.ResponseWriter, r *http.Request) {
+		c.Resume()
+		reply(w, nil)
+	})
+	mux.HandleFunc("PUT /control/settings/{name}", func(w http.ResponseWriter, r *http.Request) {
+		var value float64
+		body, err := io.ReadAll(io.LimitReader(r.Body, 64))
+		if err == nil {
+			value, err = strconv.ParseFloat(string(bytes.TrimSpace(body)), 64)
+		}
+		if err != nil {
+			reply(w, fmt.Errorf("the body must be a number: %w", err))
+			return
+		}
+		reply(w, c.Set(r.Context(), r.PathValue("name"), value))
+	})
+	mux.HandleFunc("POST /control/actions/{name}", func(w http.ResponseWriter, r *http.Request) {
+		reply(w, c.Run(r.Context(), r.PathValue("name")))
+	})
+	go http.Serve(lis, mux)
+	return nil
+}
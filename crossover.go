@@ -0,0 +1,132 @@
+package ga
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// Crossover picks which of its two parents every one of the n genes of a
+// child is copied from. The function it returns says whether gene i comes
+// from the first parent, and must be called for every gene in order.
+type Crossover func(n int, rng *rand.Rand) (fromFirst func(i int) bool)
+
+// crossovers are the built in crossovers by name
+var crossovers = map[string]Crossover{
+	"blend":     BlendCrossover,
+	"one-point": OnePointCrossover,
+	"spatial":   SpatialCrossover,
+	"two-point": TwoPointCrossover,
+	"uniform":   UniformCrossover,
+}
+
+// CrossoverNames returns the names of the built in crossovers
+func CrossoverNames() []string {
+	names := make([]string, 0, len(crossovers))
+	for name := range crossovers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewCrossover returns the built in crossover with the given name, an empty
+// name being one-point
+func NewCrossover(name string) (Crossover, error) {
+	if name == "" {
+		name = "one-point"
+	}
+	crossover, ok := crossovers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown crossover %q, use one of %s", name, strings.Join(CrossoverNames(), ", "))
+	}
+	return crossover, nil
+}
+
+// OnePointCrossover copies the genes up to a random point from the second
+// parent and the rest from the first
+func OnePointCrossover(n int, rng *rand.Rand) func(i int) bool {
+	mid := rng.Intn(n)
+	return func(i int) bool {
+		return i > mid
+	}
+}
+
+// TwoPointCrossover copies the genes between two random points from the
+// second parent and the rest from the first
+func TwoPointCrossover(n int, rng *rand.Rand) func(i int) bool {
+	a, b := rng.Intn(n), rng.Intn(n)
+	if a > b {
+		a, b = b, a
+	}
+	return func(i int) bool {
+		return i <= a || i > b
+	}
+}
+
+// UniformCrossover copies every gene from either parent with an even chance
+func UniformCrossover(n int, rng *rand.Rand) func(i int) bool {
+	// a random number gives the coin flips of 64 genes
+	var flips uint64
+	return func(i int) bool {
+		if i%64 == 0 {
+			flips = rng.Uint64()
+		}
+		first := flips&1 == 1
+		flips >>= 1
+		return first
+	}
+}
+
+// BlendCrossover picks the parent of every gene like UniformCrossover does.
+// Genomes whose genes are numbers can take the picked gene as the one to
+// blend with the other parent's, see Blend, and only copy what can't be
+// blended.
+func BlendCrossover(n int, rng *rand.Rand) func(i int) bool {
+	return UniformCrossover(n, rng)
+}
+
+// Blend returns a random value between a and b that can reach past either of
+// them by alpha times the distance between them (BLX-α). With an alpha of 0
+// it's an arithmetic crossover with a random weight, staying between them.
+func Blend(a float64, b float64, alpha float64, rng *rand.Rand) float64 {
+	lo, hi := min(a, b), max(a, b)
+	d := (hi - lo) * alpha
+	return lo - d + rng.Float64()*(hi-lo+2*d)
+}
+
+// SpatialCrossover cuts the genes in two like OnePointCrossover. Genomes
+// whose genes have places in a picture can cut the picture in two with
+// NewCut instead, taking the genes on each side of it from each parent, so
+// what's near each other stays together.
+func SpatialCrossover(n int, rng *rand.Rand) func(i int) bool {
+	return OnePointCrossover(n, rng)
+}
+
+// NewCut returns a random straight cut across a w x h picture, as likely to
+// be vertical, horizontal or diagonal at any angle, and whether the point x,
+// y is on the first side of it
+func NewCut(w int, h int, rng *rand.Rand) func(x int, y int) bool {
+	switch rng.Intn(3) {
+	case 0:
+		at := rng.Intn(w)
+		return func(x int, y int) bool {
+			return x > at
+		}
+	case 1:
+		at := rng.Intn(h)
+		return func(x int, y int) bool {
+			return y > at
+		}
+	}
+	// the side of a line through a random point is the sign of the cross
+	// product of the line's direction with the way to the point
+	cx, cy := rng.Float64()*float64(w), rng.Float64()*float64(h)
+	angle := rng.Float64() * math.Pi
+	dx, dy := math.Cos(angle), math.Sin(angle)
+	return func(x int, y int) bool {
+		return (float64(x)-cx)*dy-(float64(y)-cy)*dx > 0
+	}
+}
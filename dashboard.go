@@ -0,0 +1,223 @@
+package ga
+
+import (
+	"fmt"
+	"image"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// the number of generations of fitness the sparkline of a dashboard shows
+// at most, and its generation rate is worked out over
+const dashboardHistory = 120
+
+// the largest preview of a dashboard in characters, every character shows
+// two pixels one above the other
+const (
+	previewWidth  = 48
+	previewHeight = 24
+)
+
+// Dashboard is a full screen terminal dashboard of a run, showing a
+// sparkline of the fitness, the parameters of the run, the generation rate
+// and a preview of the best image. The run can be paused with p or space,
+// its best image saved with s and the run stopped with q or Ctrl-C.
+type Dashboard struct {
+	// OnSave is called from Update when s was pressed, it can be nil
+	OnSave func()
+	// OnQuit is called when q or Ctrl-C was pressed, it can be nil
+	OnQuit  func()
+	program *tea.Program
+	done    chan struct{}
+	mu      sync.Mutex
+	resumed *sync.Cond
+	paused  bool
+	save    bool
+}
+
+// NewDashboard takes over the terminal to show a dashboard of a run with
+// the parameters, e.g. "pop 250", until it's closed
+func NewDashboard(params []string) *Dashboard {
+	d := &Dashboard{done: make(chan struct{})}
+	d.resumed = sync.NewCond(&d.mu)
+	d.program = tea.NewProgram(dashboardModel{d: d, params: params}, tea.WithAltScreen())
+	go func() {
+		defer close(d.done)
+		d.program.Run()
+		// the run mustn't stay paused once there's no dashboard to resume it
+		d.mu.Lock()
+		d.paused = false
+		d.mu.Unlock()
+		d.resumed.Broadcast()
+	}()
+	return d
+}
+
+// Update the dashboard with the stats of the generation that was just bred
+// with the mutation rate, and the best image, which it doesn't keep. It
+// saves the best image if asked to, and waits while the run is paused.
+func (d *Dashboard) Update(stats Stats, mutationRate float64, best *image.RGBA) {
+	d.program.Send(dashboardUpdate{stats: stats, mutationRate: mutationRate, preview: preview(best)})
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for {
+		if d.save {
+			d.save = false
+			if d.OnSave != nil {
+				d.mu.Unlock()
+				d.OnSave()
+				d.mu.Lock()
+			}
+		}
+		if !d.paused {
+			return
+		}
+		d.resumed.Wait()
+	}
+}
+
+// Close the dashboard and give the terminal back
+func (d *Dashboard) Close() {
+	d.program.Quit()
+	<-d.done
+}
+
+// the image scaled down to fit the preview, keeping its aspect ratio
+func preview(img *image.RGBA) *image.RGBA {
+	return shrinkToFit(img, previewWidth, 2*previewHeight)
+}
+
+// dashboardUpdate is sent to the dashboard after every generation
+type dashboardUpdate struct {
+	stats        Stats
+	mutationRate float64
+	preview      *image.RGBA
+}
+
+// dashboardModel is the state of the dashboard shown
+type dashboardModel struct {
+	d            *Dashboard
+	params       []string
+	stats        Stats
+	mutationRate float64
+	preview      *image.RGBA
+	fitness      []int64
+	generations  []int
+	elapsed      []time.Duration
+	paused       bool
+}
+
+func (m dashboardModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m dashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case dashboardUpdate:
+		m.stats, m.mutationRate, m.preview = msg.stats, msg.mutationRate, msg.preview
+		if len(m.fitness) == dashboardHistory {
+			m.fitness, m.generations, m.elapsed = m.fitness[1:], m.generations[1:], m.elapsed[1:]
+		}
+		m.fitness = append(m.fitness, msg.stats.Fitness)
+		m.generations = append(m.generations, msg.stats.Generations)
+		m.elapsed = append(m.elapsed, msg.stats.Elapsed)
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "p", " ":
+			m.d.mu.Lock()
+			m.d.paused = !m.d.paused
+			m.paused = m.d.paused
+			m.d.mu.Unlock()
+			m.d.resumed.Broadcast()
+		case "s":
+			m.d.mu.Lock()
+			m.d.save = true
+			m.d.mu.Unlock()
+			m.d.resumed.Broadcast()
+		case "q", "ctrl+c":
+			if m.d.OnQuit != nil {
+				m.d.OnQuit()
+			}
+			m.d.mu.Lock()
+			m.d.paused = false
+			m.d.mu.Unlock()
+			m.d.resumed.Broadcast()
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m dashboardModel) View() string {
+	var b strings.Builder
+	state := "running"
+	if m.paused {
+		state = "paused"
+	}
+	fmt.Fprintf(&b, "generation %d | %s | %.1f gen/s | elapsed %s\n\n", m.stats.Generations, state, m.rate(), m.stats.Elapsed.Round(time.Second))
+	fmt.Fprintf(&b, "fitness %d | best %d | mean %.0f ± %.0f | worst %d | diversity %.2f\n",
+		m.stats.Fitness, m.stats.Best, m.stats.Mean, m.stats.StdDev, m.stats.Worst, m.stats.Diversity)
+	fmt.Fprintf(&b, "%s\n\n", sparkline(m.fitness))
+	fmt.Fprintf(&b, "mutation rate %g | pool size %d\n", m.mutationRate, m.stats.PoolSize)
+	for _, p := range m.params {
+		fmt.Fprintf(&b, "%s\n", p)
+	}
+	if m.preview != nil {
+		b.WriteString("\n")
+		b.WriteString(halfBlocks(m.preview))
+	}
+	b.WriteString("\np pause/resume · s save · q quit\n")
+	return b.String()
+}
+
+// the generations bred a second over the history
+func (m dashboardModel) rate() float64 {
+	if len(m.generations) < 2 || m.elapsed[len(m.elapsed)-1] <= m.elapsed[0] {
+		return 0
+	}
+	return float64(m.generations[len(m.generations)-1]-m.generations[0]) / (m.elapsed[len(m.elapsed)-1] - m.elapsed[0]).Seconds()
+}
+
+// sparkline draws the values as a line of bars from the lowest to the highest
+func sparkline(values []int64) string {
+	bars := []rune("▁▂▃▄▅▆▇█")
+	if len(values) == 0 {
+		return ""
+	}
+	low, high := values[0], values[0]
+	for _, v := range values {
+		low, high = min(low, v), max(high, v)
+	}
+	line := make([]rune, len(values))
+	for i, v := range values {
+		bar := 0
+		if high > low {
+			bar = int((v - low) * int64(len(bars)-1) / (high - low))
+		}
+		line[i] = bars[bar]
+	}
+	return string(line)
+}
+
+// halfBlocks draws the image with a character for every two rows of pixels,
+// the upper pixel in the foreground of ▀ and the lower in its background, in
+// 24 bit color
+func halfBlocks(img *image.RGBA) string {
+	var b strings.Builder
+	r := img.Rect
+	for y := r.Min.Y; y < r.Max.Y; y += 2 {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			top := img.RGBAAt(x, y)
+			bottom := top
+			if y+1 < r.Max.Y {
+				bottom = img.RGBAAt(x, y+1)
+			}
+			fmt.Fprintf(&b, "\033[38;2;%d;%d;%dm\033[48;2;%d;%d;%dm▀", top.R, top.G, top.B, bottom.R, bottom.G, bottom.B)
+		}
+		b.WriteString("\033[0m\n")
+	}
+	return b.String()
+}
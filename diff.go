@@ -0,0 +1,111 @@
+package ga
+
+import (
+	"image"
+	"math"
+)
+
+// Diff is the difference between 2 images
+func Diff(a, b *image.RGBA) (d int64) {
+	return int64(math.Sqrt(float64(SquaredDiff(a, b))))
+}
+
+// SquaredDiff is the sum of the squared differences of the bytes of 2 images,
+// Diff is its square root. Keeping the sum lets the difference be updated
+// byte by byte when an image only changes a little.
+func SquaredDiff(a, b *image.RGBA) (d int64) {
+	return squaredDiffBytes(a.Pix, b.Pix[:len(a.Pix)])
+}
+
+// sum of the squared differences of the bytes of a and b in plain Go
+func squaredDiffGeneric(a, b []byte) (d int64) {
+	b = b[:len(a)]
+	for i := range a {
+		d += int64(squareDifference(a[i], b[i]))
+	}
+	return
+}
+
+// square the difference
+func squareDifference(x, y uint8) uint64 {
+	d := uint64(x) - uint64(y)
+	return d * d
+}
+
+// WeightedDiff is the difference between 2 images, with the squared
+// difference of each pixel multiplied by its weight
+func WeightedDiff(a, b *image.RGBA, weights []float64) int64 {
+	d := 0.0
+	for p := 0; p < len(weights); p++ {
+		sum := uint64(0)
+		for i := p * 4; i < p*4+4; i++ {
+			sum += squareDifference(a.Pix[i], b.Pix[i])
+		}
+		d += weights[p] * float64(sum)
+	}
+
+	return int64(math.Sqrt(d))
+}
+
+// SquaredDiffRect is SquaredDiff for only the pixels inside r
+func SquaredDiffRect(a, b *image.RGBA, r image.Rectangle) (d int64) {
+	r = r.Intersect(a.Rect)
+	n := r.Dx() * 4
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		i, j := a.PixOffset(r.Min.X, y), b.PixOffset(r.Min.X, y)
+		d += squaredDiffBytes(a.Pix[i:i+n], b.Pix[j:j+n])
+	}
+	return
+}
+
+// BoundedDiff is like WeightedDiff but gives up comparing the images as soon
+// as the difference is known to be above bound, in which case it returns the
+// difference so far and false. It's a cheap way to rule out images that are
+// worse than the bound. weights can be nil for every pixel to count the same.
+func BoundedDiff(a, b *image.RGBA, weights []float64, bound int64) (int64, bool) {
+	// the difference is rounded down so it's only above bound once the sum
+	// reaches the square of the next integer
+	limit := float64(bound+1) * float64(bound+1)
+	d := 0.0
+	pixels := len(a.Pix) / 4
+	for p := 0; p < pixels; p++ {
+		sum := uint64(0)
+		for i := p * 4; i < p*4+4; i++ {
+			sum += squareDifference(a.Pix[i], b.Pix[i])
+		}
+		if weights != nil {
+			d += weights[p] * float64(sum)
+		} else {
+			d += float64(sum)
+		}
+		if d >= limit {
+			return int64(math.Sqrt(d)), false
+		}
+	}
+
+	return int64(math.Sqrt(d)), true
+}
+
+// SampledDiff estimates the difference between 2 images from every nth pixel
+// only. The result is scaled up to the number of pixels in the image so it can
+// be compared with the result of Diff. If weights isn't nil the squared
+// difference of each sampled pixel is multiplied by its weight.
+func SampledDiff(a, b *image.RGBA, n int, weights []float64) int64 {
+	d := 0.0
+	sampled := 0
+	pixels := len(a.Pix) / 4
+	for p := 0; p < pixels; p += n {
+		sum := uint64(0)
+		for i := p * 4; i < p*4+4; i++ {
+			sum += squareDifference(a.Pix[i], b.Pix[i])
+		}
+		if weights != nil {
+			d += weights[p] * float64(sum)
+		} else {
+			d += float64(sum)
+		}
+		sampled++
+	}
+
+	return int64(math.Sqrt(d * float64(pixels) / float64(sampled)))
+}
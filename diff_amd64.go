@@ -0,0 +1,15 @@
+//go:build !purego
+
+package ga
+
+// squaredDiffSSE2 is in diff_amd64.s
+//
+//go:noescape
+func squaredDiffSSE2(a, b []byte) int64
+
+// sum of the squared differences of the bytes of a and b, 16 at a time with
+// SSE2, which every amd64 processor has
+func squaredDiffBytes(a, b []byte) int64 {
+	n := len(a) &^ 15
+	return squaredDiffSSE2(a[:n], b[:n]) + squaredDiffGeneric(a[n:], b[n:])
+}
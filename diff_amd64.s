@@ -0,0 +1,64 @@
+//go:build !purego
+
+#include "textflag.h"
+
+// func squaredDiffSSE2(a, b []byte) int64
+//
+// The lengths must be the same and a multiple of 16. The bytes are widened to
+// 16 bits, subtracted and squared and summed in pairs into 4 32-bit sums,
+// which are widened into 2 64-bit sums every 4096 blocks before they can
+// overflow.
+TEXT ·squaredDiffSSE2(SB), NOSPLIT, $0-56
+	MOVQ a_base+0(FP), SI
+	MOVQ a_len+8(FP), CX
+	MOVQ b_base+24(FP), DI
+	PXOR X7, X7
+	PXOR X6, X6
+	SHRQ $4, CX
+	JZ   done
+
+outer:
+	MOVQ CX, DX
+	CMPQ DX, $4096
+	JBE  chunk
+	MOVQ $4096, DX
+
+chunk:
+	SUBQ DX, CX
+	PXOR X5, X5
+
+loop:
+	MOVOU     (SI), X0
+	MOVOU     (DI), X1
+	MOVO      X0, X2
+	MOVO      X1, X3
+	PUNPCKLBW X7, X0
+	PUNPCKLBW X7, X1
+	PUNPCKHBW X7, X2
+	PUNPCKHBW X7, X3
+	PSUBW     X1, X0
+	PSUBW     X3, X2
+	PMADDWL   X0, X0
+	PMADDWL   X2, X2
+	PADDL     X0, X5
+	PADDL     X2, X5
+	ADDQ      $16, SI
+	ADDQ      $16, DI
+	DECQ      DX
+	JNZ       loop
+
+	MOVO      X5, X4
+	PUNPCKLLQ X7, X5
+	PUNPCKHLQ X7, X4
+	PADDQ     X5, X6
+	PADDQ     X4, X6
+	TESTQ     CX, CX
+	JNZ       outer
+
+done:
+	MOVO  X6, X0
+	PSRLO $8, X0
+	PADDQ X0, X6
+	MOVQ  X6, AX
+	MOVQ  AX, ret+48(FP)
+	RET
@@ -0,0 +1,8 @@
+//go:build !amd64 || purego
+
+package ga
+
+// sum of the squared differences of the bytes of a and b
+func squaredDiffBytes(a, b []byte) int64 {
+	return squaredDiffGeneric(a, b)
+}
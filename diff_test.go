@@ -0,0 +1,91 @@
+package ga
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// an image with a gradient across it, shifted by shift
+func gradientImage(w, h, shift int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8((x + shift) * 255 / (w + shift)), uint8(y * 255 / h), uint8((x + y) * 255 / (w + h)), 255})
+		}
+	}
+	return img
+}
+
+// an image of random bytes
+func noiseImage(rng *rand.Rand, w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	rng.Read(img.Pix)
+	return img
+}
+
+// the Pearson correlation of xs and ys
+func correlation(xs, ys []float64) float64 {
+	var mx, my float64
+	for i := range xs {
+		mx += xs[i]
+		my += ys[i]
+	}
+	mx /= float64(len(xs))
+	my /= float64(len(ys))
+	var sxy, sxx, syy float64
+	for i := range xs {
+		sxy += (xs[i] - mx) * (ys[i] - my)
+		sxx += (xs[i] - mx) * (xs[i] - mx)
+		syy += (ys[i] - my) * (ys[i] - my)
+	}
+	return sxy / math.Sqrt(sxx*syy)
+}
+
+func TestSampledDiffCorrelates(t *testing.T) {
+	target := gradientImage(120, 90, 0)
+	rng := rand.New(rand.NewSource(1))
+	var full, sampled []float64
+	for shift := 0; shift < 100; shift += 5 {
+		img := gradientImage(120, 90, shift)
+		// a little noise so the differences aren't all as smooth as the
+		// gradient
+		for i := range img.Pix {
+			if rng.Intn(100) == 0 {
+				img.Pix[i] = uint8(rng.Intn(256))
+			}
+		}
+		full = append(full, float64(Diff(img, target)))
+		sampled = append(sampled, float64(SampledDiff(img, target, 7, nil)))
+	}
+	if c := correlation(full, sampled); c < 0.99 {
+		t.Errorf("sampled and full differences have a correlation of %.3f, want at least 0.99", c)
+	}
+	for i := range full {
+		if math.Abs(sampled[i]-full[i]) > full[i]*0.15 {
+			t.Errorf("sampled difference %.0f is more than 15%% off the full difference %.0f", sampled[i], full[i])
+		}
+	}
+}
+
+func BenchmarkDiff(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	x, y := noiseImage(rng, 400, 400), noiseImage(rng, 400, 400)
+	b.SetBytes(int64(len(x.Pix)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Diff(x, y)
+	}
+}
+
+func BenchmarkDiffSampled(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	x, y := noiseImage(rng, 400, 400), noiseImage(rng, 400, 400)
+	b.SetBytes(int64(len(x.Pix)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		SampledDiff(x, y, 4, nil)
+	}
+}
@@ -0,0 +1,48 @@
+package ga
+
+import (
+	"image"
+	"math"
+)
+
+// EdgeWeights returns weights for the target that make its edges count more
+// than its flat regions, so contours like the eyes, the mouth and the outline
+// are matched first. The edges are found with the Sobel operator on the
+// brightness of the target. strength is how much more the strongest edge
+// counts than a flat region, 0 makes every pixel count the same. Like the
+// weights from LoadWeights they are between 0 and 1, one for every pixel in
+// the same order as the pixels in Pix.
+func EdgeWeights(target *image.RGBA, strength float64) []float64 {
+	w, h := target.Rect.Dx(), target.Rect.Dy()
+	gray := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*target.Stride + x*4
+			gray[y*w+x] = 0.299*float64(target.Pix[i]) + 0.587*float64(target.Pix[i+1]) + 0.114*float64(target.Pix[i+2])
+		}
+	}
+	// the brightness at x, y with the edges of the image repeated outwards
+	at := func(x, y int) float64 {
+		return gray[clamp(y, 0, h-1)*w+clamp(x, 0, w-1)]
+	}
+
+	edges := make([]float64, w*h)
+	strongest := 0.0
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			gx := at(x+1, y-1) + 2*at(x+1, y) + at(x+1, y+1) - at(x-1, y-1) - 2*at(x-1, y) - at(x-1, y+1)
+			gy := at(x-1, y+1) + 2*at(x, y+1) + at(x+1, y+1) - at(x-1, y-1) - 2*at(x, y-1) - at(x+1, y-1)
+			edges[y*w+x] = math.Hypot(gx, gy)
+			strongest = max(strongest, edges[y*w+x])
+		}
+	}
+
+	weights := make([]float64, w*h)
+	for i, e := range edges {
+		if strongest > 0 {
+			e /= strongest
+		}
+		weights[i] = (1 + strength*e) / (1 + strength)
+	}
+	return weights
+}
@@ -0,0 +1,130 @@
+package ga
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// EventLog writes what happens during a run as JSON lines, one event a line,
+// so a run can be followed by other tools. Every event has its kind in
+// "event", the time it happened in "time" and the generation it happened in
+// in "generation".
+type EventLog struct {
+	w   io.WriteCloser
+	enc *json.Encoder
+}
+
+// what every event has
+type eventHeader struct {
+	Event      string    `json:"event"`
+	Time       time.Time `json:"time"`
+	Generation int       `json:"generation"`
+}
+
+// the stats of a generation or of a finished run
+type eventStats struct {
+	Fitness   int64   `json:"fitness"`
+	Best      int64   `json:"best"`
+	Median    int64   `json:"median"`
+	Worst     int64   `json:"worst"`
+	Mean      float64 `json:"mean"`
+	StdDev    float64 `json:"stddev"`
+	Diversity float64 `json:"diversity"`
+	PoolSize  int     `json:"pool_size"`
+	Children  int     `json:"children"`
+	Improved  int     `json:"improved"`
+	Elapsed   float64 `json:"elapsed_seconds"`
+}
+
+func newEventStats(stats Stats) eventStats {
+	return eventStats{
+		Fitness:   stats.Fitness,
+		Best:      stats.Best,
+		Median:    stats.Median,
+		Worst:     stats.Worst,
+		Mean:      stats.Mean,
+		StdDev:    stats.StdDev,
+		Diversity: stats.Diversity,
+		PoolSize:  stats.PoolSize,
+		Children:  stats.Children,
+		Improved:  stats.Improved,
+		Elapsed:   stats.Elapsed.Seconds(),
+	}
+}
+
+// NewEventLog appends events to the file at filePath, or writes them to
+// stdout if it's "-"
+func NewEventLog(filePath string) (*EventLog, error) {
+	var w io.WriteCloser = nopCloser{os.Stdout}
+	if filePath != "-" {
+		f, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open event log: %w", err)
+		}
+		w = f
+	}
+	return &EventLog{w: w, enc: json.NewEncoder(w)}, nil
+}
+
+// nopCloser keeps stdout open when the event log is closed
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// write an event
+func (l *EventLog) write(event any) error {
+	err := l.enc.Encode(event)
+	if err != nil {
+		return fmt.Errorf("cannot write event: %w", err)
+	}
+	return nil
+}
+
+// Generation logs a "generation" event with the stats of the generation
+// that was just bred
+func (l *EventLog) Generation(stats Stats) error {
+	return l.write(struct {
+		eventHeader
+		eventStats
+	}{eventHeader{"generation", time.Now(), stats.Generations}, newEventStats(stats)})
+}
+
+// Improvement logs an "improvement" event with the fitness of a genome
+// fitter than any found before it in the run
+func (l *EventLog) Improvement(generation int, fitness int64) error {
+	return l.write(struct {
+		eventHeader
+		Fitness int64 `json:"fitness"`
+	}{eventHeader{"improvement", time.Now(), generation}, fitness})
+}
+
+// Checkpoint logs a "checkpoint" event with the path of a checkpoint that
+// was written
+func (l *EventLog) Checkpoint(generation int, path string) error {
+	return l.write(struct {
+		eventHeader
+		Path string `json:"path"`
+	}{eventHeader{"checkpoint", time.Now(), generation}, path})
+}
+
+// Finished logs a "finished" event with the stats of the run and why it
+// stopped
+func (l *EventLog) Finished(stats Stats, reason string) error {
+	return l.write(struct {
+		eventHeader
+		eventStats
+		Reason string `json:"reason"`
+	}{eventHeader{"finished", time.Now(), stats.Generations}, newEventStats(stats), reason})
+}
+
+// Close the file the events are written to
+func (l *EventLog) Close() error {
+	return l.w.Close()
+}
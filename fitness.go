@@ -0,0 +1,141 @@
+package ga
+
+import (
+	"fmt"
+	"image"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Fitness scores how far a candidate image is from the target, the lower the
+// better with 0 for the same image. It's called concurrently for different
+// candidates so it must be safe for that.
+type Fitness interface {
+	Score(candidate, target *image.RGBA) int64
+}
+
+// FitnessFunc lets an ordinary function be used as a Fitness
+type FitnessFunc func(candidate, target *image.RGBA) int64
+
+// Score calls f
+func (f FitnessFunc) Score(candidate, target *image.RGBA) int64 {
+	return f(candidate, target)
+}
+
+// WeightedFitness is a Fitness that can count some pixels more than others,
+// like with a mask made by LoadWeights
+type WeightedFitness interface {
+	Fitness
+	// Weighted returns the fitness with the difference of each pixel
+	// multiplied by its weight, one for every pixel in the same order as the
+	// pixels in Pix
+	Weighted(weights []float64) Fitness
+}
+
+// BoundedFitness is a Fitness that can give up scoring a candidate once the
+// score is known to be above a bound, which is cheaper when all that matters
+// is whether the candidate is better than the bound
+type BoundedFitness interface {
+	Fitness
+	// ScoreBelow scores the candidate like Score, but if the score turns out
+	// to be above bound it may stop early and return a score that's above
+	// bound but no more than the real one, and false
+	ScoreBelow(candidate, target *image.RGBA, bound int64) (int64, bool)
+}
+
+// DiffFitness is the default fitness, the square root of the sum of the
+// squared differences of the pixels as worked out by Diff. If Weights isn't
+// nil the difference of each pixel is multiplied by its weight, and a
+// SampleRate above 1 only compares every nth pixel.
+type DiffFitness struct {
+	Weights    []float64
+	SampleRate int
+}
+
+// Score the candidate
+func (f DiffFitness) Score(candidate, target *image.RGBA) int64 {
+	switch {
+	case f.SampleRate > 1:
+		return SampledDiff(candidate, target, f.SampleRate, f.Weights)
+	case f.Weights != nil:
+		return WeightedDiff(candidate, target, f.Weights)
+	default:
+		return Diff(candidate, target)
+	}
+}
+
+// ScoreBelow scores the candidate up to the bound. Sampled differences are
+// always worked out in full.
+func (f DiffFitness) ScoreBelow(candidate, target *image.RGBA, bound int64) (int64, bool) {
+	if f.SampleRate > 1 {
+		return f.Score(candidate, target), true
+	}
+	return BoundedDiff(candidate, target, f.Weights, bound)
+}
+
+// Weighted returns the fitness with the weights
+func (f DiffFitness) Weighted(weights []float64) Fitness {
+	f.Weights = weights
+	return f
+}
+
+// LabFitness is the fitness that compares colors in CIELAB as worked out by
+// LabDiff. If Weights isn't nil the distance of each pixel is multiplied by
+// its weight.
+type LabFitness struct {
+	Weights []float64
+}
+
+// Score the candidate
+func (f LabFitness) Score(candidate, target *image.RGBA) int64 {
+	return WeightedLabDiff(candidate, target, f.Weights)
+}
+
+// Weighted returns the fitness with the weights
+func (f LabFitness) Weighted(weights []float64) Fitness {
+	f.Weights = weights
+	return f
+}
+
+// fitnesses are the registered fitness functions by name
+var (
+	fitnessMu sync.RWMutex
+	fitnesses = map[string]Fitness{
+		"diff": DiffFitness{},
+		"ssim": FitnessFunc(SSIMDiff),
+		"lab":  LabFitness{},
+	}
+)
+
+// RegisterFitness makes a fitness function available to NewFitness under the
+// given name, replacing any registered before with the same name. It's
+// usually called from an init function.
+func RegisterFitness(name string, f Fitness) {
+	fitnessMu.Lock()
+	defer fitnessMu.Unlock()
+	fitnesses[name] = f
+}
+
+// FitnessNames returns the names of the registered fitness functions
+func FitnessNames() []string {
+	fitnessMu.RLock()
+	defer fitnessMu.RUnlock()
+	names := make([]string, 0, len(fitnesses))
+	for name := range fitnesses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewFitness returns the registered fitness function with the given name
+func NewFitness(name string) (Fitness, error) {
+	fitnessMu.RLock()
+	f, ok := fitnesses[name]
+	fitnessMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown fitness %q, use one of %s", name, strings.Join(FitnessNames(), ", "))
+	}
+	return f, nil
+}
@@ -0,0 +1,100 @@
+package ga
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// SaveFrame writes the image as a numbered PNG frame in dir. The frame is
+// written to a temporary file first and then renamed, so if the run is
+// interrupted the directory only ever contains complete frames.
+func SaveFrame(dir string, n int, rgba *image.RGBA) error {
+	framePath := filepath.Join(dir, fmt.Sprintf("frame_%06d.png", n))
+	tmpPath := framePath + ".tmp"
+	err := Save(tmpPath, rgba)
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmpPath, framePath)
+	if err != nil {
+		return fmt.Errorf("cannot rename frame: %w", err)
+	}
+	return nil
+}
+
+// AssembleGIF assembles the numbered PNG frames in dir into an animated GIF,
+// delay is the time between frames in 100ths of a second. Frames that can't
+// be read are skipped instead of throwing away the rest of the animation,
+// why each of them was skipped is returned.
+func AssembleGIF(dir string, filePath string, delay int) (skipped []error, err error) {
+	framePaths, err := filepath.Glob(filepath.Join(dir, "frame_*.png"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(framePaths)
+
+	anim := NewAnimation(delay)
+	for _, framePath := range framePaths {
+		img, err := getImage(framePath)
+		if err != nil {
+			skipped = append(skipped, err)
+			continue
+		}
+		anim.Add(img)
+	}
+	if anim.Len() == 0 {
+		return skipped, fmt.Errorf("no frames found in %s", dir)
+	}
+	return skipped, anim.Save(filePath)
+}
+
+// Animation collects the frames of an animated GIF in memory
+type Animation struct {
+	gif   gif.GIF
+	delay int
+}
+
+// NewAnimation creates an empty animation, delay is the time between frames
+// in 100ths of a second
+func NewAnimation(delay int) *Animation {
+	return &Animation{delay: delay}
+}
+
+// Add the image as the next frame
+func (a *Animation) Add(img image.Image) {
+	a.gif.Image = append(a.gif.Image, quantize(img))
+	a.gif.Delay = append(a.gif.Delay, a.delay)
+}
+
+// Len is the number of frames
+func (a *Animation) Len() int {
+	return len(a.gif.Image)
+}
+
+// Save the animation as a GIF file
+func (a *Animation) Save(filePath string) error {
+	gifFile, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("cannot create file: %w", err)
+	}
+	err = gif.EncodeAll(gifFile, &a.gif)
+	if err != nil {
+		gifFile.Close()
+		return fmt.Errorf("cannot encode GIF: %w", err)
+	}
+	return gifFile.Close()
+}
+
+// reduce the image to a 256 color palette so it can go into a GIF
+func quantize(img image.Image) *image.Paletted {
+	bounds := img.Bounds()
+	paletted := image.NewPaletted(bounds, palette.Plan9)
+	draw.FloydSteinberg.Draw(paletted, bounds, img, bounds.Min)
+	return paletted
+}
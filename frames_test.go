@@ -0,0 +1,79 @@
+package ga
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// an image of one color
+func solidImage(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Rect, image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+func TestAssembleGIF(t *testing.T) {
+	dir := t.TempDir()
+	colors := []color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}}
+	for i, c := range colors {
+		err := SaveFrame(dir, (i+1)*10, solidImage(8, 6, c))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// what an interrupted SaveFrame leaves behind, and a frame that's been
+	// cut short
+	err := os.WriteFile(filepath.Join(dir, "frame_000040.png.tmp"), []byte("\x89PNG"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "frame_000050.png"), []byte("\x89PNG\r\n\x1a\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gifPath := filepath.Join(t.TempDir(), "evolution.gif")
+	skipped, err := AssembleGIF(dir, gifPath, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skipped) != 1 {
+		t.Errorf("got %d skipped frames, want the corrupt one: %v", len(skipped), skipped)
+	}
+
+	gifFile, err := os.Open(gifPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gifFile.Close()
+	anim, err := gif.DecodeAll(gifFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(anim.Image) != len(colors) {
+		t.Fatalf("got %d frames, want %d", len(anim.Image), len(colors))
+	}
+	for i, frame := range anim.Image {
+		if anim.Delay[i] != 7 {
+			t.Errorf("frame %d has a delay of %d, want 7", i, anim.Delay[i])
+		}
+		// the frames are in the order they were saved in
+		r, g, b, _ := frame.At(0, 0).RGBA()
+		got := color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), 255}
+		if got != colors[i] {
+			t.Errorf("frame %d is %v, want %v", i, got, colors[i])
+		}
+	}
+}
+
+func TestAssembleGIFWithoutFrames(t *testing.T) {
+	_, err := AssembleGIF(t.TempDir(), filepath.Join(t.TempDir(), "evolution.gif"), 10)
+	if err == nil {
+		t.Error("assembled a GIF without any frames")
+	}
+}
@@ -0,0 +1,621 @@
+// Package ga is a small genetic algorithm engine. A problem is described by
+// implementing Genome, and Evolve breeds a population of genomes generation
+// after generation until one of them is fit enough.
+//
+// The package also has the image helpers shared by the Mona Lisa demos.
+package ga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Genome is an individual in the population
+type Genome interface {
+	// Fitness of the genome, the lower the better
+	Fitness() int64
+	// Crossover breeds a child genome from this genome and the other one.
+	// Children are bred concurrently so any randomness must come from rng.
+	Crossover(other Genome, rng *rand.Rand) Genome
+	// Mutate randomly changes the genome, using rng for any randomness
+	Mutate(rng *rand.Rand)
+}
+
+// BoundedGenome is a Genome whose fitness can be worked out more cheaply when
+// it only matters whether it's better than a bound. Evolve uses it for
+// children that have no chance of breeding unless they're fitter than the
+// bound. Children whose fitness is only known to be above the bound are left
+// out of everything that ranks or reports the genomes, like the stats, the
+// hall of fame and the pool, until they're replaced.
+type BoundedGenome interface {
+	Genome
+	// FitnessBelow returns the fitness like Fitness and true, but once the
+	// fitness is known to be above bound it may return any fitness that's
+	// above bound but no more than the real one and false. Fitness must
+	// still return the real fitness afterwards.
+	FitnessBelow(bound int64) (fitness int64, exact bool)
+}
+
+// Recycler is a Genome that can reuse what it holds, like the memory of the
+// image it's drawn on, once it's no longer in the population. Evolve calls
+// Recycle on every genome that doesn't make it into the next generation, the
+// hall of fame or isn't the best genome found so far, after Progress and
+// Checkpoint have been called, so a genome passed to them mustn't be used
+// after they return. Recyclers must be comparable, which pointers are.
+type Recycler interface {
+	Genome
+	Recycle()
+}
+
+// Config holds the parameters of the engine
+type Config struct {
+	// PoolSize is the max size of the pool
+	PoolSize int
+	// FitnessLimit is the fitness we are satisfied with
+	FitnessLimit int64
+	// MaxGenerations stops the run once this many generations have been
+	// bred, counting those bred before a resumed run, 0 means no limit
+	MaxGenerations int
+	// Selector picks the genomes that breed each generation, if it's nil a
+	// PoolSelector of PoolSize is used
+	Selector Selector
+	// Elite is the number of the fittest genomes of each generation that are
+	// carried over unchanged into the next one
+	Elite int
+	// SteadyState breeds only this many children each generation, which
+	// take the places of the least fit genomes of the population if they're
+	// fitter, rather than a whole new generation. 0 breeds whole generations.
+	SteadyState int
+	// Strategy is the name of the way each generation is bred, see
+	// StrategyNames. Empty is generational, the other strategies don't use
+	// the Selector, Elite or SteadyState.
+	Strategy string
+	// Lambda is the number of children bred each generation by the plus and
+	// comma evolution strategies, μ being the size of the population. 0
+	// breeds as many children as there are genomes.
+	Lambda int
+	// Temperature is the temperature the anneal strategy starts at, in
+	// units of fitness: a copy of a genome that's that much less fit than it
+	// takes its place with a chance of 1/e. Cooling is the name of the way
+	// it cools down every generation by CoolingRate, see CoolingNames, empty
+	// being exponential.
+	Temperature float64
+	Cooling     string
+	CoolingRate float64
+	// Seed is what the random numbers used to breed every generation are
+	// made from, 0 picks a random seed
+	Seed int64
+	// Source makes the source of a stream of random numbers from a seed
+	// made from Seed. Every child, island and generation gets a stream of
+	// its own, so a run doesn't depend on which goroutine breeds what, and
+	// Source is called concurrently. If it's nil math/rand's is used.
+	Source func(seed int64) rand.Source
+	// Generation is the number of generations already bred when resuming a
+	// run from a State
+	Generation int
+	// Best is the best genome found before a resumed run, which stays the
+	// best until a fitter one is bred. It can be nil.
+	Best Genome
+	// Progress is called after every generation with the stats so far and
+	// the best genome found so far, it can be nil
+	Progress func(stats Stats, best Genome)
+	// Improved is called with the generation and the genome whenever a
+	// generation has a genome fitter than any found before, it can be nil
+	Improved func(generation int, best Genome)
+	// Checkpoint is called after every generation with what's needed to
+	// continue the run from there, it can be nil
+	Checkpoint func(state State)
+	// Stagnation is the number of generations the best fitness can go
+	// without improving before the population is shaken up by replacing its
+	// least fit genomes with new ones made by NewGenome, 0 never does. The
+	// generations are counted from the start of a resumed run.
+	Stagnation int
+	// Restart is the fraction of the population that's replaced when it
+	// stagnates. 1 restarts the run with only the elite carried over, or the
+	// fittest genome if there's no elite.
+	Restart float64
+	// NewGenome makes a new random genome, using rng for any randomness. It's
+	// needed when Stagnation is set.
+	NewGenome func(rng *rand.Rand) Genome
+	// Evaluate works out the fitness of all the children of a generation at
+	// once, e.g. to score them as a batch on a Backend. If it's nil every
+	// child works out its own fitness as it's bred. With islands it's called
+	// for each island on its own, one island at a time.
+	Evaluate func(children []Genome)
+	// Islands splits the population into this many islands that evolve on
+	// their own side by side, so different islands can find different ways
+	// to fit. Every MigrationInterval generations copies of the Migrants
+	// fittest genomes of every island are sent to other islands, where they
+	// replace the least fit. The pool size, elite, steady state, strategy
+	// and stagnation apply to every island on its own. 0 or 1 evolves the population as a whole.
+	Islands           int
+	MigrationInterval int
+	Migrants          int
+	// Topology is the name of the way the islands are linked, which says
+	// the islands every island sends its migrants to, see TopologyNames.
+	// Empty links them in a ring.
+	Topology string
+	// HallOfFame keeps the fittest genomes of every generation, they aren't
+	// recycled while it does. It can be nil.
+	HallOfFame *HallOfFame
+	// Control pauses and steers the run from other goroutines, it's given
+	// the pool-size setting. It can be nil.
+	Control *Control
+}
+
+// State is what's needed to continue a run exactly where it stopped. Pass
+// the population to Evolve with the seed, generation and best genome in the
+// Config.
+type State struct {
+	// Generation is the number of generations bred so far
+	Generation int
+	// Seed is the seed of the run
+	Seed int64
+	// Population is the population of the next generation
+	Population []Genome
+	// Best is the best genome found so far, which may have left the
+	// population
+	Best Genome
+}
+
+// Stats describes how a run went
+type Stats struct {
+	Generations int
+	// Fitness is that of the best genome found so far
+	Fitness int64
+	// Spread is how the fitness of the last generation is spread, leaving out
+	// the children whose fitness is only known to be above a bound
+	Spread
+	PoolSize int
+	Elapsed  time.Duration
+	// Children is the number of children bred in the last generation and
+	// Improved how many of them are fitter than both of their parents
+	Children int
+	Improved int
+}
+
+// Evolve breeds the population until the best genome's fitness is below
+// cfg.FitnessLimit, cfg.MaxGenerations have been bred or the context is done,
+// and returns the best genome found
+func Evolve(ctx context.Context, population []Genome, cfg Config) (Genome, Stats, error) {
+	if cfg.Topology == "" {
+		cfg.Topology = "ring"
+	}
+	if cfg.Strategy == "" {
+		cfg.Strategy = "generational"
+	}
+	if cfg.Cooling == "" {
+		cfg.Cooling = "exponential"
+	}
+	islands, err := cfg.split(population)
+	if err != nil {
+		return nil, Stats{}, err
+	}
+	if cfg.Selector == nil {
+		cfg.Selector = PoolSelector{Size: cfg.PoolSize}
+	}
+	if evaluate := cfg.Evaluate; evaluate != nil && len(islands) > 1 {
+		var mu sync.Mutex
+		cfg.Evaluate = func(children []Genome) {
+			mu.Lock()
+			defer mu.Unlock()
+			evaluate(children)
+		}
+	}
+
+	if cfg.Seed == 0 {
+		cfg.Seed = rand.Int63()
+	}
+	if cfg.Source == nil {
+		cfg.Source = rand.NewSource
+	}
+	// every island breeds with random numbers of its own, the first with
+	// those of the seed so a run without islands is bred the same way
+	seeds := make([]int64, len(islands))
+	plateaus := make([]*Plateau, len(islands))
+	for i := range islands {
+		seeds[i] = cfg.Seed
+		if i > 0 {
+			seeds[i] = int64(splitmix(uint64(cfg.Seed) ^ uint64(i)))
+		}
+		plateaus[i] = NewPlateau(cfg.Stagnation)
+	}
+
+	if cfg.Control != nil {
+		get, set := cfg.poolSizeSetting(len(islands[len(islands)-1]))
+		cfg.Control.Setting("pool-size", get, set)
+	}
+
+	start := time.Now()
+	stats := Stats{Generations: cfg.Generation}
+	tracker := &BestTracker{OnImprove: cfg.Improved, best: cfg.Best}
+	// the genomes at the end of every island whose fitness is only known to
+	// be above a bound, the rest are ranked
+	estimated := make([]int, len(islands))
+	ranked := population
+	for {
+		stats.Generations++
+		tracker.Track(ranked, stats.Generations)
+		best := tracker.Best()
+		stats.Fitness = best.Fitness()
+		stats.Spread = tracker.Spread()
+		stats.Elapsed = time.Since(start)
+		if cfg.HallOfFame != nil {
+			cfg.HallOfFame.Add(ranked, stats.Generations, stats.Elapsed)
+		}
+		if stats.Fitness < cfg.FitnessLimit || ctx.Err() != nil || cfg.reachedMaxGenerations(stats) {
+			return best, stats, nil
+		}
+
+		// the islands breed their next generations side by side
+		bred := make([]generation, len(islands))
+		var wg sync.WaitGroup
+		for i := range islands {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				bred[i] = cfg.breed(islands[i], estimated[i], seeds[i], stats.Generations, plateaus[i])
+			}()
+		}
+		wg.Wait()
+		stats.PoolSize, stats.Children, stats.Improved = 0, 0, 0
+		var replaced []Genome
+		for i, g := range bred {
+			if g.err != nil {
+				return best, stats, g.err
+			}
+			islands[i], estimated[i] = g.population, g.estimated
+			replaced = append(replaced, g.replaced...)
+			stats.PoolSize += g.poolSize
+			stats.Children += g.children
+			stats.Improved += g.improved
+		}
+		if len(islands) > 1 && stats.Generations%cfg.MigrationInterval == 0 {
+			replaced = append(replaced, migrate(islands, estimated, cfg.Migrants, topologies[cfg.Topology], cfg.newRand(cfg.Seed, stats.Generations, -2))...)
+		}
+
+		previous := population
+		population = slices.Concat(islands...)
+		ranked = nil
+		for i, island := range islands {
+			ranked = append(ranked, island[:len(island)-estimated[i]]...)
+		}
+		if cfg.Progress != nil {
+			cfg.Progress(stats, best)
+		}
+		if cfg.Checkpoint != nil {
+			cfg.Checkpoint(State{Generation: stats.Generations, Seed: cfg.Seed, Population: population, Best: best})
+		}
+		// a genome replaced by a migrant can be from the previous generation
+		// too, and the best genome is kept after it has left the population
+		recycle(slices.Concat(previous, replaced), slices.Concat(population, cfg.HallOfFame.genomes(), []Genome{best}))
+		cfg.Control.between(ctx)
+	}
+}
+
+// split the population into cfg.Islands islands of about the same size, one
+// island if there are none, checking every island can be bred
+func (cfg Config) split(population []Genome) ([][]Genome, error) {
+	n := max(cfg.Islands, 1)
+	// the genomes are annealed or climb on their own, the other strategies
+	// need two parents for every child
+	perIsland := 2
+	if cfg.Strategy == "anneal" || cfg.Strategy == "hill-climb" {
+		perIsland = 1
+	}
+	if len(population) < perIsland*n {
+		return nil, fmt.Errorf("population size must be at least %d", perIsland*n)
+	}
+	if n > 1 {
+		if cfg.MigrationInterval < 1 {
+			return nil, errors.New("migration interval must be at least 1 generation")
+		}
+		if cfg.Migrants < 0 || cfg.Migrants >= len(population)/n {
+			return nil, fmt.Errorf("migrant count must be between 0 and %d", len(population)/n-1)
+		}
+		if _, ok := topologies[cfg.Topology]; !ok {
+			return nil, fmt.Errorf("unknown topology %q, use one of %s", cfg.Topology, strings.Join(TopologyNames(), ", "))
+		}
+	}
+	islands := make([][]Genome, n)
+	start := 0
+	for i := range islands {
+		// the first islands get one more genome each when the population
+		// doesn't split evenly
+		size := len(population) / n
+		if i < len(population)%n {
+			size++
+		}
+		islands[i] = slices.Clone(population[start : start+size])
+		start += size
+	}
+	// the smallest island is the last one
+	size := len(islands[n-1])
+	if cfg.Strategy == "generational" && cfg.Selector == nil && (cfg.PoolSize < 1 || cfg.PoolSize >= size) {
+		return nil, fmt.Errorf("pool size must be between 1 and %d", size-1)
+	}
+	if cfg.Elite < 0 || cfg.Elite >= size {
+		return nil, fmt.Errorf("elite count must be between 0 and %d", size-1)
+	}
+	if cfg.SteadyState < 0 || cfg.SteadyState > size {
+		return nil, fmt.Errorf("steady state children must be between 0 and %d", size)
+	}
+	switch cfg.Strategy {
+	case "generational", "hill-climb":
+	case "plus":
+		if cfg.Lambda < 0 {
+			return nil, errors.New("lambda must not be negative")
+		}
+	case "comma":
+		if cfg.Lambda != 0 && cfg.Lambda < size {
+			return nil, fmt.Errorf("lambda must be 0 or at least %d, the size of the population", size)
+		}
+	case "anneal":
+		if cfg.Temperature < 0 {
+			return nil, errors.New("temperature must not be negative")
+		}
+		if _, ok := coolings[cfg.Cooling]; !ok {
+			return nil, fmt.Errorf("unknown cooling %q, use one of %s", cfg.Cooling, strings.Join(CoolingNames(), ", "))
+		}
+		if cfg.CoolingRate < 0 || (cfg.Cooling == "exponential" && cfg.CoolingRate > 1) {
+			return nil, errors.New("cooling rate must not be negative, and at most 1 for exponential cooling")
+		}
+	default:
+		return nil, fmt.Errorf("unknown strategy %q, use one of %s", cfg.Strategy, strings.Join(StrategyNames(), ", "))
+	}
+
+	if cfg.Stagnation < 0 {
+		return nil, errors.New("stagnation must not be negative")
+	}
+	if cfg.Stagnation > 0 {
+		if cfg.NewGenome == nil {
+			return nil, errors.New("restarting a stagnating population needs NewGenome")
+		}
+		if cfg.Restart <= 0 || cfg.Restart > 1 {
+			return nil, errors.New("restart fraction must be above 0 and at most 1")
+		}
+	}
+	return islands, nil
+}
+
+// generation is what breeding a generation of an island gives: the next
+// generation, how many genomes at its end only have a fitness known to be
+// above a bound, the genomes replaced when it stagnated or by children of a
+// steady state, the size of the pool its parents were picked from, how many
+// children were bred and how many of them are fitter than both of their
+// parents
+type generation struct {
+	population []Genome
+	estimated  int
+	replaced   []Genome
+	poolSize   int
+	children   int
+	improved   int
+	err        error
+}
+
+// breed the next generation of an island whose last estimated genomes only
+// have a fitness known to be above a bound, the number of generations bred
+// being the one being bred now, with random numbers made from the seed
+func (cfg Config) breed(population []Genome, estimated int, seed int64, generations int, plateau *Plateau) generation {
+	var replaced []Genome
+	ranked := population[:len(population)-estimated]
+	if cfg.Stagnation > 0 && plateau.Reached(Stats{Generations: generations, Fitness: fittest(ranked).Fitness()}) {
+		// the genomes are ranked by their real fitness to restart, which
+		// the estimated ones work out
+		population, replaced = restart(population, cfg, seed, generations)
+		ranked = population
+	}
+	g := strategies[cfg.Strategy](cfg, ranked, population, seed, generations)
+	g.replaced = append(replaced, g.replaced...)
+	return g
+}
+
+// breed a whole new generation, or only SteadyState children, from parents
+// picked by the selector among the ranked genomes at the start of the
+// population
+func (cfg Config) generational(ranked []Genome, population []Genome, seed int64, generations int) generation {
+	var g generation
+	var pick func(rng *rand.Rand) Genome
+	if picker, ok := cfg.Selector.(Picker); ok && cfg.Elite == 0 {
+		// the parents are picked straight from the population, which
+		// all of them can be picked from
+		pick = func(rng *rand.Rand) Genome {
+			return picker.Pick(ranked, rng)
+		}
+		g.poolSize = len(ranked)
+	} else {
+		// get the best fitting genomes first
+		sort.SliceStable(ranked, func(i, j int) bool {
+			return ranked[i].Fitness() < ranked[j].Fitness()
+		})
+		pool := cfg.Selector.Pool(ranked, cfg.newRand(seed, generations, 0))
+		if len(pool) == 0 {
+			g.err = errors.New("selector returned an empty pool")
+			return g
+		}
+		pick = func(rng *rand.Rand) Genome {
+			return pool[rng.Intn(len(pool))]
+		}
+		g.poolSize = len(pool)
+	}
+	rngs := cfg.childRands(seed, generations)
+	if cfg.SteadyState > 0 {
+		g.population, g.replaced, g.improved = survivors(pick, population, cfg.SteadyState, true, rngs, cfg.Evaluate)
+		g.children = cfg.SteadyState
+		return g
+	}
+	bound := breedingBound(cfg.Selector, ranked, cfg.Elite)
+	g.population, g.improved, g.estimated = naturalSelection(pick, population, cfg.Elite, bound, rngs, cfg.Evaluate)
+	g.children = len(population) - cfg.Elite
+	return g
+}
+
+// the random numbers every child of a generation is bred with, child i
+// getting rngs(i)
+func (cfg Config) childRands(seed int64, generations int) func(i int) *rand.Rand {
+	return func(i int) *rand.Rand {
+		return cfg.newRand(seed, generations, i+1)
+	}
+}
+
+// shake up a stagnating population by replacing its least fit genomes with
+// new ones, and return the new population and the genomes replaced
+func restart(population []Genome, cfg Config, seed int64, generation int) ([]Genome, []Genome) {
+	restarted := make([]Genome, len(population))
+	copy(restarted, population)
+	sort.SliceStable(restarted, func(i, j int) bool {
+		return restarted[i].Fitness() < restarted[j].Fitness()
+	})
+	keep := max(len(restarted)-int(cfg.Restart*float64(len(restarted))), cfg.Elite, 1)
+	replaced := append([]Genome(nil), restarted[keep:]...)
+
+	// the new genomes get a stream of random numbers of their own, the
+	// children of the generation use the others
+	rng := cfg.newRand(seed, generation, -1)
+	for i := keep; i < len(restarted); i++ {
+		restarted[i] = cfg.NewGenome(rng)
+	}
+	if cfg.Evaluate != nil {
+		cfg.Evaluate(restarted[keep:])
+	}
+	return restarted, replaced
+}
+
+// whether the run has bred as many generations as it's allowed to. The
+// generation being counted hasn't been bred yet.
+func (cfg Config) reachedMaxGenerations(stats Stats) bool {
+	return cfg.MaxGenerations > 0 && stats.Generations > cfg.MaxGenerations
+}
+
+// call work with every i from start up to end on a pool of as many workers
+// as Go runs at once, and wait for them all
+func parallel(start int, end int, work func(i int)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				work(i)
+			}
+		}()
+	}
+	for i := start; i < end; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// perform natural selection to create the next generation, the first elite
+// genomes of the sorted population are kept as they are and both parents of
+// every other child are picked with pick. The children are
+// bred, mutated and evaluated concurrently by a pool of workers, child i
+// with random numbers of its own from rngs(i) so the result doesn't depend
+// on which worker breeds it. Children that are BoundedGenomes are only evaluated up to the
+// bound, -1 means no bound. If evaluate isn't nil the children are evaluated
+// with it all together once they're bred instead. It returns the next
+// generation, the number of children fitter than both of their parents and
+// the number of children whose fitness is only known to be above the bound,
+// which are moved to the end of the next generation.
+func naturalSelection(pick func(rng *rand.Rand) Genome, population []Genome, elite int, bound int64, rngs func(i int) *rand.Rand, evaluate func([]Genome)) ([]Genome, int, int) {
+	next := make([]Genome, len(population))
+	copy(next, population[:elite])
+	// the fitness of the fitter parent of every child, and of the child and
+	// whether it's exact
+	parents := make([]int64, len(population))
+	fitness := make([]int64, len(population))
+	exact := make([]bool, len(population))
+
+	parallel(elite, len(population), func(i int) {
+		rng := rngs(i)
+		a := pick(rng)
+		b := pick(rng)
+
+		parents[i] = min(a.Fitness(), b.Fitness())
+
+		child := a.Crossover(b, rng)
+		child.Mutate(rng)
+		// work out the child's fitness now so it's ready for the
+		// next pool
+		switch bounded, ok := child.(BoundedGenome); {
+		case evaluate != nil:
+			// later, with the other children
+		case ok && bound >= 0:
+			fitness[i], exact[i] = bounded.FitnessBelow(bound)
+		default:
+			fitness[i], exact[i] = child.Fitness(), true
+		}
+
+		next[i] = child
+	})
+	if evaluate != nil {
+		evaluate(next[elite:])
+		for i := elite; i < len(next); i++ {
+			fitness[i], exact[i] = next[i].Fitness(), true
+		}
+	}
+	improved := 0
+	var estimated []Genome
+	ranked := next[:elite]
+	for i := elite; i < len(next); i++ {
+		if fitness[i] < parents[i] {
+			improved++
+		}
+		if exact[i] {
+			ranked = append(ranked, next[i])
+		} else {
+			estimated = append(estimated, next[i])
+		}
+	}
+	return append(ranked, estimated...), improved, len(estimated)
+}
+
+// recycle the genomes of the previous generation that aren't in the next
+// one. A genome can be in previous more than once, like an elite genome
+// that's replaced by a migrant, but it's only recycled once.
+func recycle(previous []Genome, next []Genome) {
+	kept := make(map[Recycler]bool, len(next))
+	for _, g := range next {
+		if r, ok := g.(Recycler); ok {
+			kept[r] = true
+		}
+	}
+	for _, g := range previous {
+		if r, ok := g.(Recycler); ok && !kept[r] {
+			r.Recycle()
+			kept[r] = true
+		}
+	}
+}
+
+// the fitness a child has to beat to have any chance of breeding in the next
+// generation, or -1 if any child might. The pool and truncation selectors only
+// pick from the fittest n genomes, so when at least n genomes are carried over
+// as elite a child that isn't fitter than the nth of them is never picked. The
+// elite come first in the next generation so they win ties.
+func breedingBound(s Selector, population []Genome, elite int) int64 {
+	var n int
+	switch s := s.(type) {
+	case PoolSelector:
+		n = min(s.Size, len(population)-1) + 1
+	case TruncationSelector:
+		n = min(s.Size, len(population))
+	default:
+		return -1
+	}
+	if n < 1 || elite < n {
+		return -1
+	}
+	return population[n-1].Fitness()
+}
@@ -0,0 +1,43 @@
+package ga
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+)
+
+// WriteGob writes v to the file with gob. It's written to a temporary file
+// first and then renamed, so an interrupted write doesn't destroy the
+// previous file.
+func WriteGob(filePath string, v interface{}) error {
+	tmpPath := filePath + ".tmp"
+	gobFile, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("cannot create file: %w", err)
+	}
+	err = gob.NewEncoder(gobFile).Encode(v)
+	if err != nil {
+		gobFile.Close()
+		return fmt.Errorf("cannot encode file: %w", err)
+	}
+	err = gobFile.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, filePath)
+}
+
+// ReadGob reads v from the gob file
+func ReadGob(filePath string, v interface{}) error {
+	gobFile, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("cannot read file: %w", err)
+	}
+	defer gobFile.Close()
+
+	err = gob.NewDecoder(gobFile).Decode(v)
+	if err != nil {
+		return fmt.Errorf("cannot decode file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package ga
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadGobErrors(t *testing.T) {
+	dir := t.TempDir()
+	corrupt := filepath.Join(dir, "corrupt.gob")
+	err := os.WriteFile(corrupt, []byte("not a gob"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, filePath := range []string{filepath.Join(dir, "missing.gob"), corrupt} {
+		var v struct{ Generation int }
+		err := ReadGob(filePath, &v)
+		if err == nil {
+			t.Errorf("read %s without an error", filePath)
+		}
+	}
+}
+
+func TestWriteGob(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "checkpoint.gob")
+	type checkpoint struct{ Generation int }
+	err := WriteGob(filePath, checkpoint{Generation: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c checkpoint
+	err = ReadGob(filePath, &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Generation != 7 {
+		t.Errorf("read generation %d, want 7", c.Generation)
+	}
+	// nothing's left of the temporary file it's written to first
+	_, err = os.Stat(filePath + ".tmp")
+	if !os.IsNotExist(err) {
+		t.Errorf("the temporary file is still there: %v", err)
+	}
+}
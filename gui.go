@@ -0,0 +1,126 @@
+//go:build gui
+
+package ga
+
+import (
+	"fmt"
+	"image"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// the length of the longer side of a window when it's opened, the image is
+// scaled to fit the window as it's resized
+const windowSize = 640
+
+// Window is a desktop window showing the best image of a run, scaled to fit
+// it, with the stats of the run over it. It's only built with -tags gui, as
+// it needs cgo and the headers of the platform's windowing system.
+type Window struct {
+	// OnClose is called when the window is closed before the run is done,
+	// it can be nil
+	OnClose      func()
+	title        string
+	width        int
+	height       int
+	mu           sync.Mutex
+	stats        Stats
+	mutationRate float64
+	// best is the best image, nil once it's been drawn on image
+	best  *image.RGBA
+	image *ebiten.Image
+}
+
+// NewWindow makes a window with the title showing images of the width and
+// height, which is opened by Run
+func NewWindow(title string, width, height int) (*Window, error) {
+	return &Window{title: title, width: width, height: height}, nil
+}
+
+// Update the window with the stats of the generation that was just bred
+// with the mutation rate, and the best image, which is copied
+func (win *Window) Update(stats Stats, mutationRate float64, best *image.RGBA) {
+	win.mu.Lock()
+	defer win.mu.Unlock()
+	win.stats, win.mutationRate = stats, mutationRate
+	// the organism the image belongs to may be recycled, and the image
+	// drawn on again, once Update returns
+	win.best = &image.RGBA{Pix: slices.Clone(best.Pix), Stride: best.Stride, Rect: best.Rect}
+}
+
+// Run calls run on another goroutine and shows the window on this one, which
+// must be the main goroutine, until run returns. The window can't be shown
+// without a display, Run still waits for run then and returns why.
+func (win *Window) Run(run func()) error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		run()
+	}()
+	scale := float64(windowSize) / float64(max(win.width, win.height))
+	ebiten.SetWindowTitle(win.title)
+	ebiten.SetWindowSize(max(int(float64(win.width)*scale), 1), max(int(float64(win.height)*scale), 1))
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	err := ebiten.RunGame(windowGame{win: win, done: done})
+	// the window was closed if there was no error and run isn't done
+	select {
+	case <-done:
+	default:
+		if err == nil && win.OnClose != nil {
+			win.OnClose()
+		}
+		<-done
+	}
+	return err
+}
+
+// windowGame draws the window for ebiten
+type windowGame struct {
+	win  *Window
+	done chan struct{}
+}
+
+func (g windowGame) Update() error {
+	select {
+	case <-g.done:
+		return ebiten.Termination
+	default:
+		return nil
+	}
+}
+
+func (g windowGame) Draw(screen *ebiten.Image) {
+	win := g.win
+	win.mu.Lock()
+	if win.best != nil {
+		r := win.best.Rect
+		if win.image == nil || win.image.Bounds().Size() != r.Size() {
+			win.image = ebiten.NewImage(r.Dx(), r.Dy())
+		}
+		win.image.WritePixels(win.best.Pix)
+		win.best = nil
+	}
+	stats, mutationRate := win.stats, win.mutationRate
+	win.mu.Unlock()
+	if win.image == nil {
+		ebitenutil.DebugPrint(screen, "waiting for the first generation")
+		return
+	}
+	sw, sh := screen.Bounds().Dx(), screen.Bounds().Dy()
+	iw, ih := win.image.Bounds().Dx(), win.image.Bounds().Dy()
+	scale := min(float64(sw)/float64(iw), float64(sh)/float64(ih))
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate((float64(sw)-float64(iw)*scale)/2, (float64(sh)-float64(ih)*scale)/2)
+	screen.DrawImage(win.image, op)
+	ebitenutil.DebugPrint(screen, fmt.Sprintf("generation %d | fitness %d\nmean %.0f ± %.0f | diversity %.2f\nmutation rate %g | pool size %d\nelapsed %s",
+		stats.Generations, stats.Fitness, stats.Mean, stats.StdDev, stats.Diversity, mutationRate, stats.PoolSize, stats.Elapsed.Round(time.Second)))
+}
+
+func (g windowGame) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return outsideWidth, outsideHeight
+}
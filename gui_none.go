@@ -0,0 +1,30 @@
+//go:build !gui
+
+package ga
+
+import (
+	"errors"
+	"image"
+)
+
+// Window is a desktop window showing the best image of a run, which needs
+// a build with -tags gui
+type Window struct {
+	// OnClose is called when the window is closed before the run is done,
+	// it can be nil
+	OnClose func()
+}
+
+// NewWindow fails without -tags gui
+func NewWindow(title string, width, height int) (*Window, error) {
+	return nil, errors.New("built without a window, build with -tags gui")
+}
+
+// Update does nothing without -tags gui
+func (win *Window) Update(stats Stats, mutationRate float64, best *image.RGBA) {}
+
+// Run calls run without -tags gui
+func (win *Window) Run(run func()) error {
+	run()
+	return nil
+}
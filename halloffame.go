@@ -0,0 +1,84 @@
+package ga
+
+import (
+	"sort"
+	"time"
+)
+
+// HallOfFame keeps the fittest genomes seen in a run, however long ago they
+// left the population, with when they were found. Genomes must be
+// comparable, which pointers are, so a genome that stays in the population
+// for many generations is only kept once.
+type HallOfFame struct {
+	size    int
+	entries []Fame
+}
+
+// Fame is a genome kept in a hall of fame
+type Fame struct {
+	Genome  Genome
+	Fitness int64
+	// Generation is the first generation the genome was in
+	Generation int
+	// Elapsed is how long into the run it was found
+	Elapsed time.Duration
+}
+
+// NewHallOfFame makes a hall of fame keeping the fittest size genomes
+func NewHallOfFame(size int) *HallOfFame {
+	return &HallOfFame{size: size}
+}
+
+// Add the genomes of the population of the generation that are fitter than
+// those kept so far, and return whether any of them were
+func (h *HallOfFame) Add(population []Genome, generation int, elapsed time.Duration) bool {
+	added := false
+	for _, g := range population {
+		fitness := g.Fitness()
+		if len(h.entries) == h.size && (h.size == 0 || fitness >= h.entries[len(h.entries)-1].Fitness) {
+			continue
+		}
+		if h.contains(g) {
+			continue
+		}
+		// the entries stay sorted, the first found of genomes as fit as
+		// each other goes first
+		i := sort.Search(len(h.entries), func(i int) bool {
+			return h.entries[i].Fitness > fitness
+		})
+		if len(h.entries) < h.size {
+			h.entries = append(h.entries, Fame{})
+		}
+		copy(h.entries[i+1:], h.entries[i:])
+		h.entries[i] = Fame{Genome: g, Fitness: fitness, Generation: generation, Elapsed: elapsed}
+		added = true
+	}
+	return added
+}
+
+// whether the genome is kept already
+func (h *HallOfFame) contains(g Genome) bool {
+	for _, e := range h.entries {
+		if e.Genome == g {
+			return true
+		}
+	}
+	return false
+}
+
+// Entries returns the genomes kept, fittest first
+func (h *HallOfFame) Entries() []Fame {
+	return append([]Fame(nil), h.entries...)
+}
+
+// the genomes kept
+func (h *HallOfFame) genomes() []Genome {
+	if h == nil {
+		return nil
+	}
+	genomes := make([]Genome, len(h.entries))
+	for i, e := range h.entries {
+		genomes[i] = e.Genome
+	}
+	return genomes
+}
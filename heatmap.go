@@ -0,0 +1,47 @@
+package ga
+
+import (
+	"image"
+)
+
+// Heatmap shows where the image differs from the target. The squared
+// difference of every pixel is scaled against the worst pixel and colored
+// from black (no difference) through red and yellow to white.
+func Heatmap(img, target *image.RGBA) *image.RGBA {
+	errs := make([]uint64, img.Rect.Dx()*img.Rect.Dy())
+	worst := uint64(0)
+	for p := 0; p < len(errs); p++ {
+		for i := p * 4; i < p*4+4; i++ {
+			errs[p] += squareDifference(img.Pix[i], target.Pix[i])
+		}
+		if errs[p] > worst {
+			worst = errs[p]
+		}
+	}
+
+	heat := image.NewRGBA(image.Rect(0, 0, img.Rect.Dx(), img.Rect.Dy()))
+	for p, e := range errs {
+		v := 0
+		if worst > 0 {
+			v = int(e * 255 / worst)
+		}
+		// spread 0-255 over the black, red, yellow, white ramp
+		t := v * 3
+		heat.Pix[p*4] = uint8(clamp(t, 0, 255))
+		heat.Pix[p*4+1] = uint8(clamp(t-255, 0, 255))
+		heat.Pix[p*4+2] = uint8(clamp(t-510, 0, 255))
+		heat.Pix[p*4+3] = 255
+	}
+	return heat
+}
+
+// clamp v to the range [lo, hi]
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
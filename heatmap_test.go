@@ -0,0 +1,38 @@
+package ga
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestHeatmapOfPerfectMatch(t *testing.T) {
+	target := gradientImage(20, 10, 0)
+	heat := Heatmap(target, target)
+	if heat.Rect != target.Rect {
+		t.Fatalf("the heatmap is %v, want %v", heat.Rect, target.Rect)
+	}
+	black := color.RGBA{0, 0, 0, 255}
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 20; x++ {
+			if c := heat.RGBAAt(x, y); c != black {
+				t.Fatalf("the heatmap of a perfect match is %v at (%d, %d), want black", c, x, y)
+			}
+		}
+	}
+}
+
+func TestHeatmapShowsWorstPixel(t *testing.T) {
+	target := gradientImage(20, 10, 0)
+	img := image.NewRGBA(target.Rect)
+	copy(img.Pix, target.Pix)
+	c := target.RGBAAt(3, 4)
+	img.SetRGBA(3, 4, color.RGBA{^c.R, ^c.G, ^c.B, c.A})
+	heat := Heatmap(img, target)
+	if c := heat.RGBAAt(3, 4); c != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("the only pixel that differs is %v on the heatmap, want white", c)
+	}
+	if c := heat.RGBAAt(4, 4); c != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("a pixel that matches is %v on the heatmap, want black", c)
+	}
+}
@@ -0,0 +1,62 @@
+package ga
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"os"
+)
+
+// Save the image as a PNG file
+func Save(filePath string, rgba *image.RGBA) error {
+	imgFile, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("cannot create file: %w", err)
+	}
+	err = png.Encode(imgFile, rgba.SubImage(rgba.Rect))
+	if err != nil {
+		imgFile.Close()
+		return fmt.Errorf("cannot encode image: %w", err)
+	}
+	return imgFile.Close()
+}
+
+// get the image
+func getImage(filePath string) (image.Image, error) {
+	imgFile, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read file: %w", err)
+	}
+	defer imgFile.Close()
+
+	img, _, err := image.Decode(imgFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode file: %w", err)
+	}
+
+	return img, nil
+}
+
+// Load an image as RGBA. Any PNG, JPEG or GIF can be loaded, whatever its
+// color model, and the image's bounds always start at (0, 0).
+func Load(filePath string) (*image.RGBA, error) {
+	img, err := getImage(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return ToRGBA(img), nil
+}
+
+// ToRGBA converts the image to RGBA with its bounds starting at (0, 0)
+func ToRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Rect, img, bounds.Min, draw.Src)
+	return rgba
+}
@@ -0,0 +1,66 @@
+package ga
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// write a PNG that's been cut short to a file in dir
+func corruptPNG(t *testing.T, dir string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	err := png.Encode(&buf, gradientImage(20, 20, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	filePath := filepath.Join(dir, "corrupt.png")
+	err = os.WriteFile(filePath, buf.Bytes()[:buf.Len()/2], 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filePath
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	for _, filePath := range []string{filepath.Join(dir, "missing.png"), corruptPNG(t, dir)} {
+		img, err := Load(filePath)
+		if err == nil {
+			t.Errorf("loaded %s without an error", filePath)
+		}
+		if img != nil {
+			t.Errorf("loaded an image from %s", filePath)
+		}
+		_, err = LoadWeights(filePath, image.NewRGBA(image.Rect(0, 0, 20, 20)))
+		if err == nil {
+			t.Errorf("loaded weights from %s without an error", filePath)
+		}
+	}
+}
+
+func TestSaveError(t *testing.T) {
+	err := Save(filepath.Join(t.TempDir(), "missing", "evolved.png"), gradientImage(4, 4, 0))
+	if err == nil {
+		t.Error("saved to a directory that doesn't exist without an error")
+	}
+}
+
+func TestLoadSaved(t *testing.T) {
+	img := gradientImage(20, 10, 0)
+	filePath := filepath.Join(t.TempDir(), "evolved.png")
+	err := Save(filePath, img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Rect != img.Rect || !bytes.Equal(loaded.Pix, img.Pix) {
+		t.Error("the loaded image isn't the one saved")
+	}
+}
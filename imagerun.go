@@ -0,0 +1,650 @@
+package ga
+
+import (
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"image"
+	"math"
+	"math/rand"
+)
+
+// RunConfig holds the parameters of evolving an image that looks like a
+// target that don't depend on what the genomes are, the configs of the demos
+// embed it
+type RunConfig struct {
+	// MutationRate is the rate of mutation
+	MutationRate float64
+	// PopSize is the size of the population
+	PopSize int
+	// PoolSize is the max size of the pool
+	PoolSize int
+	// Selection is the name of the selector that picks the genomes that
+	// breed each generation, see SelectorNames
+	Selection string
+	// TournamentSize is the number of genomes in each tournament when
+	// Selection is tournament
+	TournamentSize int
+	// Elite is the number of the fittest genomes of each generation that
+	// are carried over unchanged into the next one
+	Elite int
+	// SteadyState breeds only this many children each generation, which
+	// replace the least fit genomes if they're fitter, 0 breeds whole
+	// generations
+	SteadyState int
+	// Strategy is the name of the way each generation is bred, see
+	// StrategyNames
+	Strategy string
+	// Lambda is the number of children bred each generation by the plus and
+	// comma strategies, 0 breeds as many as PopSize
+	Lambda int
+	// Temperature is the temperature the anneal strategy starts at, in
+	// units of fitness, and Cooling the name of the way it cools down every
+	// generation by CoolingRate, see CoolingNames
+	Temperature float64
+	Cooling     string
+	CoolingRate float64
+	// Crossover is the name of the way a child takes its genes from its
+	// parents, see CrossoverNames
+	Crossover string
+	// BlendAlpha is how far past its parents a number of a child can be
+	// with the blend crossover, as a fraction of the distance between them
+	BlendAlpha float64
+	// FitnessLimit is the fitness of the evolved image we are satisfied with
+	FitnessLimit int64
+	// MaxGenerations stops the run once this many generations have been
+	// bred, 0 means no limit
+	MaxGenerations int
+	// Stagnation is the number of generations the best fitness can go
+	// without improving before the least fit genomes are replaced with
+	// new random ones, 0 never replaces them
+	Stagnation int
+	// Restart is the fraction of the population replaced when it
+	// stagnates, 1 keeps only the elite
+	Restart float64
+	// Islands splits the population into this many islands that evolve
+	// on their own, 0 or 1 evolves it as a whole. The pool size and elite
+	// are those of every island.
+	Islands int
+	// MigrationInterval is the number of generations between migrations,
+	// when copies of the Migrants fittest genomes of every island replace
+	// the least fit genomes of the islands it's linked to
+	MigrationInterval int
+	Migrants          int
+	// Topology is the name of the way the islands are linked, see
+	// TopologyNames
+	Topology string
+	// MutationSchedule is the name of the schedule the mutation rate is
+	// annealed by, see ScheduleNames. It starts at MutationStart times
+	// MutationRate and comes down to MutationRate over MutationGenerations
+	// generations. If it's empty the rate isn't annealed.
+	MutationSchedule string
+	// MutationStart is what MutationSchedule multiplies the mutation rate
+	// by at the start of the run
+	MutationStart float64
+	// MutationGenerations is the number of generations MutationSchedule
+	// takes to anneal the mutation rate
+	MutationGenerations int
+	// Hypermutation is the number of generations the best fitness can go
+	// without improving before the mutation rate is multiplied by
+	// HypermutationFactor, 0 never multiplies it
+	Hypermutation int
+	// HypermutationFactor is what the mutation rate is multiplied by when
+	// the population stagnates
+	HypermutationFactor float64
+	// HypermutationBurst is the number of generations the mutation rate
+	// takes to decay back to MutationRate after it's multiplied
+	HypermutationBurst int
+	// AdaptiveMutation adapts the mutation rate with the 1/5 success rule,
+	// multiplying or dividing it by this every generation depending on
+	// whether fewer or more than a fifth of the children improve on their
+	// parents. 0 keeps the rate as it is.
+	AdaptiveMutation float64
+	// Weights holds a weight between 0 and 1 for every pixel of the target,
+	// in the same order as the pixels in Pix. If it's nil every pixel counts
+	// the same.
+	Weights []float64
+	// Fitness is the name of the fitness function the evolved image is
+	// compared with the target by, see FitnessNames. Weights only apply to a
+	// WeightedFitness, SampleRate and Pyramid only to diff.
+	Fitness string
+	// SampleRate makes the fitness compare only every nth pixel of the
+	// images, which is less accurate but faster. 1 compares every pixel.
+	SampleRate int
+	// ExactBelow switches back to comparing every pixel once the best
+	// fitness is below it, 0 means never
+	ExactBelow int64
+	// Pyramid compares the images shrunk to half their size Pyramid times
+	// early in the run, which is less accurate but faster. They're compared
+	// at twice the size every time the best fitness has improved by
+	// PyramidStep since the last switch until they're compared at full size.
+	// 0 compares them at full size from the start.
+	Pyramid int
+	// PyramidStep is the fraction the best fitness has to improve by before
+	// the images are compared at the next size up
+	PyramidStep float64
+	// CacheSize is the number of fitness values remembered by a hash of the
+	// genome, so that genomes that come up again aren't evaluated again. 0
+	// turns the cache off.
+	CacheSize int
+	// Backend is the name of the Backend the children of every generation
+	// are scored on as one batch, see BackendNames. An unavailable backend
+	// falls back to the cpu one. Empty scores every child on its own as it's
+	// bred.
+	Backend string
+	// Seed is what every random number of the run is made from, so a run
+	// with the same seed and options is the same, 0 picks a random seed. A
+	// resumed run goes on with the seed of its checkpoint.
+	Seed int64
+	// RNG is the name of the source of the random numbers, see
+	// RandSourceNames, empty for math/rand's. A resumed run goes on with the
+	// source of its checkpoint.
+	RNG string
+	// HallOfFame keeps the fittest genomes of the whole run, it can be nil
+	HallOfFame *HallOfFame
+	// Control pauses and steers the run while it goes, it's given the
+	// mutation-rate and pool-size settings. It can be nil.
+	Control *Control
+}
+
+// RunStats describes how an image run went, with the mutation rate the last
+// generation was bred with
+type RunStats struct {
+	Stats
+	MutationRate float64
+}
+
+// Scoring is how the images of a run are compared with the target, which
+// changes as the run goes. A fitness is only kept while the scoring it was
+// worked out with is.
+type Scoring struct {
+	SampleRate int
+	Pyramid    int
+}
+
+// ImageGenome is a genome of an ImageRun whose fitness can be worked out
+// elsewhere, like on a backend
+type ImageGenome interface {
+	Genome
+	// Unscored returns the image to score when the fitness isn't known yet,
+	// nil otherwise
+	Unscored() *image.RGBA
+	// SetFitness sets the fitness worked out for the image with the current
+	// scoring
+	SetFitness(fitness int64)
+}
+
+// Checkpoint is what's saved of an image run so that it can be continued
+// exactly where it stopped, G being what's saved of every genome
+type Checkpoint[G any] struct {
+	// Generation is the number of generations bred so far
+	Generation int
+	// Seed is the seed of the run
+	Seed int64
+	// RNG is the source of the random numbers of the run
+	RNG string
+	// SampleRate is the sample rate the run had got to, it changes when the
+	// fitness gets below ExactBelow
+	SampleRate int
+	// Pyramid is the pyramid level the run had got to and PyramidStart the
+	// best fitness when it last changed
+	Pyramid      int
+	PyramidStart int64
+	// Population is the population of the next generation
+	Population []G
+	// Best is the best genome found so far, which may have left the
+	// population. It's nil in checkpoints saved before it was kept.
+	Best *G
+	// Mutation is how the mutation rate had changed, nil in checkpoints
+	// saved before it was kept
+	Mutation *MutationState
+}
+
+// MutationState is how the mutation rate of an image run had changed when
+// it was saved
+type MutationState struct {
+	// BaseRate is the rate the mutation controls adapt every generation,
+	// which can have been changed with the control, and Rate the one the
+	// next generation is bred with
+	BaseRate float64
+	Rate     float64
+	// Hypermutation and OneFifthRule are the states of those controls, nil
+	// when the run doesn't have them
+	Hypermutation *Hypermutation
+	OneFifthRule  *OneFifthRule
+}
+
+// ImageRun is what every genome of a run evolving an image towards a target
+// shares: how it's scored and the mutation rate, which both change as the
+// run goes
+type ImageRun struct {
+	target *image.RGBA
+	// cfg holds the mutation rate set with the control, which the mutation
+	// controls start from every generation
+	cfg       RunConfig
+	selector  Selector
+	crossover Crossover
+	source    func(seed int64) rand.Source
+	fitness   Fitness
+	// scoring and mutationRate are the current ones
+	scoring      Scoring
+	mutationRate float64
+	// pyramid holds the target at every size the images are compared at
+	// and pyramidStart the best fitness when the size last changed
+	pyramid      *Pyramid
+	pyramidStart int64
+	// cache holds the fitness of genomes by their hash made with hashSeed,
+	// it's nil when there's no cache
+	cache    *FitnessCache
+	hashSeed maphash.Seed
+	// controls change the mutation rate as the run goes, hypermutation and
+	// oneFifth are those of them with a state, nil when there are none
+	controls      []MutationControl
+	hypermutation *Hypermutation
+	oneFifth      *OneFifthRule
+	// backend scores the children of every generation, it's nil when
+	// they're scored on their own
+	backend Backend
+}
+
+// check that the parameters can be used to evolve an image towards the
+// target
+func (cfg RunConfig) validate(target *image.RGBA) error {
+	// annealing and hill climbing need only one genome, the other
+	// strategies two parents for every child
+	if cfg.Strategy == "anneal" || cfg.Strategy == "hill-climb" {
+		if cfg.PopSize < 1 {
+			return errors.New("population size must be at least 1")
+		}
+	} else if cfg.PopSize < 2 {
+		return errors.New("population size must be at least 2")
+	}
+	if cfg.Strategy == "generational" && (cfg.PoolSize < 1 || cfg.PoolSize >= cfg.PopSize) {
+		return fmt.Errorf("pool size must be between 1 and %d", cfg.PopSize-1)
+	}
+	if cfg.Elite < 0 || cfg.Elite >= cfg.PopSize {
+		return fmt.Errorf("elite count must be between 0 and %d", cfg.PopSize-1)
+	}
+	if cfg.SteadyState < 0 || cfg.SteadyState > cfg.PopSize {
+		return fmt.Errorf("steady state children must be between 0 and %d", cfg.PopSize)
+	}
+	if cfg.Lambda < 0 {
+		return errors.New("lambda cannot be negative")
+	}
+	if cfg.Stagnation < 0 {
+		return errors.New("stagnation cannot be negative")
+	}
+	if cfg.Stagnation > 0 && (cfg.Restart <= 0 || cfg.Restart > 1) {
+		return errors.New("restart fraction must be above 0 and at most 1")
+	}
+	if cfg.Islands < 0 {
+		return errors.New("island count cannot be negative")
+	}
+	if cfg.Islands > 1 {
+		if cfg.PopSize < 2*cfg.Islands {
+			return fmt.Errorf("population size must be at least %d for %d islands", 2*cfg.Islands, cfg.Islands)
+		}
+		if cfg.MigrationInterval < 1 {
+			return errors.New("migration interval must be at least 1")
+		}
+		if cfg.Migrants < 0 || cfg.Migrants >= cfg.PopSize/cfg.Islands {
+			return fmt.Errorf("migrant count must be between 0 and %d", cfg.PopSize/cfg.Islands-1)
+		}
+	}
+	if cfg.Hypermutation < 0 {
+		return errors.New("hypermutation trigger cannot be negative")
+	}
+	if cfg.Hypermutation > 0 && (cfg.HypermutationFactor < 1 || cfg.HypermutationBurst < 1) {
+		return errors.New("hypermutation factor and burst must be at least 1")
+	}
+	if cfg.AdaptiveMutation != 0 && cfg.AdaptiveMutation <= 1 {
+		return errors.New("adaptive mutation factor must be above 1")
+	}
+	if cfg.SampleRate < 1 {
+		return errors.New("sample rate must be at least 1")
+	}
+	if cfg.Fitness != "diff" && cfg.SampleRate > 1 {
+		return errors.New("sampling only works with the diff fitness")
+	}
+	if cfg.Backend != "" && (cfg.SampleRate > 1 || cfg.Pyramid > 0) {
+		return errors.New("backends don't work with sampling or the pyramid")
+	}
+	if cfg.CacheSize < 0 {
+		return errors.New("cache size cannot be negative")
+	}
+	if cfg.Pyramid < 0 {
+		return errors.New("pyramid levels cannot be negative")
+	}
+	if cfg.Fitness != "diff" && cfg.Pyramid > 0 {
+		return errors.New("the pyramid only works with the diff fitness")
+	}
+	if cfg.Pyramid > 0 && (cfg.PyramidStep <= 0 || cfg.PyramidStep >= 1) {
+		return errors.New("pyramid step must be between 0 and 1")
+	}
+	if cfg.Weights != nil && len(cfg.Weights) != target.Rect.Dx()*target.Rect.Dy() {
+		return errors.New("there must be one weight for every pixel of the target")
+	}
+	return nil
+}
+
+// NewImageRun checks the parameters and makes the run of evolving an image
+// towards the target with them. It must be closed once the run is over.
+func NewImageRun(target *image.RGBA, cfg RunConfig) (*ImageRun, error) {
+	err := cfg.validate(target)
+	if err != nil {
+		return nil, err
+	}
+	r := &ImageRun{
+		target:       target,
+		cfg:          cfg,
+		scoring:      Scoring{SampleRate: cfg.SampleRate, Pyramid: cfg.Pyramid},
+		mutationRate: cfg.MutationRate,
+	}
+	r.selector, err = NewSelector(cfg.Selection, cfg.PoolSize, cfg.TournamentSize)
+	if err != nil {
+		return nil, err
+	}
+	r.fitness, err = NewFitness(cfg.Fitness)
+	if err != nil {
+		return nil, err
+	}
+	r.crossover, err = NewCrossover(cfg.Crossover)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Weights != nil {
+		weighted, ok := r.fitness.(WeightedFitness)
+		if !ok {
+			return nil, fmt.Errorf("the %s fitness cannot be weighted", cfg.Fitness)
+		}
+		r.fitness = weighted.Weighted(cfg.Weights)
+	}
+	if r.cfg.Seed == 0 {
+		r.cfg.Seed = rand.Int63()
+	}
+	r.source, err = RandSource(cfg.RNG)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Pyramid > 0 {
+		r.pyramid = NewPyramid(target, cfg.Weights, cfg.Pyramid)
+	}
+	if cfg.CacheSize > 0 {
+		r.cache = NewFitnessCache(cfg.CacheSize)
+		r.hashSeed = maphash.MakeSeed()
+	}
+	if cfg.MutationSchedule != "" {
+		schedule, err := NewSchedule(cfg.MutationSchedule, cfg.MutationStart, cfg.MutationGenerations)
+		if err != nil {
+			return nil, err
+		}
+		r.controls = append(r.controls, schedule)
+	}
+	if cfg.Hypermutation > 0 {
+		r.hypermutation = NewHypermutation(cfg.Hypermutation, cfg.HypermutationFactor, cfg.HypermutationBurst)
+		r.controls = append(r.controls, r.hypermutation)
+	}
+	if cfg.AdaptiveMutation > 0 {
+		r.oneFifth = NewOneFifthRule(cfg.AdaptiveMutation)
+		r.controls = append(r.controls, r.oneFifth)
+	}
+	if cfg.Backend != "" {
+		r.backend, _, err = NewBackend(cfg.Backend, target, r.fitness)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Control != nil {
+		// the schedules and controls of the mutation rate go on from the
+		// new rate
+		cfg.Control.Setting("mutation-rate", func() float64 {
+			return r.mutationRate
+		}, func(rate float64) error {
+			if rate < 0 || rate > 1 {
+				return errors.New("mutation rate must be between 0 and 1")
+			}
+			r.cfg.MutationRate, r.mutationRate = rate, rate
+			return nil
+		})
+	}
+	return r, nil
+}
+
+// Close releases the backend of the run
+func (r *ImageRun) Close() error {
+	if r.backend == nil {
+		return nil
+	}
+	return r.backend.Close()
+}
+
+// Config returns the configuration to breed the genomes of the run with
+// Evolve. Its Progress adapts the scoring and the mutation rate after every
+// generation and then calls progress, which can be nil, with the stats of
+// the generation. Its Evaluate scores the children on the backend if there
+// is one, they must be ImageGenomes then. The caller sets the rest, like
+// NewGenome.
+func (r *ImageRun) Config(progress func(stats RunStats, best Genome)) Config {
+	cfg := Config{
+		PoolSize:          r.cfg.PoolSize,
+		FitnessLimit:      r.cfg.FitnessLimit,
+		MaxGenerations:    r.cfg.MaxGenerations,
+		Seed:              r.cfg.Seed,
+		Source:            r.source,
+		Selector:          r.selector,
+		Elite:             r.cfg.Elite,
+		SteadyState:       r.cfg.SteadyState,
+		Strategy:          r.cfg.Strategy,
+		Lambda:            r.cfg.Lambda,
+		Temperature:       r.cfg.Temperature,
+		Cooling:           r.cfg.Cooling,
+		CoolingRate:       r.cfg.CoolingRate,
+		Stagnation:        r.cfg.Stagnation,
+		Restart:           r.cfg.Restart,
+		Islands:           r.cfg.Islands,
+		MigrationInterval: r.cfg.MigrationInterval,
+		Migrants:          r.cfg.Migrants,
+		Topology:          r.cfg.Topology,
+		HallOfFame:        r.cfg.HallOfFame,
+		Control:           r.cfg.Control,
+		Progress: func(stats Stats, best Genome) {
+			mutationRate := r.mutationRate
+			r.adapt(stats)
+			if progress != nil {
+				progress(RunStats{Stats: stats, MutationRate: mutationRate}, best)
+			}
+		},
+	}
+	if r.backend != nil {
+		cfg.Evaluate = r.evaluate
+	}
+	return cfg
+}
+
+// adapt the scoring and the mutation rate to the stats of the last
+// generation
+func (r *ImageRun) adapt(stats Stats) {
+	scoring := r.scoring
+	// sampled fitness is only an estimate, so compare every pixel once
+	// we're close. Genomes work out their fitness again when the scoring
+	// changes.
+	if r.scoring.SampleRate > 1 && stats.Fitness < r.cfg.ExactBelow {
+		r.scoring.SampleRate = 1
+	}
+	// likewise compare bigger images as the fitness improves
+	if r.scoring.Pyramid > 0 {
+		if r.pyramidStart == 0 {
+			r.pyramidStart = stats.Fitness
+		} else if float64(stats.Fitness) < float64(r.pyramidStart)*(1-r.cfg.PyramidStep) {
+			r.scoring.Pyramid--
+			r.pyramidStart = 0
+		}
+	}
+	// cached fitness is stale once the images are compared differently
+	if r.cache != nil && r.scoring != scoring {
+		r.cache.Clear()
+	}
+	if len(r.controls) > 0 {
+		r.mutationRate = r.cfg.MutationRate
+		for _, c := range r.controls {
+			r.mutationRate = c.Adapt(r.mutationRate, stats)
+		}
+	}
+}
+
+// Seed returns the seed of the run, a random one if none was set
+func (r *ImageRun) Seed() int64 {
+	return r.cfg.Seed
+}
+
+// Rand returns the random numbers of the run made from the seed
+func (r *ImageRun) Rand(seed int64) *rand.Rand {
+	return rand.New(r.source(seed))
+}
+
+// MutationRate returns the current mutation rate
+func (r *ImageRun) MutationRate() float64 {
+	return r.mutationRate
+}
+
+// Scoring returns how the images are compared with the target now
+func (r *ImageRun) Scoring() Scoring {
+	return r.scoring
+}
+
+// Crossover returns which parent each of the n genes of a child comes from
+func (r *ImageRun) Crossover(n int, rng *rand.Rand) func(i int) bool {
+	return r.crossover(n, rng)
+}
+
+// Incremental is whether the fitness is the plain diff, which genomes can
+// keep up to date as they mutate instead of comparing every pixel again
+func (r *ImageRun) Incremental() bool {
+	return r.cfg.Fitness == "diff" && r.cfg.Weights == nil && r.scoring == Scoring{SampleRate: 1}
+}
+
+// Score how far the image is from the target with the current scoring,
+// giving up once the score is known to be above bound if the fitness can,
+// in which case false is returned. With a cache the score is looked up and
+// kept by the hash of the genome, made with the seed by hash.
+func (r *ImageRun) Score(img *image.RGBA, bound int64, hash func(seed maphash.Seed) uint64) (int64, bool) {
+	var key uint64
+	if r.cache != nil {
+		key = hash(r.hashSeed)
+		if fitness, ok := r.cache.Get(key); ok {
+			return fitness, true
+		}
+	}
+	fitness, exact := r.score(img, bound)
+	if exact && r.cache != nil {
+		r.cache.Put(key, fitness)
+	}
+	return fitness, exact
+}
+
+// score the image without the cache. The diff fitness is made from the
+// weights and the sample rate every time as the sample rate and the pyramid
+// level can change during a run.
+func (r *ImageRun) score(img *image.RGBA, bound int64) (int64, bool) {
+	if r.scoring.Pyramid > 0 {
+		return r.pyramid.Diff(img, r.scoring.Pyramid, r.scoring.SampleRate), true
+	}
+	fitness := r.fitness
+	if r.cfg.Fitness == "diff" {
+		fitness = DiffFitness{Weights: r.cfg.Weights, SampleRate: r.scoring.SampleRate}
+	}
+	if bounded, ok := fitness.(BoundedFitness); ok && bound < math.MaxInt64 {
+		return bounded.ScoreBelow(img, r.target, bound)
+	}
+	return fitness.Score(img, r.target), true
+}
+
+// score the genomes that don't know their fitness yet as one batch on the
+// backend. If the backend fails they're left to work it out themselves.
+func (r *ImageRun) evaluate(genomes []Genome) {
+	var unscored []ImageGenome
+	var images []*image.RGBA
+	for _, g := range genomes {
+		g := g.(ImageGenome)
+		if img := g.Unscored(); img != nil {
+			unscored = append(unscored, g)
+			images = append(images, img)
+		}
+	}
+	scores := make([]int64, len(images))
+	if r.backend.Score(images, scores) != nil {
+		return
+	}
+	for i, g := range unscored {
+		g.SetFitness(scores[i])
+	}
+}
+
+// NewCheckpoint saves the state of the run, every genome of the population
+// and the best one saved with save
+func NewCheckpoint[G any](r *ImageRun, state State, save func(g Genome) G) Checkpoint[G] {
+	c := Checkpoint[G]{
+		Generation:   state.Generation,
+		Seed:         state.Seed,
+		RNG:          r.cfg.RNG,
+		SampleRate:   r.scoring.SampleRate,
+		Pyramid:      r.scoring.Pyramid,
+		PyramidStart: r.pyramidStart,
+		Population:   make([]G, len(state.Population)),
+		Mutation:     &MutationState{BaseRate: r.cfg.MutationRate, Rate: r.mutationRate},
+	}
+	for i, g := range state.Population {
+		c.Population[i] = save(g)
+	}
+	if state.Best != nil {
+		best := save(state.Best)
+		c.Best = &best
+	}
+	// copies, as the controls go on changing
+	if r.hypermutation != nil {
+		h := *r.hypermutation
+		c.Mutation.Hypermutation = &h
+	}
+	if r.oneFifth != nil {
+		o := *r.oneFifth
+		c.Mutation.OneFifthRule = &o
+	}
+	return c
+}
+
+// Resume sets the run and the configuration made by Config up to go on
+// from the checkpoint, and returns the population to pass to Evolve, every
+// genome of it and the best one made by load. The controls of the mutation
+// rate go on from where they had got to if the run still has them, with
+// the parameters it has now.
+func Resume[G any](r *ImageRun, cfg *Config, c Checkpoint[G], load func(g G) Genome) ([]Genome, error) {
+	if len(c.Population) != r.cfg.PopSize {
+		return nil, fmt.Errorf("the checkpoint holds %d genomes but the population size is %d",
+			len(c.Population), r.cfg.PopSize)
+	}
+	source, err := RandSource(c.RNG)
+	if err != nil {
+		return nil, err
+	}
+	r.cfg.Seed, r.cfg.RNG, r.source = c.Seed, c.RNG, source
+	r.scoring = Scoring{SampleRate: c.SampleRate, Pyramid: min(c.Pyramid, r.cfg.Pyramid)}
+	r.pyramidStart = c.PyramidStart
+	if m := c.Mutation; m != nil {
+		r.cfg.MutationRate, r.mutationRate = m.BaseRate, m.Rate
+		if r.hypermutation != nil && m.Hypermutation != nil {
+			r.hypermutation.Best, r.hypermutation.Improved, r.hypermutation.Started = m.Hypermutation.Best, m.Hypermutation.Improved, m.Hypermutation.Started
+		}
+		if r.oneFifth != nil && m.OneFifthRule != nil {
+			r.oneFifth.Scale = m.OneFifthRule.Scale
+		}
+	}
+	cfg.Seed, cfg.Source, cfg.Generation = c.Seed, source, c.Generation
+	population := make([]Genome, len(c.Population))
+	for i, g := range c.Population {
+		population[i] = load(g)
+	}
+	if c.Best != nil {
+		cfg.Best = load(*c.Best)
+	}
+	return population, nil
+}
@@ -0,0 +1,64 @@
+package ga
+
+import (
+	"image"
+	"math"
+)
+
+// linear holds the linear light value of every 8 bit sRGB value
+var linear [256]float64
+
+func init() {
+	for i := range linear {
+		v := float64(i) / 255
+		if v <= 0.04045 {
+			linear[i] = v / 12.92
+		} else {
+			linear[i] = math.Pow((v+0.055)/1.055, 2.4)
+		}
+	}
+}
+
+// LabDiff is the difference between 2 images as the sum of the CIE76 ΔE
+// distances between their pixels in the CIELAB color space. Distances in
+// CIELAB are much closer to how different people see 2 colors than distances
+// in RGB, so colors like skin tones come out more faithfully. Alpha is
+// ignored.
+func LabDiff(a, b *image.RGBA) int64 {
+	return WeightedLabDiff(a, b, nil)
+}
+
+// WeightedLabDiff is LabDiff with the distance of each pixel multiplied by
+// its weight. If weights is nil every pixel counts the same.
+func WeightedLabDiff(a, b *image.RGBA, weights []float64) int64 {
+	d := 0.0
+	for i := 0; i+3 < len(a.Pix); i += 4 {
+		l1, a1, b1 := toLab(a.Pix[i], a.Pix[i+1], a.Pix[i+2])
+		l2, a2, b2 := toLab(b.Pix[i], b.Pix[i+1], b.Pix[i+2])
+		e := math.Sqrt((l1-l2)*(l1-l2) + (a1-a2)*(a1-a2) + (b1-b2)*(b1-b2))
+		if weights != nil {
+			e *= weights[i/4]
+		}
+		d += e
+	}
+	return int64(d)
+}
+
+// convert an sRGB color to CIELAB with a D65 white point
+func toLab(r, g, b uint8) (float64, float64, float64) {
+	lr, lg, lb := linear[r], linear[g], linear[b]
+	x := (0.4124*lr + 0.3576*lg + 0.1805*lb) / 0.95047
+	y := 0.2126*lr + 0.7152*lg + 0.0722*lb
+	z := (0.0193*lr + 0.1192*lg + 0.9505*lb) / 1.08883
+	fx, fy, fz := labF(x), labF(y), labF(z)
+	return 116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)
+}
+
+// the nonlinear part of the XYZ to CIELAB conversion
+func labF(t float64) float64 {
+	const delta = 6.0 / 29
+	if t > delta*delta*delta {
+		return math.Cbrt(t)
+	}
+	return t/(3*delta*delta) + 4.0/29
+}
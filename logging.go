@@ -0,0 +1,64 @@
+package ga
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// logLevels are the levels a run can log at by name, quiet only logs errors
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"quiet": slog.LevelError,
+}
+
+// LogLevelNames returns the names of the levels a run can log at
+func LogLevelNames() []string {
+	names := make([]string, 0, len(logLevels))
+	for name := range logLevels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseLogLevel returns the level with the given name
+func ParseLogLevel(name string) (slog.Level, error) {
+	level, ok := logLevels[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown log level %q, use one of %s", name, strings.Join(LogLevelNames(), ", "))
+	}
+	return level, nil
+}
+
+// NewLogger returns a logger writing records at or above the level to w in
+// the format, text for key=value pairs or json for JSON lines
+func NewLogger(w io.Writer, format string, level slog.Level) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q, use text or json", format)
+}
+
+// StatsAttrs returns the stats of a generation as attributes to log them with
+func StatsAttrs(stats Stats) []any {
+	return []any{
+		slog.Int("generation", stats.Generations),
+		slog.Int64("fitness", stats.Fitness),
+		slog.Int64("best", stats.Best),
+		slog.Int64("median", stats.Median),
+		slog.Int64("worst", stats.Worst),
+		slog.Float64("mean", stats.Mean),
+		slog.Float64("stddev", stats.StdDev),
+		slog.Float64("diversity", stats.Diversity),
+		slog.Int("pool_size", stats.PoolSize),
+		slog.Duration("elapsed", stats.Elapsed),
+	}
+}
@@ -0,0 +1,86 @@
+package ga
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics are Prometheus metrics of how a run is going, with those of the Go
+// runtime and the process such as memory. The generation rate and the
+// evaluations a second are the rate of the counters, e.g.
+// rate(ga_generations_total[1m]).
+type Metrics struct {
+	registry    *prometheus.Registry
+	generations prometheus.Counter
+	evaluations prometheus.Counter
+	fitness     prometheus.Gauge
+	best        prometheus.Gauge
+	mean        prometheus.Gauge
+	worst       prometheus.Gauge
+	diversity   prometheus.Gauge
+	poolSize    prometheus.Gauge
+}
+
+// NewMetrics makes the metrics of a run
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		generations: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ga_generations_total", Help: "Generations bred.",
+		}),
+		evaluations: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ga_evaluations_total", Help: "Children bred and evaluated.",
+		}),
+		fitness: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ga_fitness", Help: "Fitness of the best genome found so far, the lower the better.",
+		}),
+		best: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ga_generation_best_fitness", Help: "Fitness of the fittest genome of the last generation.",
+		}),
+		mean: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ga_generation_mean_fitness", Help: "Mean fitness of the last generation.",
+		}),
+		worst: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ga_generation_worst_fitness", Help: "Fitness of the least fit genome of the last generation.",
+		}),
+		diversity: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ga_diversity", Help: "Fraction of the last generation with a fitness no other genome has.",
+		}),
+		poolSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ga_pool_size", Help: "Size of the pool the last generation was bred from.",
+		}),
+	}
+	m.registry.MustRegister(m.generations, m.evaluations, m.fitness, m.best, m.mean, m.worst, m.diversity, m.poolSize,
+		collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	return m
+}
+
+// Observe the stats of a generation that was just bred
+func (m *Metrics) Observe(stats Stats) {
+	m.generations.Inc()
+	m.evaluations.Add(float64(stats.Children))
+	m.fitness.Set(float64(stats.Fitness))
+	m.best.Set(float64(stats.Best))
+	m.mean.Set(stats.Mean)
+	m.worst.Set(float64(stats.Worst))
+	m.diversity.Set(stats.Diversity)
+	m.poolSize.Set(float64(stats.PoolSize))
+}
+
+// Serve the metrics on /metrics of the address, e.g. :9090, until the
+// program exits
+func (m *Metrics) Serve(addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("cannot listen: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	go http.Serve(lis, mux)
+	return nil
+}
@@ -0,0 +1,6 @@
+//go:build gpu
+
+package main
+
+// the opencl backend registers itself when it's imported
+import _ "github.com/sensorphalanx/ga/opencl"
@@ -1,243 +1,93 @@
 package main
 
 import (
-	"bytes"
-	"encoding/base64"
+	"context"
+	"flag"
 	"fmt"
 	"image"
-	"image/png"
-	"math"
-	"math/rand"
-	"os"
-	"sort"
-	"time"
-)
-
-// MutationRate is the rate of mutation
-var MutationRate = 0.0004
-
-// PopSize is the size of the population
-var PopSize = 250
-
-// PoolSize is the max size of the pool
-var PoolSize = 30
+	"path/filepath"
 
-// FitnessLimit is the fitness of the evolved image we are satisfied with
-var FitnessLimit int64 = 7500
+	"github.com/sensorphalanx/ga"
+	"github.com/sensorphalanx/ga/pixels"
+)
 
 func main() {
-	start := time.Now()
-	rand.Seed(time.Now().UTC().UnixNano())
-	target := load("./ml.png")
-	printImage(target.SubImage(target.Rect))
-	population := createPopulation(target)
-
-	found := false
-	generation := 0
-	for !found {
-		generation++
-		bestOrganism := getBest(population)
-		if bestOrganism.Fitness < FitnessLimit {
-			found = true
-		} else {
-			pool := createPool(population, target)
-			population = naturalSelection(pool, population, target)
-			if generation%100 == 0 {
-				sofar := time.Since(start)
-				fmt.Printf("\nTime taken so far: %s | generation: %d | fitness: %d | pool size: %d", sofar, generation, bestOrganism.Fitness, len(pool))
-				save("./evolved.png", bestOrganism.DNA)
-				fmt.Println()
-				printImage(bestOrganism.DNA.SubImage(bestOrganism.DNA.Rect))
-			}
-		}
-
-	}
-	elapsed := time.Since(start)
-	fmt.Printf("\nTotal time taken: %s\n", elapsed)
-}
-
-// create a random image
-func createRandomImageFrom(img *image.RGBA) (created *image.RGBA) {
-	pix := make([]uint8, len(img.Pix))
-	rand.Read(pix)
-	created = &image.RGBA{
-		Pix:    pix,
-		Stride: img.Stride,
-		Rect:   img.Rect,
-	}
-	return
-}
-
-// save the image
-func save(filePath string, rgba *image.RGBA) {
-	imgFile, err := os.Create(filePath)
-	defer imgFile.Close()
-	if err != nil {
-		fmt.Println("Cannot create file:", err)
-	}
-
-	png.Encode(imgFile, rgba.SubImage(rgba.Rect))
-}
-
-// load the image
-func load(filePath string) *image.RGBA {
-	imgFile, err := os.Open(filePath)
-	defer imgFile.Close()
-	if err != nil {
-		fmt.Println("Cannot read file:", err)
-	}
-
-	img, _, err := image.Decode(imgFile)
+	cfg := pixels.DefaultConfig()
+	cli := ga.NewCLI[pixels.Genome](flag.CommandLine, &cfg.RunConfig, 100)
+	resume := flag.String("resume", "", "genome or checkpoint file saved by an earlier run to continue evolving from")
+	seedImage := flag.String("seed-image", "", "image saved by an earlier run, like evolved.png, to start a new run from, resized to the target")
+	flag.BoolVar(&cfg.SeedFromTarget, "seed-from-target", cfg.SeedFromTarget, "start from jittered copies of the target instead of random noise")
+	flag.IntVar(&cfg.Jitter, "jitter", cfg.Jitter, "max per-byte jitter when seeding from the target")
+	flag.IntVar(&cfg.SeedBlur, "seed-blur", cfg.SeedBlur, "blur the target by this radius before seeding from it, 0 seeds from it as it is")
+	flag.Parse()
+	target, err := cli.Start()
 	if err != nil {
-		fmt.Println("Cannot decode file:", err)
-	}
-	return img.(*image.RGBA)
-}
-
-// difference between 2 images
-func diff(a, b *image.RGBA) (d int64) {
-	d = 0
-	for i := 0; i < len(a.Pix); i++ {
-		d += int64(squareDifference(a.Pix[i], b.Pix[i]))
+		fmt.Println("Cannot evolve image:", err)
+		return
 	}
 
-	return int64(math.Sqrt(float64(d)))
-}
-
-// square the difference
-func squareDifference(x, y uint8) uint64 {
-	d := uint64(x) - uint64(y)
-	return d * d
-}
-
-// create the reproduction pool that creates the next generation
-func createPool(population []Organism, target *image.RGBA) (pool []Organism) {
-	pool = make([]Organism, 0)
-	// get top best fitting organisms
-	sort.SliceStable(population, func(i, j int) bool {
-		return population[i].Fitness < population[j].Fitness
-	})
-	top := population[0 : PoolSize+1]
-	// if there is no difference between the top  organisms, the population is stable
-	// and we can't get generate a proper breeding pool so we make the pool equal to the
-	// population and reproduce the next generation
-	if top[len(top)-1].Fitness-top[0].Fitness == 0 {
-		pool = population
+	w, h := target.Rect.Dx(), target.Rect.Dy()
+	if *resume != "" && *seedImage != "" {
+		fmt.Println("Cannot both resume a run and seed a new one, use -resume or -seed-image")
 		return
 	}
-	// create a pool for next generation
-	for i := 0; i < len(top)-1; i++ {
-		num := (top[PoolSize].Fitness - top[i].Fitness)
-		for n := int64(0); n < num; n++ {
-			pool = append(pool, top[i])
+	if *resume != "" {
+		// a checkpoint continues the run exactly, a genome starts a new run
+		// from the organism
+		checkpoint, err := pixels.LoadCheckpoint(*resume)
+		if err == nil {
+			cfg.Resume = &checkpoint
+			cfg.PopSize = len(checkpoint.Population)
 		}
 	}
-	return
-}
-
-// perform natural selection to create the next generation
-func naturalSelection(pool []Organism, population []Organism, target *image.RGBA) []Organism {
-	next := make([]Organism, len(population))
-
-	for i := 0; i < len(population); i++ {
-		r1, r2 := rand.Intn(len(pool)), rand.Intn(len(pool))
-		a := pool[r1]
-		b := pool[r2]
-
-		child := crossover(a, b)
-		child.mutate()
-		child.calcFitness(target)
-
-		next[i] = child
-	}
-	return next
-}
-
-// creates the initial population
-func createPopulation(target *image.RGBA) (population []Organism) {
-	population = make([]Organism, PopSize)
-	for i := 0; i < PopSize; i++ {
-		population[i] = createOrganism(target)
+	if *resume != "" && cfg.Resume == nil {
+		genome, err := pixels.LoadGenome(*resume)
+		if err != nil {
+			fmt.Println("Cannot load genome:", err)
+			return
+		}
+		cfg.Start, err = genome.Fit(w, h)
+		if err != nil {
+			fmt.Println("Cannot resume:", err)
+			return
+		}
 	}
-	return
-}
-
-// Get the best gene
-func getBest(population []Organism) Organism {
-	best := int64(0)
-	index := 0
-	for i := 0; i < len(population); i++ {
-		if population[i].Fitness > best {
-			index = i
-			best = population[i].Fitness
+	if *seedImage != "" {
+		seed, err := ga.Load(*seedImage)
+		if err != nil {
+			fmt.Println("Cannot load seed image:", err)
+			return
+		}
+		if seed.Rect.Dx() != w || seed.Rect.Dy() != h {
+			fmt.Printf("Resizing seed image from %dx%d to fit the %dx%d target\n", seed.Rect.Dx(), seed.Rect.Dy(), w, h)
+			seed = ga.Resize(seed, w, h)
 		}
+		cfg.Start = seed
 	}
-	return population[index]
-}
-
-// Organism represents the genotype of the GA
-type Organism struct {
-	DNA     *image.RGBA
-	Fitness int64
-}
 
-// generates a Organism string
-func createOrganism(target *image.RGBA) (organism Organism) {
-	organism = Organism{
-		DNA:     createRandomImageFrom(target),
-		Fitness: 0,
+	cli.Image = func(g ga.Genome) *image.RGBA {
+		return g.(*pixels.Organism).DNA
 	}
-	organism.calcFitness(target)
-	return
-}
-
-// calculates the fitness of the Organism to the target string
-func (o *Organism) calcFitness(target *image.RGBA) {
-	difference := diff(o.DNA, target)
-	if difference == 0 {
-		o.Fitness = 1
+	cli.SaveGenome = func(dir string, best ga.Genome) {
+		err := pixels.SaveGenome(filepath.Join(dir, "genome.gob"), best.(*pixels.Organism).Genome())
+		if err != nil {
+			fmt.Println("Cannot save genome:", err)
+		}
 	}
-	o.Fitness = difference
-
-}
-
-// crosses over 2 Organism strings
-func crossover(d1 Organism, d2 Organism) Organism {
-	pix := make([]uint8, len(d1.DNA.Pix))
-	child := Organism{
-		DNA: &image.RGBA{
-			Pix:    pix,
-			Stride: d1.DNA.Stride,
-			Rect:   d1.DNA.Rect,
-		},
-		Fitness: 0,
+	cli.SaveCheckpoint = pixels.SaveCheckpoint
+	cli.SaveHallOfFame = pixels.SaveHallOfFame
+	cfg.Progress = func(stats ga.RunStats, best *pixels.Organism) {
+		cli.Progress(stats, best)
 	}
-	mid := rand.Intn(len(d1.DNA.Pix))
-	for i := 0; i < len(d1.DNA.Pix); i++ {
-		if i > mid {
-			child.DNA.Pix[i] = d1.DNA.Pix[i]
-		} else {
-			child.DNA.Pix[i] = d2.DNA.Pix[i]
-		}
-
+	cfg.Improved = func(generation int, best *pixels.Organism) {
+		cli.Improved(generation, best)
 	}
-	return child
-}
-
-// mutate the Organism string
-func (o *Organism) mutate() {
-	for i := 0; i < len(o.DNA.Pix); i++ {
-		if rand.Float64() < MutationRate {
-			o.DNA.Pix[i] = uint8(rand.Intn(255))
-		}
+	cfg.Checkpoint = cli.Checkpoint
+	_, err = cli.Run(cfg.Resume != nil, func(ctx context.Context) (ga.Genome, ga.Stats, error) {
+		best, stats, err := pixels.Evolve(ctx, target, cfg)
+		return best, stats, err
+	})
+	if err != nil {
+		fmt.Println("Cannot evolve image:", err)
 	}
 }
-
-// this only works for iTerm!
-
-func printImage(img image.Image) {
-	var buf bytes.Buffer
-	png.Encode(&buf, img)
-	imgBase64Str := base64.StdEncoding.EncodeToString(buf.Bytes())
-	fmt.Printf("\x1b]1337;File=inline=1:%s\a\n", imgBase64Str)
-}
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/llgcode/draw2d/draw2dimg"
+	"github.com/sensorphalanx/ga"
 )
 
 const escape = "\x1b"
@@ -35,11 +36,16 @@ var MaxCircleSize = 8
 
 func main() {
 	start := time.Now()
-	rand.Seed(time.Now().UTC().UnixNano())
-	target := load("./ml.png")
+	// every random number comes from rng
+	rng := rand.New(rand.NewSource(time.Now().UTC().UnixNano()))
+	target, err := load("./ml.png")
+	if err != nil {
+		fmt.Println("Cannot load target image:", err)
+		return
+	}
 	printImage(target.SubImage(target.Rect))
 
-	population := createPopulation(target)
+	population := createPopulation(target, rng)
 
 	found := false
 	generation := 0
@@ -49,11 +55,14 @@ func main() {
 		if bestOrganism.Fitness < 5000 {
 			found = true
 		} else {
-			pool := createPool(population, target)
-			population = naturalSelection(pool, population, target)
+			pool := createPool(population, target, rng)
+			population = naturalSelection(pool, population, target, rng)
 			sofar := time.Since(start)
 			if generation%10 == 0 {
-				save("./evolved.png", bestOrganism.DNA)
+				err := save("./evolved.png", bestOrganism.DNA)
+				if err != nil {
+					fmt.Println("Cannot save evolved image:", err)
+				}
 				fmt.Printf("\nTime taken so far: %s | generation: %d | fitness: %d | pool size: %d", sofar, generation, bestOrganism.Fitness, len(pool))
 				fmt.Println()
 				printImage(bestOrganism.DNA.SubImage(bestOrganism.DNA.Rect))
@@ -65,34 +74,40 @@ func main() {
 	fmt.Printf("\nTotal time taken: %s\n", elapsed)
 }
 
-func save(filePath string, rgba *image.RGBA) {
+func save(filePath string, rgba *image.RGBA) error {
 	imgFile, err := os.Create(filePath)
-	defer imgFile.Close()
 	if err != nil {
-		fmt.Println("Cannot create file:", err)
+		return fmt.Errorf("cannot create file: %w", err)
 	}
-
-	png.Encode(imgFile, rgba.SubImage(rgba.Rect))
+	err = png.Encode(imgFile, rgba.SubImage(rgba.Rect))
+	if err != nil {
+		imgFile.Close()
+		return fmt.Errorf("cannot encode image: %w", err)
+	}
+	return imgFile.Close()
 }
 
-func getImage(filePath string) image.Image {
+func getImage(filePath string) (image.Image, error) {
 	imgFile, err := os.Open(filePath)
-	defer imgFile.Close()
 	if err != nil {
-		fmt.Println("Cannot read file:", err)
+		return nil, fmt.Errorf("cannot read file: %w", err)
 	}
+	defer imgFile.Close()
 
 	img, _, err := image.Decode(imgFile)
 	if err != nil {
-		fmt.Println("Cannot decode file:", err)
+		return nil, fmt.Errorf("cannot decode file: %w", err)
 	}
 
-	return img
+	return img, nil
 }
 
-func load(filePath string) *image.RGBA {
-	img := getImage(filePath)
-	return img.(*image.RGBA)
+func load(filePath string) (*image.RGBA, error) {
+	img, err := getImage(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return ga.ToRGBA(img), nil
 }
 
 func diff(a, b *image.RGBA) (d int64) {
@@ -110,9 +125,7 @@ func squareDifference(x, y uint8) uint64 {
 }
 
 // create the reproduction pool that creates the next generation
-func createPool(population []Organism, target *image.RGBA) (pool []Organism) {
-	pool = make([]Organism, 0)
-
+func createPool(population []Organism, target *image.RGBA, rng *rand.Rand) (pool []Organism) {
 	// get top 10 best fitting organisms
 	sort.SliceStable(population, func(i, j int) bool {
 		return population[i].Fitness < population[j].Fitness
@@ -122,28 +135,34 @@ func createPool(population []Organism, target *image.RGBA) (pool []Organism) {
 		pool = population
 		return
 	}
-	// create a pool for next generation
-	for i := 0; i < len(top)-1; i++ {
-		num := (top[PoolSize].Fitness - top[i].Fitness)
-		for n := int64(0); n < num; n++ {
-			pool = append(pool, top[i])
-		}
+	// create a pool for next generation, each of the top organisms is picked
+	// with a chance proportional to how much fitter it is than the last one
+	cumulative := make([]int64, PoolSize)
+	total := int64(0)
+	for i := 0; i < PoolSize; i++ {
+		total += top[PoolSize].Fitness - top[i].Fitness
+		cumulative[i] = total
+	}
+	pool = make([]Organism, len(population))
+	for i := range pool {
+		r := rng.Int63n(total)
+		pool[i] = top[sort.Search(PoolSize, func(j int) bool { return cumulative[j] > r })]
 	}
 	return
 }
 
 // perform natural selection to create the next generation
-func naturalSelection(pool []Organism, population []Organism, target *image.RGBA) []Organism {
+func naturalSelection(pool []Organism, population []Organism, target *image.RGBA, rng *rand.Rand) []Organism {
 	next := make([]Organism, len(population))
 
 	for i := 0; i < len(population); i++ {
 		// fmt.Println("pool:", len(pool))
-		r1, r2 := rand.Intn(len(pool)), rand.Intn(len(pool))
+		r1, r2 := rng.Intn(len(pool)), rng.Intn(len(pool))
 		a := pool[r1]
 		b := pool[r2]
 
-		child := crossover(a, b)
-		child.mutate()
+		child := crossover(a, b, rng)
+		child.mutate(rng)
 		child.calcFitness(target)
 
 		next[i] = child
@@ -152,10 +171,10 @@ func naturalSelection(pool []Organism, population []Organism, target *image.RGBA
 }
 
 // creates the initial population
-func createPopulation(target *image.RGBA) (population []Organism) {
+func createPopulation(target *image.RGBA, rng *rand.Rand) (population []Organism) {
 	population = make([]Organism, PopSize)
 	for i := 0; i < PopSize; i++ {
-		population[i] = createOrganism(target)
+		population[i] = createOrganism(target, rng)
 	}
 	return
 }
@@ -195,11 +214,11 @@ type Organism struct {
 }
 
 // create an organism
-func createOrganism(target *image.RGBA) (organism Organism) {
+func createOrganism(target *image.RGBA, rng *rand.Rand) (organism Organism) {
 	// randomly make triangles
 	circles := make([]Circle, NumCircles)
 	for i := 0; i < NumCircles; i++ {
-		circles[i] = createCircle(target.Rect.Dx(), target.Rect.Dy())
+		circles[i] = createCircle(target.Rect.Dx(), target.Rect.Dy(), rng)
 	}
 
 	organism = Organism{
@@ -211,12 +230,12 @@ func createOrganism(target *image.RGBA) (organism Organism) {
 	return
 }
 
-func createCircle(w int, h int) (c Circle) {
+func createCircle(w int, h int, rng *rand.Rand) (c Circle) {
 	c = Circle{
-		X:     rand.Intn(w),
-		Y:     rand.Intn(h),
-		R:     rand.Intn(MaxCircleSize),
-		Color: color.RGBA{uint8(rand.Intn(255)), uint8(rand.Intn(255)), uint8(rand.Intn(255)), uint8(rand.Intn(255))},
+		X:     rng.Intn(w),
+		Y:     rng.Intn(h),
+		R:     rng.Intn(MaxCircleSize),
+		Color: color.RGBA{uint8(rng.Intn(255)), uint8(rng.Intn(255)), uint8(rng.Intn(255)), uint8(rng.Intn(255))},
 	}
 	return
 }
@@ -232,14 +251,14 @@ func (d *Organism) calcFitness(target *image.RGBA) {
 }
 
 // crosses over 2 srganisms
-func crossover(d1 Organism, d2 Organism) Organism {
+func crossover(d1 Organism, d2 Organism, rng *rand.Rand) Organism {
 
 	child := Organism{
 		Circles: make([]Circle, len(d1.Circles)),
 		Fitness: 0,
 	}
 
-	mid := rand.Intn(len(d1.Circles))
+	mid := rng.Intn(len(d1.Circles))
 	for i := 0; i < len(d1.Circles); i++ {
 		if i > mid {
 			child.Circles[i] = d1.Circles[i]
@@ -253,10 +272,10 @@ func crossover(d1 Organism, d2 Organism) Organism {
 }
 
 // mutate the organism
-func (d *Organism) mutate() {
+func (d *Organism) mutate(rng *rand.Rand) {
 	for i := 0; i < len(d.Circles); i++ {
-		if rand.Float64() < MutationRate {
-			d.Circles[i] = createCircle(d.DNA.Rect.Dx(), d.DNA.Rect.Dy())
+		if rng.Float64() < MutationRate {
+			d.Circles[i] = createCircle(d.DNA.Rect.Dx(), d.DNA.Rect.Dy(), rng)
 		}
 	}
 	d.DNA = draw(d.DNA.Rect.Dx(), d.DNA.Rect.Dy(), d.Circles)
@@ -1,292 +1,173 @@
 package main
 
 import (
-	"bytes"
-	"encoding/base64"
+	"context"
+	"flag"
 	"fmt"
 	"image"
-	"image/color"
-	"image/png"
-	"math"
-	"math/rand"
 	"os"
-	"sort"
-	"time"
+	"path/filepath"
+	"strings"
 
-	"github.com/llgcode/draw2d/draw2dimg"
+	"github.com/sensorphalanx/ga"
+	"github.com/sensorphalanx/ga/triangles"
 )
 
-const escape = "\x1b"
-
-// MutationRate is the rate of mutation
-var MutationRate = 0.021
-
-// PopSize is the size of the population
-var PopSize = 100
-
-// PoolSize is the max size of the pool
-var PoolSize = 20
-
-// NumTriangles is the number of triangles to draw in each picture
-var NumTriangles = 150
-
-// FitnessLimit is the fitness of the evolved image we are satisfied with
-var FitnessLimit int64 = 7500
-
 func main() {
-	start := time.Now()
-	rand.Seed(time.Now().UTC().UnixNano())
-	target := load("./ml.png")
-	printImage(target.SubImage(target.Rect))
-
-	population := createPopulation(target)
-
-	found := false
-	generation := 0
-	for !found {
-		generation++
-		bestOrganism := getBest(population)
-		if bestOrganism.Fitness < FitnessLimit {
-			found = true
-		} else {
-			pool := createPool(population, target)
-			population = naturalSelection(pool, population, target)
-			sofar := time.Since(start)
-			if generation%10 == 0 {
-				save("./evolved.png", bestOrganism.DNA)
-				fmt.Printf("\nTime taken so far: %s | generation: %d | fitness: %d | pool size: %d", sofar, generation, bestOrganism.Fitness, len(pool))
-				fmt.Println()
-				printImage(bestOrganism.DNA.SubImage(bestOrganism.DNA.Rect))
-			}
+	if len(os.Args) > 1 && os.Args[1] == "render" {
+		err := render(os.Args[2:])
+		if err != nil {
+			fmt.Println("Cannot render genome:", err)
+			os.Exit(1)
 		}
-
+		return
 	}
-	elapsed := time.Since(start)
-	fmt.Printf("\nTotal time taken: %s\n", elapsed)
-}
-
-func save(filePath string, rgba *image.RGBA) {
-	imgFile, err := os.Create(filePath)
-	defer imgFile.Close()
-	if err != nil {
-		fmt.Println("Cannot create file:", err)
+	if len(os.Args) > 1 && os.Args[1] == "worker" {
+		err := worker(os.Args[2:])
+		if err != nil {
+			fmt.Println("Cannot serve as a worker:", err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	png.Encode(imgFile, rgba.SubImage(rgba.Rect))
-}
-
-func getImage(filePath string) image.Image {
-	imgFile, err := os.Open(filePath)
-	defer imgFile.Close()
+	cfg := triangles.DefaultConfig()
+	cli := ga.NewCLI[triangles.Genome](flag.CommandLine, &cfg.RunConfig, 10)
+	flag.Lookup("max-dimension").Usage += ". The final picture is also drawn at the original size to evolved_full.png"
+	flag.Lookup("out").Usage = "directory to save evolved.png, genome.gob, genome.json, evolved.svg and heatmap.png to"
+	flag.Float64Var(&cfg.Replace, "replace", cfg.Replace, "chance of a mutated shape being replaced with a new random one instead of nudged by -move and -shade")
+	flag.Float64Var(&cfg.GeometryRate, "geometry-rate", cfg.GeometryRate, "chance of the points of a nudged shape moving")
+	flag.Float64Var(&cfg.ColorRate, "color-rate", cfg.ColorRate, "chance of the color of a nudged shape changing")
+	flag.Float64Var(&cfg.BackgroundRate, "background-rate", cfg.BackgroundRate, "chance of the background color of a mutated picture changing by up to -shade")
+	flag.Float64Var(&cfg.AlphaRate, "alpha-rate", cfg.AlphaRate, "chance of the alpha of a nudged shape changing")
+	flag.IntVar(&cfg.Fade, "fade", cfg.Fade, "max amount the alpha of a nudged shape changes by")
+	flag.IntVar(&cfg.MinAlpha, "min-alpha", cfg.MinAlpha, "least alpha a shape can have, from 0 to 255")
+	flag.IntVar(&cfg.MaxAlpha, "max-alpha", cfg.MaxAlpha, "most alpha a shape can have, from 0 to 255")
+	flag.Float64Var(&cfg.TransformRate, "transform-rate", cfg.TransformRate, "chance of a nudged shape also being moved, turned or resized as a whole")
+	flag.IntVar(&cfg.Move, "move", cfg.Move, "max number of pixels each point of a nudged shape moves by")
+	flag.IntVar(&cfg.Shade, "shade", cfg.Shade, "max amount each color channel of a nudged shape changes by")
+	flag.IntVar(&cfg.NumShapes, "triangles", cfg.NumShapes, "number of shapes in each picture")
+	flag.Float64Var(&cfg.SwapRate, "swap-rate", cfg.SwapRate, "chance of two shapes of a mutated picture swapping places in the order they're drawn in")
+	flag.Float64Var(&cfg.ShiftRate, "shift-rate", cfg.ShiftRate, "chance of a shape of a mutated picture moving to another place in the order they're drawn in")
+	flag.Float64Var(&cfg.AddRate, "add-rate", cfg.AddRate, "chance of a new random shape being added to a mutated picture")
+	flag.Float64Var(&cfg.RemoveRate, "remove-rate", cfg.RemoveRate, "chance of a shape being removed from a mutated picture")
+	flag.IntVar(&cfg.MaxShapes, "max-triangles", cfg.MaxShapes, "max number of shapes in a picture with -add-rate or -grow, 0 means no limit")
+	flag.IntVar(&cfg.Grow, "grow", cfg.Grow, "add -grow-by smaller shapes to every picture after this many generations without the fitness improving, 0 never does")
+	flag.IntVar(&cfg.GrowBy, "grow-by", cfg.GrowBy, "number of shapes -grow adds")
+	renderScale := flag.Int("render-scale", 1, "also save the final picture redrawn at this multiple of the target size, e.g. evolved_4x.png")
+	saveJSON := flag.Bool("json", false, "also save the genome as JSON to genome.json")
+	saveVector := flag.Bool("svg", false, "also save the shapes of the evolved picture to evolved.svg")
+	workers := flag.String("workers", "", "comma separated addresses of workers started with the worker command, e.g. host1:7070,host2:7070, to draw and score each generation's children on")
+	flag.StringVar(&cfg.Queue, "queue", cfg.Queue, "URL of a NATS server, e.g. nats://host:4222, to push each generation's children onto for workers started with worker -queue to draw and score")
+	resume := flag.String("resume", "", "genome (.gob or .json) or checkpoint file saved by an earlier run to continue evolving from")
+	seedGenome := flag.String("seed-genome", "", "genome (.gob or .json) saved by an earlier run to start a new run from, scaled to fit the target")
+	flag.StringVar(&cfg.Shape, "shape", cfg.Shape, "kind of shape to draw with: "+strings.Join(triangles.ShapeKinds(), ", ")+" or "+triangles.MixedShapes)
+	flag.IntVar(&cfg.ShapeSize, "tri-size", cfg.ShapeSize, "max span of a shape in pixels")
+	flag.IntVar(&cfg.Vertices, "vertices", cfg.Vertices, "number of vertices polygons start with, from 3 to 8")
+	flag.IntVar(&cfg.MaxVertices, "max-vertices", cfg.MaxVertices, "most vertices a polygon can grow to, up to 8")
+	flag.Float64Var(&cfg.VertexRate, "vertex-rate", cfg.VertexRate, "chance of a nudged polygon gaining or losing a vertex")
+	flag.StringVar(&cfg.Font, "font", cfg.Font, "TrueType or OpenType font file to draw glyphs with, needed by -shape glyph")
+	flag.StringVar(&cfg.Glyphs, "glyphs", cfg.Glyphs, "characters to draw glyphs of, e.g. \".:-=+*#%@\" for ASCII art, empty draws any printable ASCII character the font has")
+	flag.BoolVar(&cfg.SeedFromTarget, "seed-from-target", cfg.SeedFromTarget, "color initial shapes from the target instead of randomly")
+	flag.IntVar(&cfg.Jitter, "jitter", cfg.Jitter, "max per-channel color jitter when seeding from the target")
+	flag.BoolVar(&cfg.SeedEdges, "seed-edges", cfg.SeedEdges, "place initial shapes near the edges of the target more often than in its flat regions")
+	flag.IntVar(&cfg.Palette, "palette", cfg.Palette, "color the shapes with only this many colors picked from the target, 0 lets them have any color")
+	flag.Parse()
+	target, err := cli.Start()
 	if err != nil {
-		fmt.Println("Cannot read file:", err)
+		fmt.Println("Cannot evolve image:", err)
+		return
 	}
-
-	img, _, err := image.Decode(imgFile)
-	if err != nil {
-		fmt.Println("Cannot decode file:", err)
+	if *workers != "" {
+		cfg.Workers = strings.Split(*workers, ",")
 	}
-
-	return img
-}
-
-func load(filePath string) *image.RGBA {
-	img := getImage(filePath)
-	return img.(*image.RGBA)
-}
-
-func diff(a, b *image.RGBA) (d int64) {
-	d = 0
-	for i := 0; i < len(a.Pix); i++ {
-		d += int64(squareDifference(a.Pix[i], b.Pix[i]))
+	cfg.RemoteError = func(err error) {
+		fmt.Println("Cannot score on the workers, scoring here instead:", err)
 	}
 
-	return int64(math.Sqrt(float64(d)))
-}
-
-func squareDifference(x, y uint8) uint64 {
-	d := uint64(x) - uint64(y)
-	return d * d
-}
-
-// create the reproduction pool that creates the next generation
-func createPool(population []Organism, target *image.RGBA) (pool []Organism) {
-	pool = make([]Organism, 0)
-
-	// get top 10 best fitting DNAs
-	sort.SliceStable(population, func(i, j int) bool {
-		return population[i].Fitness < population[j].Fitness
-	})
-	top := population[0 : PoolSize+1]
-	if top[len(top)-1].Fitness-top[0].Fitness == 0 {
-		pool = population
+	w, h := target.Rect.Dx(), target.Rect.Dy()
+	if *resume != "" && *seedGenome != "" {
+		fmt.Println("Cannot both resume a run and seed a new one, use -resume or -seed-genome")
 		return
 	}
-	// create a pool for next generation
-	for i := 0; i < len(top)-1; i++ {
-		num := (top[PoolSize].Fitness - top[i].Fitness)
-		for n := int64(0); n < num; n++ {
-			pool = append(pool, top[i])
+	genomePath := *seedGenome
+	if *resume != "" {
+		// a checkpoint continues the run exactly, a genome starts a new run
+		// from the organism
+		checkpoint, err := triangles.LoadCheckpoint(*resume)
+		if err == nil {
+			cfg.Resume = &checkpoint
+			cfg.PopSize = len(checkpoint.Population)
+		} else {
+			genomePath = *resume
 		}
 	}
-	return
-}
-
-// perform natural selection to create the next generation
-func naturalSelection(pool []Organism, population []Organism, target *image.RGBA) []Organism {
-	next := make([]Organism, len(population))
-
-	for i := 0; i < len(population); i++ {
-		// fmt.Println("pool:", len(pool))
-		r1, r2 := rand.Intn(len(pool)), rand.Intn(len(pool))
-		a := pool[r1]
-		b := pool[r2]
-
-		child := crossover(a, b)
-		child.mutate()
-		child.calcFitness(target)
-
-		next[i] = child
-	}
-	return next
-}
-
-// creates the initial population
-func createPopulation(target *image.RGBA) (population []Organism) {
-	population = make([]Organism, PopSize)
-	for i := 0; i < PopSize; i++ {
-		population[i] = createOrganism(target)
-	}
-	return
-}
-
-// Get the best organism
-func getBest(population []Organism) Organism {
-	best := int64(0)
-	index := 0
-	for i := 0; i < len(population); i++ {
-		if population[i].Fitness > best {
-			index = i
-			best = population[i].Fitness
+	if genomePath != "" {
+		genome, err := triangles.LoadGenome(genomePath)
+		if err != nil {
+			fmt.Println("Cannot load genome:", err)
+			return
+		}
+		if genome.Width != w || genome.Height != h {
+			fmt.Printf("Scaling genome from %dx%d to fit the %dx%d target\n", genome.Width, genome.Height, w, h)
 		}
+		genome = genome.Fit(w, h)
+		cfg.Start, cfg.StartBackground = genome.Shapes, genome.Background
 	}
-	return population[index]
-}
 
-// Point represents a position in the image
-type Point struct {
-	X int
-	Y int
-}
-
-// Triangle represents a drawn triangle
-type Triangle struct {
-	P1    Point
-	P2    Point
-	P3    Point
-	Color color.Color
-}
-
-// Organism represents an individual in the population
-type Organism struct {
-	DNA       *image.RGBA
-	Triangles []Triangle
-	Fitness   int64
-}
-
-// create an organism
-func createOrganism(target *image.RGBA) (organism Organism) {
-	// randomly make triangles
-	triangles := make([]Triangle, NumTriangles)
-	for i := 0; i < NumTriangles; i++ {
-		triangles[i] = createTriangle(target.Rect.Dx(), target.Rect.Dy())
+	cli.Image = func(g ga.Genome) *image.RGBA {
+		return g.(*triangles.Organism).DNA
 	}
-
-	organism = Organism{
-		DNA:       draw(target.Rect.Dx(), target.Rect.Dy(), triangles),
-		Triangles: triangles,
-		Fitness:   0,
+	cli.SaveGenome = func(dir string, best ga.Genome) {
+		genome := best.(*triangles.Organism).Genome()
+		err := triangles.SaveGenome(filepath.Join(dir, "genome.gob"), genome)
+		if err != nil {
+			fmt.Println("Cannot save genome:", err)
+		}
+		if *saveJSON {
+			err = triangles.SaveGenome(filepath.Join(dir, "genome.json"), genome)
+			if err != nil {
+				fmt.Println("Cannot save JSON genome:", err)
+			}
+		}
+		if *saveVector {
+			err = triangles.SaveSVG(filepath.Join(dir, "evolved.svg"), genome)
+			if err != nil {
+				fmt.Println("Cannot save SVG:", err)
+			}
+		}
 	}
-	organism.calcFitness(target)
-	return
-}
-
-func createTriangle(w int, h int) (t Triangle) {
-	p1 := Point{X: rand.Intn(w), Y: rand.Intn(h)}
-	p2 := Point{X: p1.X + (rand.Intn(30) - 15), Y: p1.Y + (rand.Intn(30) - 15)}
-	p3 := Point{X: p1.X + (rand.Intn(30) - 15), Y: p1.Y + (rand.Intn(30) - 15)}
-	t = Triangle{
-		P1:    p1,
-		P2:    p2,
-		P3:    p3,
-		Color: color.RGBA{uint8(rand.Intn(255)), uint8(rand.Intn(255)), uint8(rand.Intn(255)), uint8(rand.Intn(255))},
+	cli.SaveCheckpoint = triangles.SaveCheckpoint
+	cli.SaveHallOfFame = triangles.SaveHallOfFame
+	cfg.Progress = func(stats ga.RunStats, best *triangles.Organism) {
+		cli.Progress(stats, best)
 	}
-	return
-}
-
-// calculates the fitness of the Organism to the target string
-func (d *Organism) calcFitness(target *image.RGBA) {
-	difference := diff(d.DNA, target)
-	if difference == 0 {
-		d.Fitness = 1
+	cfg.Improved = func(generation int, best *triangles.Organism) {
+		cli.Improved(generation, best)
 	}
-	d.Fitness = difference
-
-}
-
-// crosses over 2 organisms
-func crossover(d1 Organism, d2 Organism) Organism {
-
-	child := Organism{
-		Triangles: make([]Triangle, len(d1.Triangles)),
-		Fitness:   0,
+	cfg.Checkpoint = cli.Checkpoint
+	best, err := cli.Run(cfg.Resume != nil, func(ctx context.Context) (ga.Genome, ga.Stats, error) {
+		best, stats, err := triangles.Evolve(ctx, target, cfg)
+		return best, stats, err
+	})
+	if err != nil {
+		fmt.Println("Cannot evolve image:", err)
+		return
 	}
 
-	mid := rand.Intn(len(d1.Triangles))
-	for i := 0; i < len(d1.Triangles); i++ {
-		if i > mid {
-			child.Triangles[i] = d1.Triangles[i]
-		} else {
-			child.Triangles[i] = d2.Triangles[i]
+	// the genome is drawn at the original size too
+	genome := best.(*triangles.Organism).Genome()
+	if target.Rect.Size() != cli.Original {
+		g := genome.Fit(cli.Original.X, cli.Original.Y)
+		err := ga.Save(filepath.Join(cli.OutDir, "evolved_full.png"), g.Draw())
+		if err != nil {
+			fmt.Println("Cannot save full size picture:", err)
 		}
-
 	}
-	child.DNA = draw(d1.DNA.Rect.Dx(), d1.DNA.Rect.Dy(), child.Triangles)
-	return child
-}
-
-// mutate the organism
-func (d *Organism) mutate() {
-	for i := 0; i < len(d.Triangles); i++ {
-		if rand.Float64() < MutationRate {
-			d.Triangles[i] = createTriangle(d.DNA.Rect.Dx(), d.DNA.Rect.Dy())
+	if *renderScale > 1 {
+		err := renderGenome(scaledPath(filepath.Join(cli.OutDir, "evolved.png"), *renderScale), genome, *renderScale)
+		if err != nil {
+			fmt.Println("Cannot render scaled picture:", err)
 		}
 	}
-	d.DNA = draw(d.DNA.Rect.Dx(), d.DNA.Rect.Dy(), d.Triangles)
-}
-
-func draw(w int, h int, triangles []Triangle) *image.RGBA {
-	dest := image.NewRGBA(image.Rect(0, 0, w, h))
-	gc := draw2dimg.NewGraphicContext(dest)
-
-	for _, triangle := range triangles {
-		gc.SetFillColor(triangle.Color)
-		gc.SetStrokeColor(triangle.Color)
-		gc.MoveTo(float64(triangle.P1.X), float64(triangle.P1.Y))
-		gc.LineTo(float64(triangle.P2.X), float64(triangle.P2.Y))
-		gc.LineTo(float64(triangle.P3.X), float64(triangle.P3.Y))
-		gc.Close()
-		gc.Fill()
-	}
-
-	return dest
-}
-
-// this only works for iTerm!
-
-func printImage(img image.Image) {
-	var buf bytes.Buffer
-	png.Encode(&buf, img)
-	imgBase64Str := base64.StdEncoding.EncodeToString(buf.Bytes())
-	fmt.Printf("%s]1337;File=inline=1:%s\a\n", escape, imgBase64Str)
 }
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/sensorphalanx/ga"
+	"github.com/sensorphalanx/ga/triangles"
+)
+
+// render the genome scale times the size it was evolved at. Shapes don't
+// depend on the resolution of the picture, so it stays sharp.
+func renderGenome(filePath string, g triangles.Genome, scale int) error {
+	g = g.Fit(g.Width*scale, g.Height*scale)
+	return ga.Save(filePath, g.Draw())
+}
+
+// the file a picture rendered at a scale is saved to, e.g. evolved_4x.png
+func scaledPath(filePath string, scale int) string {
+	ext := filepath.Ext(filePath)
+	return fmt.Sprintf("%s_%dx%s", filePath[:len(filePath)-len(ext)], scale, ext)
+}
+
+// the render command draws a saved genome at a bigger scale without evolving
+// anything:
+//
+//	monalisa_triangles render -scale 4 -o big.png genome.gob
+func render(args []string) error {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	scale := fs.Int("scale", 4, "multiple of the size the genome was evolved at to render it at")
+	outPath := fs.String("o", "", "file to save the picture to, defaults to the genome file with _<scale>x.png")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: render [-scale n] [-o file] genome.gob")
+	}
+	if *scale < 1 {
+		return errors.New("scale must be at least 1")
+	}
+	genomePath := fs.Arg(0)
+	if *outPath == "" {
+		ext := filepath.Ext(genomePath)
+		*outPath = scaledPath(genomePath[:len(genomePath)-len(ext)]+".png", *scale)
+	}
+
+	g, err := triangles.LoadGenome(genomePath)
+	if err != nil {
+		return err
+	}
+	err = renderGenome(*outPath, g, *scale)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Rendered %dx%d picture to %s\n", g.Width*(*scale), g.Height*(*scale), *outPath)
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sensorphalanx/ga"
+	"github.com/sensorphalanx/ga/triangles"
+)
+
+// the worker command draws and scores the organisms of masters run with
+// -workers, or pulls them from the queue of masters run with -queue, so a
+// run can use the CPUs of other machines:
+//
+//	monalisa_triangles worker -listen :7070
+//	monalisa_triangles worker -queue nats://host:4222
+func worker(args []string) error {
+	fs := flag.NewFlagSet("worker", flag.ExitOnError)
+	listen := fs.String("listen", ":7070", "address to listen for masters on")
+	queue := fs.String("queue", "", "URL of a NATS server to pull organisms from instead of listening, e.g. nats://host:4222")
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		return errors.New("usage: worker [-listen address | -queue url]")
+	}
+	if *queue != "" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		fmt.Println("Scoring organisms from the queue at", *queue)
+		return ga.ServeQueue(ctx, *queue, triangles.NewEvaluator)
+	}
+	lis, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return fmt.Errorf("cannot listen: %w", err)
+	}
+	fmt.Println("Scoring organisms for masters on", lis.Addr())
+	return ga.ServeWorker(lis, triangles.NewEvaluator)
+}
@@ -0,0 +1,155 @@
+package ga
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// MutationControl changes the mutation rate of a run as it goes. Controls
+// can be chained, each one adapting the rate the one before it returned.
+type MutationControl interface {
+	// Adapt returns the mutation rate to breed the next generation with,
+	// given the stats of the generation just bred and the rate it would be
+	// bred with otherwise
+	Adapt(rate float64, stats Stats) float64
+}
+
+// schedules give what the mutation rate is multiplied by when a schedule
+// that starts at start is t of the way through, t goes from 0 to 1
+var schedules = map[string]func(start float64, t float64) float64{
+	"linear": func(start float64, t float64) float64 {
+		return start + (1-start)*t
+	},
+	"exponential": func(start float64, t float64) float64 {
+		return math.Pow(start, 1-t)
+	},
+	"step": func(start float64, t float64) float64 {
+		return math.Pow(start, 1-math.Floor(t*scheduleSteps)/scheduleSteps)
+	},
+}
+
+// the number of steps the step schedule takes to get down to the rate
+const scheduleSteps = 5
+
+// ScheduleNames returns the names of the mutation rate schedules
+func ScheduleNames() []string {
+	names := make([]string, 0, len(schedules))
+	for name := range schedules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Schedule anneals the mutation rate over a run, starting at Start times the
+// rate and coming down to the rate itself once Generations generations have
+// been bred. Kind is how it comes down, see ScheduleNames: linearly,
+// exponentially, or exponentially in a few steps.
+type Schedule struct {
+	Kind        string
+	Start       float64
+	Generations int
+}
+
+// NewSchedule returns the schedule of the given kind
+func NewSchedule(kind string, start float64, generations int) (Schedule, error) {
+	if _, ok := schedules[kind]; !ok {
+		return Schedule{}, fmt.Errorf("unknown schedule %q, use one of %s", kind, strings.Join(ScheduleNames(), ", "))
+	}
+	if start <= 0 {
+		return Schedule{}, errors.New("schedule start must be above 0")
+	}
+	if generations < 1 {
+		return Schedule{}, errors.New("schedule must last at least 1 generation")
+	}
+	return Schedule{Kind: kind, Start: start, Generations: generations}, nil
+}
+
+// Adapt scales the rate by how far through the schedule the run is
+func (s Schedule) Adapt(rate float64, stats Stats) float64 {
+	t := min(1, float64(stats.Generations)/float64(s.Generations))
+	return min(1, rate*schedules[s.Kind](s.Start, t))
+}
+
+// Hypermutation multiplies the mutation rate by Factor once the best fitness
+// hasn't improved for Trigger generations, to shake the population out of a
+// local optimum. The rate decays back to normal over the Burst generations
+// after that. The rest is the state it's got to, which is exported so that
+// it can be saved with a run.
+type Hypermutation struct {
+	Trigger int
+	Factor  float64
+	Burst   int
+	// Best is the best fitness so far, -1 before the first generation, and
+	// Improved the generation it was reached in
+	Best     int64
+	Improved int
+	// Started is the generation the burst started in, 0 when there's no
+	// burst
+	Started int
+}
+
+// NewHypermutation returns a hypermutation control that hasn't seen any
+// generation yet
+func NewHypermutation(trigger int, factor float64, burst int) *Hypermutation {
+	return &Hypermutation{Trigger: trigger, Factor: factor, Burst: burst, Best: -1}
+}
+
+// Adapt multiplies the rate during a burst
+func (h *Hypermutation) Adapt(rate float64, stats Stats) float64 {
+	if h.Best < 0 || stats.Fitness < h.Best {
+		h.Best, h.Improved = stats.Fitness, stats.Generations
+	}
+	if h.Started == 0 && stats.Generations-h.Improved >= h.Trigger {
+		h.Started = stats.Generations
+	}
+	if h.Started == 0 {
+		return rate
+	}
+	t := stats.Generations - h.Started
+	if t >= h.Burst {
+		// give the population the full trigger to improve again before the
+		// next burst
+		h.Started, h.Improved = 0, stats.Generations
+		return rate
+	}
+	return min(1, rate*math.Pow(h.Factor, 1-float64(t)/float64(h.Burst)))
+}
+
+// OneFifthRule adapts the mutation rate to how many children improve on
+// their parents, aiming for a fifth of them. When fewer than a fifth of the
+// children of a generation are fitter than both of their parents the rate is
+// multiplied by Factor, and when more are it's divided by it.
+type OneFifthRule struct {
+	Factor float64
+	// Scale is what the rate is multiplied by so far, it's exported so that
+	// it can be saved with a run
+	Scale float64
+}
+
+// NewOneFifthRule returns a 1/5 success rule control that starts from the
+// rate it's given
+func NewOneFifthRule(factor float64) *OneFifthRule {
+	return &OneFifthRule{Factor: factor, Scale: 1}
+}
+
+// Adapt scales the rate by how successful the last generation was
+func (r *OneFifthRule) Adapt(rate float64, stats Stats) float64 {
+	if stats.Children > 0 {
+		success := float64(stats.Improved) / float64(stats.Children)
+		switch {
+		case success < 0.2:
+			r.Scale *= r.Factor
+		case success > 0.2:
+			r.Scale /= r.Factor
+		}
+	}
+	// don't keep raising the scale once the rate can't go any higher
+	if rate > 0 {
+		r.Scale = min(r.Scale, 1/rate)
+	}
+	return rate * r.Scale
+}
@@ -0,0 +1,267 @@
+//go:build gpu
+
+package opencl
+
+/*
+#cgo CFLAGS: -DCL_TARGET_OPENCL_VERSION=120
+#cgo linux LDFLAGS: -lOpenCL
+#cgo darwin LDFLAGS: -framework OpenCL
+
+#include <stdlib.h>
+#ifdef __APPLE__
+#include <OpenCL/opencl.h>
+#else
+#include <CL/cl.h>
+#endif
+*/
+import "C"
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"sync"
+	"unsafe"
+
+	"github.com/sensorphalanx/ga"
+)
+
+// squaredDiffKernel sums the squared differences of the bytes of every
+// candidate and the target. Each work group sums a strided share of one
+// candidate's bytes into partial, which the host adds up, so the result is
+// the same as ga.SquaredDiff's.
+const squaredDiffKernel = `
+__kernel void squared_diff(__global const uchar *candidates, __global const uchar *target,
+	const uint n, __global ulong *partial, __local ulong *scratch) {
+	size_t c = get_global_id(1);
+	size_t l = get_local_id(0);
+	__global const uchar *candidate = candidates + c * n;
+	ulong sum = 0;
+	for (size_t i = get_global_id(0); i < n; i += get_global_size(0)) {
+		int d = (int)candidate[i] - (int)target[i];
+		sum += (ulong)(d * d);
+	}
+	scratch[l] = sum;
+	barrier(CLK_LOCAL_MEM_FENCE);
+	for (size_t s = get_local_size(0) / 2; s > 0; s >>= 1) {
+		if (l < s) {
+			scratch[l] += scratch[l + s];
+		}
+		barrier(CLK_LOCAL_MEM_FENCE);
+	}
+	if (l == 0) {
+		partial[c * get_num_groups(0) + get_group_id(0)] = scratch[0];
+	}
+}
+`
+
+// the number of work groups every candidate is split between
+const groupsPerCandidate = 64
+
+func init() {
+	ga.RegisterBackend("opencl", newBackend)
+}
+
+// backend scores the candidates of a batch with the diff fitness on
+// the first GPU OpenCL finds. The target is uploaded once, the candidates
+// of every batch together.
+type backend struct {
+	// mu keeps batches from sharing the buffers
+	mu      sync.Mutex
+	context C.cl_context
+	queue   C.cl_command_queue
+	program C.cl_program
+	kernel  C.cl_kernel
+	target  C.cl_mem
+	// size is the number of bytes of the target and every candidate, local
+	// the size of a work group
+	size  int
+	local int
+	// the buffers of the candidates and the partial sums, made again when a
+	// batch has more candidates than they hold
+	candidates C.cl_mem
+	partial    C.cl_mem
+	capacity   int
+	pix        []byte
+	sums       []uint64
+}
+
+// clError is the error of an OpenCL call that failed
+func clError(what string, status C.cl_int) error {
+	return fmt.Errorf("cannot %s: OpenCL error %d", what, int(status))
+}
+
+// newBackend makes the backend for the unweighted diff fitness of every
+// pixel, the only one it can score. It's unavailable for the other fitnesses
+// and when there's no GPU.
+func newBackend(target *image.RGBA, fitness ga.Fitness) (ga.Backend, error) {
+	if f, ok := fitness.(ga.DiffFitness); !ok || f.Weights != nil || f.SampleRate > 1 {
+		return nil, fmt.Errorf("%w: the opencl backend only scores the unweighted diff fitness", ga.ErrBackendUnavailable)
+	}
+	var platform C.cl_platform_id
+	var platforms C.cl_uint
+	if status := C.clGetPlatformIDs(1, &platform, &platforms); status != C.CL_SUCCESS || platforms == 0 {
+		return nil, fmt.Errorf("%w: no OpenCL platform", ga.ErrBackendUnavailable)
+	}
+	var device C.cl_device_id
+	if status := C.clGetDeviceIDs(platform, C.CL_DEVICE_TYPE_GPU, 1, &device, nil); status != C.CL_SUCCESS {
+		return nil, fmt.Errorf("%w: no GPU", ga.ErrBackendUnavailable)
+	}
+
+	b := &backend{size: len(target.Pix)}
+	var status C.cl_int
+	b.context = C.clCreateContext(nil, 1, &device, nil, nil, &status)
+	if status != C.CL_SUCCESS {
+		return nil, clError("create context", status)
+	}
+	b.queue = C.clCreateCommandQueue(b.context, device, 0, &status)
+	if status != C.CL_SUCCESS {
+		b.Close()
+		return nil, clError("create command queue", status)
+	}
+	source := C.CString(squaredDiffKernel)
+	defer C.free(unsafe.Pointer(source))
+	b.program = C.clCreateProgramWithSource(b.context, 1, &source, nil, &status)
+	if status != C.CL_SUCCESS {
+		b.Close()
+		return nil, clError("create program", status)
+	}
+	if status := C.clBuildProgram(b.program, 1, &device, nil, nil, nil); status != C.CL_SUCCESS {
+		b.Close()
+		return nil, clError("build program", status)
+	}
+	name := C.CString("squared_diff")
+	defer C.free(unsafe.Pointer(name))
+	b.kernel = C.clCreateKernel(b.program, name, &status)
+	if status != C.CL_SUCCESS {
+		b.Close()
+		return nil, clError("create kernel", status)
+	}
+	// the reduction halves the work group, so its size is a power of 2
+	var local C.size_t
+	if status := C.clGetKernelWorkGroupInfo(b.kernel, device, C.CL_KERNEL_WORK_GROUP_SIZE, C.size_t(unsafe.Sizeof(local)), unsafe.Pointer(&local), nil); status != C.CL_SUCCESS {
+		b.Close()
+		return nil, clError("get work group size", status)
+	}
+	b.local = 1 << (bits.Len(uint(min(local, 256))) - 1)
+	b.target = C.clCreateBuffer(b.context, C.CL_MEM_READ_ONLY|C.CL_MEM_COPY_HOST_PTR, C.size_t(b.size), unsafe.Pointer(&target.Pix[0]), &status)
+	if status != C.CL_SUCCESS {
+		b.Close()
+		return nil, clError("upload target", status)
+	}
+	return b, nil
+}
+
+// grow the buffers so they hold n candidates
+func (b *backend) grow(n int) error {
+	if n <= b.capacity {
+		return nil
+	}
+	b.release()
+	var status C.cl_int
+	b.candidates = C.clCreateBuffer(b.context, C.CL_MEM_READ_ONLY, C.size_t(n*b.size), nil, &status)
+	if status != C.CL_SUCCESS {
+		return clError("create candidate buffer", status)
+	}
+	b.partial = C.clCreateBuffer(b.context, C.CL_MEM_WRITE_ONLY, C.size_t(n*groupsPerCandidate*8), nil, &status)
+	if status != C.CL_SUCCESS {
+		return clError("create partial sum buffer", status)
+	}
+	b.capacity = n
+	b.pix = make([]byte, n*b.size)
+	b.sums = make([]uint64, n*groupsPerCandidate)
+	return nil
+}
+
+// Score the candidates on the GPU, which have to be the size of the target
+func (b *backend) Score(candidates []*image.RGBA, scores []int64) error {
+	if len(candidates) == 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.grow(len(candidates)); err != nil {
+		return err
+	}
+	for i, c := range candidates {
+		if len(c.Pix) != b.size {
+			return fmt.Errorf("candidate %d is %d bytes, the target %d", i, len(c.Pix), b.size)
+		}
+		copy(b.pix[i*b.size:], c.Pix)
+	}
+	n := len(candidates) * b.size
+	if status := C.clEnqueueWriteBuffer(b.queue, b.candidates, C.CL_TRUE, 0, C.size_t(n), unsafe.Pointer(&b.pix[0]), 0, nil, nil); status != C.CL_SUCCESS {
+		return clError("upload candidates", status)
+	}
+	size := C.cl_uint(b.size)
+	args := []struct {
+		size  uintptr
+		value unsafe.Pointer
+	}{
+		{unsafe.Sizeof(b.candidates), unsafe.Pointer(&b.candidates)},
+		{unsafe.Sizeof(b.target), unsafe.Pointer(&b.target)},
+		{unsafe.Sizeof(size), unsafe.Pointer(&size)},
+		{unsafe.Sizeof(b.partial), unsafe.Pointer(&b.partial)},
+		// the scratch memory of a work group
+		{uintptr(b.local * 8), nil},
+	}
+	for i, arg := range args {
+		if status := C.clSetKernelArg(b.kernel, C.cl_uint(i), C.size_t(arg.size), arg.value); status != C.CL_SUCCESS {
+			return clError("set kernel argument", status)
+		}
+	}
+	global := [2]C.size_t{C.size_t(groupsPerCandidate * b.local), C.size_t(len(candidates))}
+	local := [2]C.size_t{C.size_t(b.local), 1}
+	if status := C.clEnqueueNDRangeKernel(b.queue, b.kernel, 2, nil, &global[0], &local[0], 0, nil, nil); status != C.CL_SUCCESS {
+		return clError("run kernel", status)
+	}
+	sums := b.sums[:len(candidates)*groupsPerCandidate]
+	if status := C.clEnqueueReadBuffer(b.queue, b.partial, C.CL_TRUE, 0, C.size_t(len(sums)*8), unsafe.Pointer(&sums[0]), 0, nil, nil); status != C.CL_SUCCESS {
+		return clError("download scores", status)
+	}
+	for i := range candidates {
+		var sum uint64
+		for _, s := range sums[i*groupsPerCandidate : (i+1)*groupsPerCandidate] {
+			sum += s
+		}
+		scores[i] = int64(math.Sqrt(float64(sum)))
+	}
+	return nil
+}
+
+// release the candidate and partial sum buffers
+func (b *backend) release() {
+	if b.candidates != nil {
+		C.clReleaseMemObject(b.candidates)
+		b.candidates = nil
+	}
+	if b.partial != nil {
+		C.clReleaseMemObject(b.partial)
+		b.partial = nil
+	}
+	b.capacity = 0
+}
+
+// Close releases everything the backend made on the GPU
+func (b *backend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.release()
+	if b.target != nil {
+		C.clReleaseMemObject(b.target)
+	}
+	if b.kernel != nil {
+		C.clReleaseKernel(b.kernel)
+	}
+	if b.program != nil {
+		C.clReleaseProgram(b.program)
+	}
+	if b.queue != nil {
+		C.clReleaseCommandQueue(b.queue)
+	}
+	if b.context != nil {
+		C.clReleaseContext(b.context)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+//go:build gpu
+
+package opencl
+
+import (
+	"errors"
+	"image"
+	"math/rand"
+	"testing"
+
+	"github.com/sensorphalanx/ga"
+)
+
+// an image of random bytes
+func noiseImage(rng *rand.Rand, w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	rng.Read(img.Pix)
+	return img
+}
+
+func TestScoresMatchCPU(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	// an odd size so the candidates don't split evenly between work groups
+	w, h := 123, 77
+	target := noiseImage(rng, w, h)
+	gpu, err := newBackend(target, ga.DiffFitness{})
+	if errors.Is(err, ga.ErrBackendUnavailable) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gpu.Close()
+	cpu, _, err := ga.NewBackend("cpu", target, ga.DiffFitness{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cpu.Close()
+
+	black, white := image.NewRGBA(target.Rect), image.NewRGBA(target.Rect)
+	for i := range white.Pix {
+		white.Pix[i] = 255
+	}
+	// batches that grow the buffers and then use part of them
+	for _, n := range []int{1, 9, 4} {
+		candidates := []*image.RGBA{target, black, white}
+		for len(candidates) < n+3 {
+			candidates = append(candidates, noiseImage(rng, w, h))
+		}
+		want, got := make([]int64, len(candidates)), make([]int64, len(candidates))
+		err := cpu.Score(candidates, want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = gpu.Score(candidates, got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range candidates {
+			if got[i] != want[i] {
+				t.Errorf("candidate %d of a batch of %d scored %d on the GPU, want %d", i, len(candidates), got[i], want[i])
+			}
+		}
+	}
+}
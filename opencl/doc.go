@@ -0,0 +1,8 @@
+// Package opencl is a ga.Backend that scores candidates on a GPU with
+// OpenCL. It needs cgo and the OpenCL headers and library, so it's only
+// built with -tags gpu, and importing it then registers the "opencl" backend.
+//
+// The backend is experimental as its kernel hasn't been run on a GPU yet.
+// go test -tags gpu checks its scores against the cpu backend's on one, and
+// skips when there's no GPU.
+package opencl
@@ -0,0 +1,91 @@
+package ga
+
+import (
+	"image"
+	"image/color"
+	"slices"
+)
+
+// the most rounds of k-means Palette runs before settling for the colors it
+// has, it usually settles long before
+const paletteRounds = 30
+
+// Palette returns the k colors that sum up the target best, found by k-means
+// clustering of its pixels. The colors start from the pixels at evenly spaced
+// steps of brightness, so the same target always gets the same palette. There
+// are fewer than k colors if the target has fewer.
+func Palette(target *image.RGBA, k int) []color.RGBA {
+	w, h := target.Rect.Dx(), target.Rect.Dy()
+	pixels := make([][3]int, 0, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*target.Stride + x*4
+			pixels = append(pixels, [3]int{int(target.Pix[i]), int(target.Pix[i+1]), int(target.Pix[i+2])})
+		}
+	}
+	if len(pixels) == 0 || k < 1 {
+		return nil
+	}
+
+	sorted := slices.Clone(pixels)
+	slices.SortFunc(sorted, func(a, b [3]int) int {
+		return brightness(a) - brightness(b)
+	})
+	var centers [][3]int
+	for i := 0; i < k; i++ {
+		c := sorted[(2*i+1)*len(sorted)/(2*k)]
+		if !slices.Contains(centers, c) {
+			centers = append(centers, c)
+		}
+	}
+
+	nearest := make([]int, len(pixels))
+	for round := 0; round < paletteRounds; round++ {
+		moved := false
+		for i, p := range pixels {
+			best, bestDist := 0, -1
+			for j, c := range centers {
+				d := 0
+				for ch := range p {
+					d += (p[ch] - c[ch]) * (p[ch] - c[ch])
+				}
+				if bestDist < 0 || d < bestDist {
+					best, bestDist = j, d
+				}
+			}
+			if nearest[i] != best {
+				nearest[i], moved = best, true
+			}
+		}
+		if !moved && round > 0 {
+			break
+		}
+		// every center moves to the mean of its pixels, a center without any
+		// stays where it is
+		sums := make([][4]int, len(centers))
+		for i, p := range pixels {
+			s := &sums[nearest[i]]
+			s[0], s[1], s[2], s[3] = s[0]+p[0], s[1]+p[1], s[2]+p[2], s[3]+1
+		}
+		for j, s := range sums {
+			if s[3] > 0 {
+				centers[j] = [3]int{(s[0] + s[3]/2) / s[3], (s[1] + s[3]/2) / s[3], (s[2] + s[3]/2) / s[3]}
+			}
+		}
+	}
+
+	palette := make([]color.RGBA, 0, len(centers))
+	for _, c := range centers {
+		rgba := color.RGBA{uint8(c[0]), uint8(c[1]), uint8(c[2]), 255}
+		if !slices.Contains(palette, rgba) {
+			palette = append(palette, rgba)
+		}
+	}
+	return palette
+}
+
+// brightness of the color, weighted like EdgeWeights weighs it but in whole
+// numbers
+func brightness(c [3]int) int {
+	return 299*c[0] + 587*c[1] + 114*c[2]
+}
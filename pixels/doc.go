@@ -0,0 +1,5 @@
+// Package pixels evolves a picture of a target image pixel by pixel, each
+// organism's DNA is an image the size of the target. Run evolves a picture
+// with the given Config, and Evolve is the same with a context and returns
+// the fittest organism, whose Genome can be saved and resumed from.
+package pixels
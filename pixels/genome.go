@@ -0,0 +1,100 @@
+package pixels
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+
+	"github.com/sensorphalanx/ga"
+)
+
+// Genome is what's saved of an organism so that a run can be resumed from it
+type Genome struct {
+	Width  int
+	Height int
+	Pix    []uint8
+}
+
+// SaveGenome saves the genome
+func SaveGenome(filePath string, g Genome) error {
+	return ga.WriteGob(filePath, g)
+}
+
+// LoadGenome loads the genome
+func LoadGenome(filePath string) (g Genome, err error) {
+	err = ga.ReadGob(filePath, &g)
+	if err != nil {
+		return g, err
+	}
+	if !g.valid() {
+		return g, fmt.Errorf("%s doesn't hold a %dx%d image", filePath, g.Width, g.Height)
+	}
+	return g, nil
+}
+
+// check that the genome holds a whole image
+func (g Genome) valid() bool {
+	return g.Width > 0 && g.Height > 0 && len(g.Pix) == g.Width*g.Height*4
+}
+
+// Fit fits the genome to a w x h target. The genome is the pixels of the image
+// itself, so it can't be used for a differently sized target.
+func (g Genome) Fit(w int, h int) (*image.RGBA, error) {
+	if g.Width != w || g.Height != h {
+		return nil, fmt.Errorf("genome is %dx%d but the target is %dx%d, pixel genomes can't be resized",
+			g.Width, g.Height, w, h)
+	}
+	return &image.RGBA{
+		Pix:    g.Pix,
+		Stride: w * 4,
+		Rect:   image.Rect(0, 0, w, h),
+	}, nil
+}
+
+// Checkpoint is what's saved of a whole run so that it can be continued
+// exactly where it stopped
+type Checkpoint = ga.Checkpoint[Genome]
+
+// SaveCheckpoint saves the checkpoint
+func SaveCheckpoint(filePath string, c Checkpoint) error {
+	return ga.WriteGob(filePath, c)
+}
+
+// SaveHallOfFame saves the images of the organisms of the hall of fame
+// entries to the directory, fittest first, and returns the paths of the images
+func SaveHallOfFame(dir string, entries []ga.Fame) ([]string, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create directory: %w", err)
+	}
+	paths := make([]string, len(entries))
+	for i, e := range entries {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("%02d_gen%d.png", i+1, e.Generation))
+		err := ga.Save(paths[i], e.Genome.(*Organism).DNA)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// LoadCheckpoint loads the checkpoint
+func LoadCheckpoint(filePath string) (c Checkpoint, err error) {
+	err = ga.ReadGob(filePath, &c)
+	if err != nil {
+		return c, err
+	}
+	if len(c.Population) == 0 {
+		return c, fmt.Errorf("%s doesn't hold a population", filePath)
+	}
+	for i, g := range c.Population {
+		if !g.valid() {
+			return c, fmt.Errorf("organism %d in %s doesn't hold a whole image", i, filePath)
+		}
+	}
+	if c.Best != nil && !c.Best.valid() {
+		return c, fmt.Errorf("the best organism in %s doesn't hold a whole image", filePath)
+	}
+	return c, nil
+}
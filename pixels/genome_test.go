@@ -0,0 +1,96 @@
+package pixels
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+// a checkpoint of a short run towards target
+func checkpointOf(t *testing.T, w, h int) Checkpoint {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.PopSize = 20
+	cfg.PoolSize = 10
+	cfg.FitnessLimit = 0
+	cfg.MaxGenerations = 5
+	cfg.Seed = 1
+	var last Checkpoint
+	cfg.Checkpoint = func(c Checkpoint) {
+		last = c
+	}
+	_, _, err := Run(gradient(w, h), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return last
+}
+
+// resume the checkpoint towards target for a few more generations
+func resume(c Checkpoint, w, h int) error {
+	cfg := DefaultConfig()
+	cfg.PopSize = len(c.Population)
+	cfg.PoolSize = 10
+	cfg.FitnessLimit = 0
+	cfg.MaxGenerations = c.Generation + 5
+	cfg.Resume = &c
+	_, _, err := Run(gradient(w, h), cfg)
+	return err
+}
+
+func TestResumeMatchingCheckpoint(t *testing.T) {
+	c := checkpointOf(t, 16, 12)
+	checkpointPath := filepath.Join(t.TempDir(), "checkpoint.gob")
+	err := SaveCheckpoint(checkpointPath, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadCheckpoint(checkpointPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = resume(loaded, 16, 12)
+	if err != nil {
+		t.Errorf("cannot resume a checkpoint on the target it was made for: %v", err)
+	}
+}
+
+func TestResumeMismatchedCheckpoint(t *testing.T) {
+	c := checkpointOf(t, 16, 12)
+	err := resume(c, 12, 16)
+	if err == nil {
+		t.Error("resumed a checkpoint for a 16x12 target on a 12x16 one")
+	}
+	// a checkpoint that's been cut short
+	c.Population[3].Pix = c.Population[3].Pix[:100]
+	err = resume(c, 16, 12)
+	if err == nil {
+		t.Error("resumed a checkpoint with a genome that doesn't hold a whole image")
+	}
+}
+
+func TestFitGenome(t *testing.T) {
+	img := gradient(16, 12)
+	g := Genome{Width: 16, Height: 12, Pix: img.Pix}
+	genomePath := filepath.Join(t.TempDir(), "genome.gob")
+	err := SaveGenome(genomePath, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err = LoadGenome(genomePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fitted, err := g.Fit(16, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(fitted.Pix, img.Pix) {
+		t.Error("the fitted image isn't the one saved")
+	}
+	// pixels can't be resized
+	_, err = g.Fit(32, 24)
+	if err == nil {
+		t.Error("fitted a 16x12 pixel genome to a 32x24 target")
+	}
+}
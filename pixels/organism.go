@@ -0,0 +1,215 @@
+package pixels
+
+import (
+	"hash/maphash"
+	"image"
+	"math"
+	"math/rand"
+
+	"github.com/sensorphalanx/ga"
+)
+
+// create a random image
+func createRandomImageFrom(img *image.RGBA, rng *rand.Rand) (created *image.RGBA) {
+	pix := make([]uint8, len(img.Pix))
+	rng.Read(pix)
+	created = &image.RGBA{
+		Pix:    pix,
+		Stride: img.Stride,
+		Rect:   img.Rect,
+	}
+	return
+}
+
+// create a copy of the image with every byte moved randomly by up to jitter
+func createJitteredImageFrom(img *image.RGBA, jitter int, rng *rand.Rand) (created *image.RGBA) {
+	pix := make([]uint8, len(img.Pix))
+	for i := 0; i < len(pix); i++ {
+		pix[i] = uint8(clamp(int(img.Pix[i])+rng.Intn(2*jitter+1)-jitter, 0, 255))
+	}
+	created = &image.RGBA{
+		Pix:    pix,
+		Stride: img.Stride,
+		Rect:   img.Rect,
+	}
+	return
+}
+
+// clamp v to the range [lo, hi]
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// Organism represents the genotype of the GA
+type Organism struct {
+	DNA *image.RGBA
+	// fitness is -1 until it's calculated
+	fitness int64
+	// scoring is how the fitness was calculated
+	scoring ga.Scoring
+	// sqErr is the sum of the squared differences of the bytes of DNA and
+	// the target, kept up to date through mutations once sqErrKnown
+	sqErr      int64
+	sqErrKnown bool
+	problem    *problem
+}
+
+// Genome returns the genome of the organism
+func (o *Organism) Genome() Genome {
+	return Genome{Width: o.DNA.Rect.Dx(), Height: o.DNA.Rect.Dy(), Pix: o.DNA.Pix}
+}
+
+// generates a Organism string
+func createOrganism(p *problem, rng *rand.Rand) (organism *Organism) {
+	organism = &Organism{
+		DNA:     createRandomImageFrom(p.target, rng),
+		fitness: -1,
+		problem: p,
+	}
+	if p.cfg.SeedFromTarget {
+		organism.DNA = createJitteredImageFrom(p.seed, p.cfg.Jitter, rng)
+	}
+	if p.cfg.Start != nil {
+		// start from the given image, mutated so the population isn't all
+		// the same
+		copy(organism.DNA.Pix, p.cfg.Start.Pix)
+		organism.mutate(rng, p.run.MutationRate())
+	}
+	return
+}
+
+// Fitness of the Organism to the target, the lower the better
+func (o *Organism) Fitness() int64 {
+	fitness, _ := o.FitnessBelow(math.MaxInt64)
+	return fitness
+}
+
+// FitnessBelow is like Fitness but stops working out the fitness once it's
+// known to be above bound, returning false then. A fitness that isn't exact
+// isn't kept, so it's worked out again when it's asked for.
+func (o *Organism) FitnessBelow(bound int64) (int64, bool) {
+	run := o.problem.run
+	if o.fitness >= 0 && o.scoring == run.Scoring() {
+		return o.fitness, true
+	}
+	if !run.Incremental() {
+		fitness, exact := run.Score(o.DNA, bound, o.hash)
+		if !exact {
+			return fitness, false
+		}
+		o.SetFitness(fitness)
+		return fitness, true
+	}
+	if !o.sqErrKnown {
+		o.sqErr = ga.SquaredDiff(o.DNA, o.problem.target)
+		o.sqErrKnown = true
+	}
+	o.SetFitness(int64(math.Sqrt(float64(o.sqErr))))
+	return o.fitness, true
+}
+
+// Unscored returns the pixels of the Organism if its fitness isn't known.
+// The error of pixels bred with the incremental fitness is already known,
+// so only its square root is left to work out here.
+func (o *Organism) Unscored() *image.RGBA {
+	if o.fitness >= 0 {
+		return nil
+	}
+	if o.sqErrKnown && o.problem.run.Incremental() {
+		o.Fitness()
+		return nil
+	}
+	return o.DNA
+}
+
+// SetFitness of the Organism, worked out with the current scoring
+func (o *Organism) SetFitness(fitness int64) {
+	o.fitness = fitness
+	o.scoring = o.problem.run.Scoring()
+}
+
+// hash of the organism's pixels
+func (o *Organism) hash(seed maphash.Seed) uint64 {
+	return maphash.Bytes(seed, o.DNA.Pix)
+}
+
+// Crossover the Organism with another one
+func (o *Organism) Crossover(other ga.Genome, rng *rand.Rand) ga.Genome {
+	d1, d2 := o, other.(*Organism)
+	pix := make([]uint8, len(d1.DNA.Pix))
+	child := &Organism{
+		DNA: &image.RGBA{
+			Pix:    pix,
+			Stride: d1.DNA.Stride,
+			Rect:   d1.DNA.Rect,
+		},
+		fitness: -1,
+		problem: d1.problem,
+	}
+	// work out the error of the child as it's bred, so mutations can update it
+	incremental, target := d1.problem.run.Incremental(), d1.problem.target
+	fromFirst := d1.problem.run.Crossover(len(d1.DNA.Pix), rng)
+	blend, alpha := d1.problem.blend, d1.problem.cfg.BlendAlpha
+	var cut func(x int, y int) bool
+	if d1.problem.spatial {
+		cut = ga.NewCut(d1.DNA.Rect.Dx(), d1.DNA.Rect.Dy(), rng)
+	}
+	for i := 0; i < len(d1.DNA.Pix); i++ {
+		switch {
+		case cut != nil:
+			// every channel of a pixel comes from the side of the cut it's on
+			x, y := i%d1.DNA.Stride/4, i/d1.DNA.Stride
+			if cut(x, y) {
+				child.DNA.Pix[i] = d1.DNA.Pix[i]
+			} else {
+				child.DNA.Pix[i] = d2.DNA.Pix[i]
+			}
+		case blend:
+			v := ga.Blend(float64(d1.DNA.Pix[i]), float64(d2.DNA.Pix[i]), alpha, rng)
+			child.DNA.Pix[i] = uint8(min(max(math.Round(v), 0), 255))
+		case fromFirst(i):
+			child.DNA.Pix[i] = d1.DNA.Pix[i]
+		default:
+			child.DNA.Pix[i] = d2.DNA.Pix[i]
+		}
+		if incremental {
+			child.sqErr += squareDifference(child.DNA.Pix[i], target.Pix[i])
+		}
+	}
+	child.sqErrKnown = incremental
+	return child
+}
+
+// Mutate the Organism string
+func (o *Organism) Mutate(rng *rand.Rand) {
+	o.mutate(rng, o.problem.run.MutationRate())
+}
+
+// mutate the Organism string
+func (o *Organism) mutate(rng *rand.Rand, rate float64) {
+	target := o.problem.target
+	// only the bytes that mutate are visited
+	for i := ga.Skip(rng, rate); i < len(o.DNA.Pix); i += 1 + ga.Skip(rng, rate) {
+		if o.sqErrKnown {
+			// only the error of the changed byte changes
+			o.sqErr -= squareDifference(o.DNA.Pix[i], target.Pix[i])
+			o.DNA.Pix[i] = uint8(rng.Intn(255))
+			o.sqErr += squareDifference(o.DNA.Pix[i], target.Pix[i])
+		} else {
+			o.DNA.Pix[i] = uint8(rng.Intn(255))
+		}
+	}
+	o.fitness = -1
+}
+
+// square the difference of 2 bytes
+func squareDifference(x, y uint8) int64 {
+	d := int64(x) - int64(y)
+	return d * d
+}
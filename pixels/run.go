@@ -0,0 +1,215 @@
+package pixels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"math/rand"
+	"slices"
+
+	"github.com/sensorphalanx/ga"
+)
+
+// Config holds the parameters of a run
+type Config struct {
+	ga.RunConfig
+	// SeedFromTarget starts the population from jittered copies of the
+	// target instead of random noise
+	SeedFromTarget bool
+	// Jitter is the max amount each byte is moved away from the target when
+	// seeding the population from the target
+	Jitter int
+	// SeedBlur blurs the target the population is seeded from by this radius
+	// first, so evolving starts from its broad shapes and colors and works
+	// out the details. 0 seeds it from the target as it is.
+	SeedBlur int
+	// Start is the image to start evolving from instead of random noise, the
+	// rest of the initial population are mutated copies of it
+	Start *image.RGBA
+	// Resume continues the run saved in the checkpoint instead of starting
+	// from a new population, PopSize must be the size of its population
+	Resume *Checkpoint
+	// Progress is called after every generation with the stats so far and
+	// the best organism, it can be nil
+	Progress func(stats ga.RunStats, best *Organism)
+	// Improved is called with the generation and the organism whenever a
+	// generation has an organism fitter than any found before, it can be
+	// nil
+	Improved func(generation int, best *Organism)
+	// Checkpoint is called after every generation with what's needed to
+	// continue the run from there, it can be nil
+	Checkpoint func(c Checkpoint)
+}
+
+// DefaultConfig returns the parameters the monalisa demo is tuned with
+func DefaultConfig() Config {
+	return Config{
+		RunConfig: ga.RunConfig{
+			MutationRate:        0.0004,
+			PopSize:             250,
+			PoolSize:            30,
+			Selection:           "pool",
+			Crossover:           "one-point",
+			Strategy:            "generational",
+			Temperature:         10,
+			Cooling:             "exponential",
+			CoolingRate:         0.999,
+			BlendAlpha:          0.5,
+			TournamentSize:      3,
+			FitnessLimit:        7500,
+			Fitness:             "diff",
+			SampleRate:          1,
+			PyramidStep:         0.1,
+			Restart:             0.5,
+			MigrationInterval:   50,
+			Topology:            "ring",
+			Migrants:            2,
+			MutationStart:       10,
+			MutationGenerations: 1000,
+			HypermutationFactor: 10,
+			HypermutationBurst:  50,
+		},
+		Jitter: 50,
+	}
+}
+
+// problem is the target every organism of a run is evolved towards and the
+// parameters it's evolved with
+type problem struct {
+	target *image.RGBA
+	cfg    Config
+	// run is how the organisms are scored and mutated
+	run *ga.ImageRun
+	// blend is whether children are blended from their parents rather than
+	// copied from them
+	blend bool
+	// spatial is whether children take what's on each side of a cut across
+	// the picture from each parent
+	spatial bool
+	// seed is the image organisms are seeded from with SeedFromTarget, the
+	// target blurred by SeedBlur
+	seed *image.RGBA
+}
+
+// check that the parameters of the organisms can be used to evolve the
+// target, NewImageRun checks the rest
+func (cfg Config) validate(target *image.RGBA) error {
+	if cfg.Jitter < 0 {
+		return errors.New("jitter cannot be negative")
+	}
+	if cfg.SeedBlur < 0 {
+		return errors.New("seed blur cannot be negative")
+	}
+	if cfg.Start != nil && cfg.Start.Rect.Size() != target.Rect.Size() {
+		return errors.New("the image to start from must be the same size as the target")
+	}
+	if cfg.Resume != nil {
+		// every genome is checked, a checkpoint that's been tampered with
+		// or cut short could have any of them wrong
+		genomes := cfg.Resume.Population
+		if cfg.Resume.Best != nil {
+			genomes = append(slices.Clip(genomes), *cfg.Resume.Best)
+		}
+		for i, g := range genomes {
+			if !g.valid() {
+				return fmt.Errorf("genome %d of the checkpoint doesn't hold a %dx%d image", i, g.Width, g.Height)
+			}
+			if g.Width != target.Rect.Dx() || g.Height != target.Rect.Dy() {
+				return fmt.Errorf("the checkpoint is for a %dx%d target but the target is %dx%d",
+					g.Width, g.Height, target.Rect.Dx(), target.Rect.Dy())
+			}
+		}
+	}
+	return nil
+}
+
+// Run evolves an image that looks like the target and returns the best image
+// found. It doesn't print or save anything, use cfg.Progress for that.
+func Run(target *image.RGBA, cfg Config) (*image.RGBA, ga.Stats, error) {
+	best, stats, err := Evolve(context.Background(), target, cfg)
+	if err != nil {
+		return nil, stats, err
+	}
+	return best.DNA, stats, nil
+}
+
+// Evolve is like Run but also stops when the context is done, in which case
+// the best organism found so far is returned.
+func Evolve(ctx context.Context, target *image.RGBA, cfg Config) (*Organism, ga.Stats, error) {
+	err := cfg.validate(target)
+	if err != nil {
+		return nil, ga.Stats{}, err
+	}
+	run, err := ga.NewImageRun(target, cfg.RunConfig)
+	if err != nil {
+		return nil, ga.Stats{}, err
+	}
+	defer run.Close()
+
+	p := &problem{target: target, cfg: cfg, run: run, blend: cfg.Crossover == "blend", spatial: cfg.Crossover == "spatial", seed: target}
+	if cfg.SeedBlur > 0 {
+		p.seed = ga.Blur(target, cfg.SeedBlur)
+	}
+	gaCfg := run.Config(func(stats ga.RunStats, best ga.Genome) {
+		if cfg.Progress != nil {
+			cfg.Progress(stats, best.(*Organism))
+		}
+	})
+	gaCfg.NewGenome = func(rng *rand.Rand) ga.Genome {
+		return createOrganism(p, rng)
+	}
+	if cfg.Checkpoint != nil {
+		gaCfg.Checkpoint = func(state ga.State) {
+			cfg.Checkpoint(ga.NewCheckpoint(run, state, p.save))
+		}
+	}
+	if cfg.Improved != nil {
+		gaCfg.Improved = func(generation int, best ga.Genome) {
+			cfg.Improved(generation, best.(*Organism))
+		}
+	}
+	var population []ga.Genome
+	if cfg.Resume != nil {
+		population, err = ga.Resume(run, &gaCfg, *cfg.Resume, p.load)
+		if err != nil {
+			return nil, ga.Stats{}, err
+		}
+	} else {
+		// the generations are bred with other streams of the seed
+		population = createPopulation(p, run.Rand(run.Seed()))
+	}
+
+	best, stats, err := ga.Evolve(ctx, population, gaCfg)
+	if err != nil {
+		return nil, stats, err
+	}
+	return best.(*Organism), stats, nil
+}
+
+// creates the initial population with the random numbers of rng
+func createPopulation(p *problem, rng *rand.Rand) (population []ga.Genome) {
+	population = make([]ga.Genome, p.cfg.PopSize)
+	for i := 0; i < p.cfg.PopSize; i++ {
+		population[i] = createOrganism(p, rng)
+	}
+	return
+}
+
+// make the organism saved in a checkpoint
+func (p *problem) load(g Genome) ga.Genome {
+	return &Organism{
+		DNA: &image.RGBA{
+			Pix:    g.Pix,
+			Stride: g.Width * 4,
+			Rect:   image.Rect(0, 0, g.Width, g.Height),
+		},
+		fitness: -1,
+		problem: p,
+	}
+}
+
+// what's saved of an organism in a checkpoint
+func (p *problem) save(g ga.Genome) Genome {
+	return g.(*Organism).Genome()
+}
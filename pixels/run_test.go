@@ -0,0 +1,99 @@
+package pixels
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/sensorphalanx/ga"
+)
+
+// a small target with a gradient across it, so there's something to evolve
+// towards in every pixel
+func gradient(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 255 / w), uint8(y * 255 / h), 128, 255})
+		}
+	}
+	return img
+}
+
+func TestRun(t *testing.T) {
+	target := gradient(16, 12)
+	cfg := DefaultConfig()
+	cfg.PopSize = 50
+	cfg.FitnessLimit = 0
+	cfg.MaxGenerations = 30
+	cfg.Seed = 1
+	first := int64(-1)
+	cfg.Progress = func(stats ga.RunStats, best *Organism) {
+		if first < 0 {
+			first = stats.Fitness
+		}
+	}
+	img, stats, err := Run(target, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Rect.Size() != target.Rect.Size() {
+		t.Errorf("got a %v image for a %v target", img.Rect.Size(), target.Rect.Size())
+	}
+	if first < 0 {
+		t.Fatal("progress was never reported")
+	}
+	if stats.Fitness >= first {
+		t.Errorf("fitness didn't improve on %d, got %d", first, stats.Fitness)
+	}
+}
+
+func TestEvolveStopsAtDeadline(t *testing.T) {
+	target := gradient(16, 12)
+	cfg := DefaultConfig()
+	cfg.PopSize = 50
+	cfg.FitnessLimit = 0
+	cfg.Seed = 1
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	best, stats, err := Evolve(ctx, target, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("took %s to stop after a 50ms deadline", elapsed)
+	}
+	if best == nil || best.DNA.Rect.Size() != target.Rect.Size() {
+		t.Fatal("didn't return the best organism the size of the target")
+	}
+	if best.Fitness() != stats.Fitness {
+		t.Errorf("the best organism has a fitness of %d but the stats say %d", best.Fitness(), stats.Fitness)
+	}
+}
+
+// the fitness of the best organism of the first generation
+func firstFitness(t *testing.T, target *image.RGBA, seedFromTarget bool) int64 {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.PopSize = 50
+	cfg.FitnessLimit = 0
+	cfg.MaxGenerations = 1
+	cfg.Seed = 1
+	cfg.SeedFromTarget = seedFromTarget
+	_, stats, err := Run(target, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stats.Fitness
+}
+
+func TestSeedFromTarget(t *testing.T) {
+	target := gradient(16, 12)
+	random, seeded := firstFitness(t, target, false), firstFitness(t, target, true)
+	if seeded >= random {
+		t.Errorf("seeding from the target starts at a fitness of %d, no better than %d from random", seeded, random)
+	}
+}
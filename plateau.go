@@ -0,0 +1,32 @@
+package ga
+
+// Plateau tells when the best fitness of a run has stopped improving
+type Plateau struct {
+	// Generations is the number of generations the best fitness can go
+	// without improving before the run is on a plateau
+	Generations int
+	// the best fitness so far and the generation it was reached in
+	best     int64
+	improved int
+}
+
+// NewPlateau returns a plateau detector that hasn't seen any generation yet
+func NewPlateau(generations int) *Plateau {
+	return &Plateau{Generations: generations, best: -1}
+}
+
+// Reached is called with the stats of every generation and returns whether
+// the best fitness hasn't improved for Generations generations. The count
+// starts over once a plateau is reported, so that whatever is done about it
+// has time to work before the next one.
+func (p *Plateau) Reached(stats Stats) bool {
+	if p.best < 0 || stats.Fitness < p.best {
+		p.best, p.improved = stats.Fitness, stats.Generations
+		return false
+	}
+	if stats.Generations-p.improved < p.Generations {
+		return false
+	}
+	p.improved = stats.Generations
+	return true
+}
@@ -0,0 +1,216 @@
+package ga
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// imageProtocols are the ways PrintImage can show an image on a terminal by
+// name
+var imageProtocols = map[string]func(w io.Writer, img image.Image) error{
+	"blocks": writeBlocks,
+	"iterm2": writeITerm2,
+	"kitty":  writeKitty,
+	"none":   writeNone,
+	"sixel":  writeSixel,
+}
+
+// the protocol PrintImage shows images with, auto until it's been detected
+var imageProtocol = "auto"
+
+// ImageProtocolNames returns the names of the protocols PrintImage can show
+// images with
+func ImageProtocolNames() []string {
+	names := make([]string, 0, len(imageProtocols))
+	for name := range imageProtocols {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetImageProtocol makes PrintImage show images with the protocol with the
+// given name, iterm2 for iTerm2's OSC 1337, kitty for kitty's graphics
+// protocol, sixel for xterm, mlterm, foot, WezTerm and the like, blocks for
+// any terminal with 24 bit color, none to show no images, or auto, the
+// default, for the one DetectImageProtocol detects the first time an image
+// is shown
+func SetImageProtocol(name string) error {
+	if _, ok := imageProtocols[name]; !ok && name != "auto" {
+		return fmt.Errorf("unknown image protocol %q, use auto or one of %s", name, strings.Join(ImageProtocolNames(), ", "))
+	}
+	imageProtocol = name
+	return nil
+}
+
+// PrintImage shows the image on the terminal with the protocol set with
+// SetImageProtocol. Inside tmux the image is passed through to the terminal
+// tmux runs in, which needs tmux's allow-passthrough option on.
+func PrintImage(img image.Image) {
+	if imageProtocol == "auto" {
+		imageProtocol = DetectImageProtocol()
+	}
+	if !inTmux() {
+		imageProtocols[imageProtocol](os.Stdout, img)
+		return
+	}
+	var buf bytes.Buffer
+	imageProtocols[imageProtocol](&buf, img)
+	os.Stdout.Write(tmuxPassthrough(buf.Bytes()))
+}
+
+// write nothing, for runs on servers and in batch jobs
+func writeNone(w io.Writer, img image.Image) error {
+	return nil
+}
+
+// write the image as an inline PNG in iTerm2's OSC 1337
+func writeITerm2(w io.Writer, img image.Image) error {
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		return fmt.Errorf("cannot encode image: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "\x1b]1337;File=inline=1:%s\a\n", base64.StdEncoding.EncodeToString(buf.Bytes()))
+	return err
+}
+
+// the largest image drawn with blocks in characters, every character shows
+// two pixels one above the other
+const (
+	blocksWidth  = 80
+	blocksHeight = 40
+)
+
+// write the image shrunk to fit in half block characters in 24 bit color,
+// for terminals that can't show images
+func writeBlocks(w io.Writer, img image.Image) error {
+	_, err := io.WriteString(w, halfBlocks(shrinkToFit(ToRGBA(img), blocksWidth, 2*blocksHeight)))
+	return err
+}
+
+// the image scaled down to fit in width by height pixels, keeping its
+// aspect ratio
+func shrinkToFit(img *image.RGBA, width, height int) *image.RGBA {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	scale := min(float64(width)/float64(w), float64(height)/float64(h), 1)
+	return Resize(img, max(int(float64(w)*scale), 1), max(int(float64(h)*scale), 1))
+}
+
+// the most base64 bytes of a PNG in a chunk of kitty's graphics protocol
+const kittyChunk = 4096
+
+// write the image as a PNG in kitty's graphics protocol, in chunks as kitty
+// wants them, without kitty answering
+func writeKitty(w io.Writer, img image.Image) error {
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		return fmt.Errorf("cannot encode image: %w", err)
+	}
+	data := base64.StdEncoding.EncodeToString(buf.Bytes())
+	bw := bufio.NewWriter(w)
+	// the first chunk says what the image is, the rest only whether more
+	// follow
+	control := "a=T,f=100,q=2,"
+	for {
+		chunk := data[:min(kittyChunk, len(data))]
+		data = data[len(chunk):]
+		more := 0
+		if len(data) > 0 {
+			more = 1
+		}
+		fmt.Fprintf(bw, "\x1b_G%sm=%d;%s\x1b\\", control, more, chunk)
+		control = ""
+		if more == 0 {
+			break
+		}
+	}
+	bw.WriteString("\n")
+	return bw.Flush()
+}
+
+// write the image as sixels, dithered down to 256 colors. Every band of six
+// rows is drawn a color at a time, each sixel character setting the pixels
+// of its column that have the color.
+func writeSixel(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	p := image.NewPaletted(image.Rect(0, 0, bounds.Dx(), bounds.Dy()), palette.Plan9)
+	draw.FloydSteinberg.Draw(p, p.Rect, img, bounds.Min)
+	width, height := p.Rect.Dx(), p.Rect.Dy()
+	bw := bufio.NewWriter(w)
+	// the pixels have the same width as height
+	fmt.Fprintf(bw, "\x1bPq\"1;1;%d;%d", width, height)
+	var used [256]bool
+	for _, i := range p.Pix {
+		used[i] = true
+	}
+	for i, c := range p.Palette {
+		if used[i] {
+			r, g, b, _ := c.RGBA()
+			fmt.Fprintf(bw, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, b*100/0xffff)
+		}
+	}
+	sixels := make([]byte, width)
+	for top := 0; top < height; top += 6 {
+		rows := min(6, height-top)
+		var inBand [256]bool
+		for y := top; y < top+rows; y++ {
+			for _, i := range p.Pix[y*p.Stride : y*p.Stride+width] {
+				inBand[i] = true
+			}
+		}
+		first := true
+		for i := range inBand {
+			if !inBand[i] {
+				continue
+			}
+			for x := range sixels {
+				var bits byte
+				for dy := range rows {
+					if p.Pix[(top+dy)*p.Stride+x] == uint8(i) {
+						bits |= 1 << dy
+					}
+				}
+				sixels[x] = '?' + bits
+			}
+			// go back to the start of the band for every color but the
+			// first
+			if !first {
+				bw.WriteByte('$')
+			}
+			first = false
+			fmt.Fprintf(bw, "#%d", i)
+			writeSixelRuns(bw, bytes.TrimRight(sixels, "?"))
+		}
+		bw.WriteByte('-')
+	}
+	bw.WriteString("\x1b\\\n")
+	return bw.Flush()
+}
+
+// write the sixels with runs of the same sixel as a repeat count
+func writeSixelRuns(w *bufio.Writer, sixels []byte) {
+	for i := 0; i < len(sixels); {
+		n := 1
+		for i+n < len(sixels) && sixels[i+n] == sixels[i] {
+			n++
+		}
+		if n > 3 {
+			fmt.Fprintf(w, "!%d%c", n, sixels[i])
+		} else {
+			w.Write(sixels[i : i+n])
+		}
+		i += n
+	}
+}
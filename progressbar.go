@@ -0,0 +1,145 @@
+package ga
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// the number of generations the improvement of the fitness and the
+// generation rate of a progress bar are worked out over
+const progressWindow = 100
+
+// the width of a progress bar in characters
+const progressWidth = 30
+
+// how often a progress bar is redrawn at most
+const progressRedraw = 100 * time.Millisecond
+
+// ProgressBar shows how far a run has got on one line of a terminal, redrawn
+// as the run goes, with the generations a second and an estimate of how long
+// is left. The estimate extrapolates how the fitness improved over the last
+// generations, slowing down as much as it did between the first and the
+// second half of them, to when it reaches the fitness limit, or to when the
+// max generations are bred if that's sooner.
+type ProgressBar struct {
+	w              io.Writer
+	limit          int64
+	maxGenerations int
+	start          int64
+	// the generation, time and fitness of the last generations, oldest
+	// first
+	samples []progressSample
+	drawn   time.Time
+}
+
+type progressSample struct {
+	generation int
+	elapsed    time.Duration
+	fitness    int64
+}
+
+// NewProgressBar makes a progress bar drawn on w of a run that stops once
+// the fitness is below limit or maxGenerations have been bred, 0 meaning no
+// limit
+func NewProgressBar(w io.Writer, limit int64, maxGenerations int) *ProgressBar {
+	return &ProgressBar{w: w, limit: limit, maxGenerations: maxGenerations}
+}
+
+// Update the bar with the stats of the generation that was just bred
+func (b *ProgressBar) Update(stats Stats) {
+	if len(b.samples) == 0 {
+		b.start = stats.Fitness
+	}
+	if len(b.samples) == progressWindow {
+		b.samples = b.samples[1:]
+	}
+	b.samples = append(b.samples, progressSample{stats.Generations, stats.Elapsed, stats.Fitness})
+	if time.Since(b.drawn) >= progressRedraw {
+		b.draw()
+	}
+}
+
+// Done draws the bar as it ends and moves on to the next line
+func (b *ProgressBar) Done() {
+	if len(b.samples) > 0 {
+		b.draw()
+	}
+	fmt.Fprintln(b.w)
+}
+
+// draw the bar over the line it was drawn on before
+func (b *ProgressBar) draw() {
+	b.drawn = time.Now()
+	last := b.samples[len(b.samples)-1]
+	done := b.done()
+	filled := int(done * progressWidth)
+	line := fmt.Sprintf("\r[%s%s] %3.0f%% | generation %d | fitness %d → %d | %.1f gen/s | ETA %s",
+		strings.Repeat("#", filled), strings.Repeat(" ", progressWidth-filled), done*100,
+		last.generation, last.fitness, b.limit, b.rate(), b.eta())
+	// clear what's left of a longer line drawn before
+	fmt.Fprint(b.w, line, "\033[K")
+}
+
+// the fraction of the run that's done, by how far the fitness has come
+// towards the limit or the generations bred towards the max
+func (b *ProgressBar) done() float64 {
+	last := b.samples[len(b.samples)-1]
+	var done float64
+	if b.start > b.limit {
+		done = float64(b.start-last.fitness) / float64(b.start-b.limit)
+	}
+	if b.maxGenerations > 0 {
+		done = max(done, float64(last.generation)/float64(b.maxGenerations))
+	}
+	return min(max(done, 0), 1)
+}
+
+// the generations bred a second over the last generations
+func (b *ProgressBar) rate() float64 {
+	first, last := b.samples[0], b.samples[len(b.samples)-1]
+	if last.elapsed <= first.elapsed {
+		return 0
+	}
+	return float64(last.generation-first.generation) / (last.elapsed - first.elapsed).Seconds()
+}
+
+// how long is left until the run stops, or "?" if it can't be told yet
+func (b *ProgressBar) eta() string {
+	first, last := b.samples[0], b.samples[len(b.samples)-1]
+	if last.elapsed <= first.elapsed {
+		return "?"
+	}
+	left := time.Duration(-1)
+	// the improvements of the halves of the last generations, the fitness
+	// is assumed to keep improving by a fraction q of the half before each
+	// half from now on
+	middle := b.samples[len(b.samples)/2]
+	before, after := float64(first.fitness-middle.fitness), float64(middle.fitness-last.fitness)
+	half := float64(last.elapsed - middle.elapsed)
+	gap := float64(max(last.fitness-b.limit, 0))
+	if after > 0 && half > 0 {
+		q := after / max(before, after)
+		switch {
+		case q == 1:
+			left = time.Duration(gap / after * half)
+		case gap < after*q/(1-q):
+			// the halves it takes for the improvements to add up to the
+			// gap, a geometric series
+			halves := math.Log(1-gap*(1-q)/(after*q)) / math.Log(q)
+			left = time.Duration(halves * half)
+		}
+	}
+	if b.maxGenerations > 0 && b.rate() > 0 {
+		toMax := time.Duration(float64(b.maxGenerations-last.generation) / b.rate() * float64(time.Second))
+		if left < 0 || toMax < left {
+			left = toMax
+		}
+	}
+	if left < 0 {
+		return "?"
+	}
+	return left.Round(time.Second).String()
+}
@@ -0,0 +1,77 @@
+package ga
+
+import (
+	"image"
+	"math"
+)
+
+// Pyramid holds the target and its weights halved in size again and again,
+// so that images can be compared roughly at a small size early in a run and
+// exactly at full size later on
+type Pyramid struct {
+	targets []*image.RGBA
+	weights [][]float64
+}
+
+// NewPyramid makes a pyramid of levels halvings of the target on top of the
+// full size target. weights can be nil, like for WeightedDiff.
+func NewPyramid(target *image.RGBA, weights []float64, levels int) *Pyramid {
+	p := &Pyramid{
+		targets: []*image.RGBA{target},
+		weights: [][]float64{weights},
+	}
+	w, h := target.Rect.Dx(), target.Rect.Dy()
+	for level := 1; level <= levels; level++ {
+		lw, lh := max(1, w>>level), max(1, h>>level)
+		p.targets = append(p.targets, Resize(target, lw, lh))
+		if weights != nil {
+			p.weights = append(p.weights, resizeWeights(weights, w, h, lw, lh))
+		} else {
+			p.weights = append(p.weights, nil)
+		}
+	}
+	return p
+}
+
+// Levels returns the number of halvings in the pyramid, level 0 is the full
+// size target
+func (p *Pyramid) Levels() int {
+	return len(p.targets) - 1
+}
+
+// Diff is the difference between the image and the target at the given
+// level, with the image shrunk to the size of the target at that level. Like
+// with SampledDiff the result is scaled up to the full size so it can be
+// compared with the result of Diff. A sampleRate above 1 only compares every
+// nth pixel.
+func (p *Pyramid) Diff(img *image.RGBA, level int, sampleRate int) int64 {
+	target := p.targets[level]
+	f := DiffFitness{Weights: p.weights[level], SampleRate: sampleRate}
+	if level == 0 {
+		return f.Score(img, target)
+	}
+	w, h := target.Rect.Dx(), target.Rect.Dy()
+	d := f.Score(Resize(img, w, h), target)
+	full := p.targets[0].Rect.Dx() * p.targets[0].Rect.Dy()
+	return int64(float64(d) * math.Sqrt(float64(full)/float64(w*h)))
+}
+
+// shrink the weights of a sw x sh image to w x h, each weight is the average
+// of the weights it covers like Resize does with pixels
+func resizeWeights(weights []float64, sw int, sh int, w int, h int) []float64 {
+	resized := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		y0, y1 := y*sh/h, max((y+1)*sh/h, y*sh/h+1)
+		for x := 0; x < w; x++ {
+			x0, x1 := x*sw/w, max((x+1)*sw/w, x*sw/w+1)
+			sum := 0.0
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					sum += weights[sy*sw+sx]
+				}
+			}
+			resized[y*w+x] = sum / float64((y1-y0)*(x1-x0))
+		}
+	}
+	return resized
+}
@@ -0,0 +1,236 @@
+package ga
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Remote scores encoded candidates somewhere else than in this process, on
+// Workers or on evaluators pulling them from a Queue
+type Remote interface {
+	// Score scores every candidate into the score with the same index
+	Score(ctx context.Context, candidates [][]byte, scores []int64) error
+	// Close releases what the remote holds
+	Close() error
+}
+
+// the subjects of a queue: batches of candidates are sent to the evaluators
+// listening on queueScore, which ask for the problem of a batch they don't
+// know on queueProblem followed by its ID
+const (
+	queueScore     = "ga.score"
+	queueProblem   = "ga.problem."
+	queueEvaluator = "ga.evaluators"
+)
+
+// the most candidates sent in one batch, small batches spread out between
+// the evaluators better
+const queueBatch = 4
+
+// how long a batch can take to be scored before it's given up on, e.g. when
+// its evaluator went away
+const queueTimeout = time.Minute
+
+// messages on a queue, sent with gob
+type (
+	// the reply to a request for a part of a problem, which is split up
+	// into parts that fit in a message
+	problemPart struct {
+		Parts int
+		Data  []byte
+	}
+	queueReply struct {
+		Scores []int64
+		Err    string
+	}
+)
+
+// Queue is a NATS queue a master pushes its candidates onto in batches. Any
+// number of evaluators started with ServeQueue pull the batches, score them
+// and send back the scores, so evaluators can come and go during a run.
+type Queue struct {
+	conn *nats.Conn
+	sub  *nats.Subscription
+	id   uint64
+}
+
+// NewQueue connects to the NATS server at the URL, e.g.
+// nats://host:4222, and hands the problem to the evaluators that ask for it
+func NewQueue(url string, problem []byte) (*Queue, error) {
+	conn, err := nats.Connect(url)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to queue: %w", err)
+	}
+	q := &Queue{conn: conn, id: problemID(problem)}
+	size := max(int(conn.MaxPayload())/2, 1)
+	parts := (len(problem) + size - 1) / size
+	q.sub, err = conn.Subscribe(queueProblem+strconv.FormatUint(q.id, 16), func(m *nats.Msg) {
+		part, err := strconv.Atoi(string(m.Data))
+		if err != nil || part < 0 || part >= max(parts, 1) {
+			return
+		}
+		data := problem[min(part*size, len(problem)):min((part+1)*size, len(problem))]
+		reply, err := gobCodec{}.Marshal(problemPart{Parts: parts, Data: data})
+		if err == nil {
+			m.Respond(reply)
+		}
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("cannot serve problem: %w", err)
+	}
+	return q, nil
+}
+
+// Score sends the candidates to the evaluators in batches and waits for all
+// their scores
+func (q *Queue) Score(ctx context.Context, candidates [][]byte, scores []int64) error {
+	var batches [][2]int
+	size := int(q.conn.MaxPayload()) / 2
+	for lo := 0; lo < len(candidates); {
+		// a batch holds at least one candidate, however big
+		hi, n := lo+1, len(candidates[lo])
+		for hi < len(candidates) && hi-lo < queueBatch && n+len(candidates[hi]) <= size {
+			n += len(candidates[hi])
+			hi++
+		}
+		batches = append(batches, [2]int{lo, hi})
+		lo = hi
+	}
+	errs := make([]error, len(batches))
+	var wg sync.WaitGroup
+	for i, b := range batches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = q.score(ctx, candidates[b[0]:b[1]], scores[b[0]:b[1]])
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// score a batch of candidates on whichever evaluator pulls it
+func (q *Queue) score(ctx context.Context, candidates [][]byte, scores []int64) error {
+	req, err := gobCodec{}.Marshal(scoreRequest{ID: q.id, Candidates: candidates})
+	if err != nil {
+		return fmt.Errorf("cannot encode batch: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, queueTimeout)
+	defer cancel()
+	m, err := q.conn.RequestWithContext(ctx, queueScore, req)
+	if err != nil {
+		return fmt.Errorf("cannot score batch: %w", err)
+	}
+	var reply queueReply
+	err = gobCodec{}.Unmarshal(m.Data, &reply)
+	if err != nil {
+		return fmt.Errorf("cannot decode scores: %w", err)
+	}
+	if reply.Err != "" {
+		return errors.New(reply.Err)
+	}
+	if len(reply.Scores) != len(candidates) {
+		return fmt.Errorf("an evaluator sent %d scores for %d candidates", len(reply.Scores), len(candidates))
+	}
+	copy(scores, reply.Scores)
+	return nil
+}
+
+// Close stops handing out the problem and disconnects from the queue
+func (q *Queue) Close() error {
+	q.sub.Unsubscribe()
+	q.conn.Close()
+	return nil
+}
+
+// evaluator pulls batches from a queue, the problems of the batches are
+// fetched from their masters the first time they're seen
+type evaluator struct {
+	conn   *nats.Conn
+	worker *worker
+	// fetching makes sure only one batch fetches a problem at a time
+	fetching sync.Mutex
+}
+
+// ServeQueue pulls batches of candidates from the NATS server at the URL
+// until the context is done, scoring them with evaluators made by maker for
+// their problems. It pulls a batch for every CPU at a time.
+func ServeQueue(ctx context.Context, url string, maker EvaluatorMaker) error {
+	conn, err := nats.Connect(url)
+	if err != nil {
+		return fmt.Errorf("cannot connect to queue: %w", err)
+	}
+	defer conn.Close()
+	e := &evaluator{conn: conn, worker: &worker{maker: maker, evaluators: map[uint64]Evaluator{}}}
+	// the server hands every batch to one subscription of the queue group,
+	// and each subscription scores a batch at a time
+	for i := 0; i < runtime.GOMAXPROCS(0); i++ {
+		_, err := conn.QueueSubscribe(queueScore, queueEvaluator, e.handle)
+		if err != nil {
+			return fmt.Errorf("cannot subscribe to queue: %w", err)
+		}
+	}
+	<-ctx.Done()
+	return nil
+}
+
+// score the batch in the message and send back the scores
+func (e *evaluator) handle(m *nats.Msg) {
+	var reply queueReply
+	var req scoreRequest
+	err := gobCodec{}.Unmarshal(m.Data, &req)
+	if err == nil {
+		err = e.prepare(req.ID)
+	}
+	if err == nil {
+		var r *scoreReply
+		r, err = e.worker.score(&req)
+		if r != nil {
+			reply.Scores = r.Scores
+		}
+	}
+	if err != nil {
+		reply.Err = err.Error()
+	}
+	data, err := gobCodec{}.Marshal(reply)
+	if err == nil {
+		m.Respond(data)
+	}
+}
+
+// fetch the problem with the ID from its master, unless it's known
+func (e *evaluator) prepare(id uint64) error {
+	e.fetching.Lock()
+	defer e.fetching.Unlock()
+	e.worker.mu.Lock()
+	_, ok := e.worker.evaluators[id]
+	e.worker.mu.Unlock()
+	if ok {
+		return nil
+	}
+	var problem bytes.Buffer
+	for part, parts := 0, 1; part < parts; part++ {
+		m, err := e.conn.Request(queueProblem+strconv.FormatUint(id, 16), []byte(strconv.Itoa(part)), queueTimeout)
+		if err != nil {
+			return fmt.Errorf("cannot fetch problem: %w", err)
+		}
+		var p problemPart
+		err = gobCodec{}.Unmarshal(m.Data, &p)
+		if err != nil {
+			return fmt.Errorf("cannot decode problem: %w", err)
+		}
+		parts = p.Parts
+		problem.Write(p.Data)
+	}
+	_, err := e.worker.prepare(&prepareRequest{Problem: problem.Bytes()})
+	return err
+}
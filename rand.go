@@ -0,0 +1,128 @@
+package ga
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// randSources are the sources of random numbers a run can make its streams
+// with by name, see RandSource
+var randSources = map[string]func(seed int64) rand.Source{
+	"go":      rand.NewSource,
+	"xoshiro": NewXoshiro,
+}
+
+// RandSourceNames returns the names of the sources of random numbers a run
+// can make its streams with
+func RandSourceNames() []string {
+	names := make([]string, 0, len(randSources))
+	for name := range randSources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RandSource returns the source of random numbers with the given name to
+// use as the Source of a Config, go for math/rand's, which an empty name
+// is too, or xoshiro for NewXoshiro's, which is a lot quicker to make and
+// to draw from
+func RandSource(name string) (func(seed int64) rand.Source, error) {
+	if name == "" {
+		name = "go"
+	}
+	source, ok := randSources[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown random number source %q, use one of %s", name, strings.Join(RandSourceNames(), ", "))
+	}
+	return source, nil
+}
+
+// make the random number generator for a stream of random numbers of a
+// generation with the Source of the run. Every stream is made from the seed
+// so a run can be repeated, or continued from a checkpoint, exactly.
+func (cfg Config) newRand(seed int64, generation int, stream int) *rand.Rand {
+	x := splitmix(uint64(seed) ^ splitmix(uint64(generation)))
+	x = splitmix(x ^ uint64(stream))
+	return rand.New(cfg.Source(int64(x)))
+}
+
+// the SplitMix64 mixing function, it spreads nearby inputs over the whole
+// range of outputs
+func splitmix(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+	x = (x ^ x>>27) * 0x94d049bb133111eb
+	return x ^ x>>31
+}
+
+// Xoshiro is the xoshiro256** generator of random numbers. Its state is 4
+// words where math/rand's source has 607, so it's made in a fraction of the
+// time, which counts as every child of a generation is bred with a stream of
+// its own, and it draws numbers quicker too.
+type Xoshiro struct {
+	s [4]uint64
+}
+
+// NewXoshiro returns a xoshiro256** source seeded with the seed
+func NewXoshiro(seed int64) rand.Source {
+	x := &Xoshiro{}
+	x.Seed(seed)
+	return x
+}
+
+// Seed the source, spreading the seed over the state with SplitMix64 as the
+// authors of xoshiro suggest
+func (x *Xoshiro) Seed(seed int64) {
+	for i := range x.s {
+		x.s[i] = splitmix(uint64(seed) + uint64(i)*0x9e3779b97f4a7c15)
+	}
+}
+
+// Uint64 returns the next random number
+func (x *Xoshiro) Uint64() uint64 {
+	s := &x.s
+	result := bits.RotateLeft64(s[1]*5, 7) * 9
+	t := s[1] << 17
+	s[2] ^= s[0]
+	s[3] ^= s[1]
+	s[1] ^= s[2]
+	s[0] ^= s[3]
+	s[2] ^= t
+	s[3] = bits.RotateLeft64(s[3], 45)
+	return result
+}
+
+// Int63 returns the next random number without its sign
+func (x *Xoshiro) Int63() int64 {
+	return int64(x.Uint64() >> 1)
+}
+
+// Skip returns the number of genes to skip before the next one that
+// mutates, when every gene mutates with the chance rate. It's drawn from the
+// geometric distribution, so looping over the genes that mutate
+//
+//	for i := Skip(rng, rate); i < len(genes); i += 1 + Skip(rng, rate)
+//
+// takes a random number for every gene that mutates instead of one for
+// every gene. It's never more than maxSkip, so adding it to an index of a
+// slice can't overflow an int, even one of 32 bits.
+func Skip(rng *rand.Rand, rate float64) int {
+	if rate >= 1 {
+		return 0
+	}
+	if rate <= 0 {
+		return maxSkip
+	}
+	// 1-Float64 is never 0, so its log is never -Inf
+	skip := math.Floor(math.Log(1-rng.Float64()) / math.Log1p(-rate))
+	return int(min(skip, maxSkip))
+}
+
+// maxSkip is the most genes Skip skips, half the largest int of 32 bits so
+// that an index below it plus 1 plus a skip is still an int
+const maxSkip = math.MaxInt32 / 2
@@ -0,0 +1,276 @@
+package ga
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+)
+
+// Evaluator scores candidates sent by a master on a worker. What a candidate
+// is, e.g. an encoded genome the worker renders before scoring it, is up to
+// the master and the workers of a run.
+type Evaluator interface {
+	// Score scores every candidate into the score with the same index
+	Score(candidates [][]byte, scores []int64) error
+}
+
+// EvaluatorMaker makes the evaluator of a problem a master sent to a worker,
+// e.g. its encoded target and fitness
+type EvaluatorMaker func(problem []byte) (Evaluator, error)
+
+// the most problems a worker keeps evaluators for, so a worker shared by a
+// few masters doesn't have to be sent their problems again and again
+const workerProblems = 4
+
+// the largest message a master and its workers send, a problem holds the
+// whole target
+const maxMessage = 1 << 30
+
+// messages between a master and its workers, sent with gob
+type (
+	prepareRequest struct {
+		Problem []byte
+	}
+	prepareReply struct {
+		ID uint64
+	}
+	scoreRequest struct {
+		ID         uint64
+		Candidates [][]byte
+	}
+	scoreReply struct {
+		Scores []int64
+	}
+)
+
+// gobCodec encodes the messages of the worker service with gob, so it needs
+// no generated code
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var b bytes.Buffer
+	err := gob.NewEncoder(&b).Encode(v)
+	return b.Bytes(), err
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func (gobCodec) Name() string {
+	return "gob"
+}
+
+func init() {
+	encoding.RegisterCodec(gobCodec{})
+}
+
+// workerServer is what the worker service is served by
+type workerServer interface {
+	prepare(req *prepareRequest) (*prepareReply, error)
+	score(req *scoreRequest) (*scoreReply, error)
+}
+
+// the worker service, written out by hand as its messages aren't protobufs
+var workerService = grpc.ServiceDesc{
+	ServiceName: "ga.Worker",
+	HandlerType: (*workerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Prepare", Handler: unaryHandler(workerServer.prepare)},
+		{MethodName: "Score", Handler: unaryHandler(workerServer.score)},
+	},
+	Metadata: "remote.go",
+}
+
+// the gRPC handler of a method of the worker service
+func unaryHandler[Req any, Reply any](method func(workerServer, *Req) (*Reply, error)) grpc.MethodHandler {
+	return func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		req := new(Req)
+		err := dec(req)
+		if err != nil {
+			return nil, err
+		}
+		return method(srv.(workerServer), req)
+	}
+}
+
+// worker holds the evaluators of the problems it was last sent by their ID
+type worker struct {
+	maker      EvaluatorMaker
+	mu         sync.Mutex
+	evaluators map[uint64]Evaluator
+	recent     []uint64
+}
+
+// prepare an evaluator for the problem, or keep the one there is
+func (w *worker) prepare(req *prepareRequest) (*prepareReply, error) {
+	id := problemID(req.Problem)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.evaluators[id]; ok {
+		return &prepareReply{ID: id}, nil
+	}
+	e, err := w.maker(req.Problem)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "cannot prepare problem: %v", err)
+	}
+	if len(w.recent) == workerProblems {
+		delete(w.evaluators, w.recent[0])
+		w.recent = w.recent[1:]
+	}
+	w.evaluators[id] = e
+	w.recent = append(w.recent, id)
+	return &prepareReply{ID: id}, nil
+}
+
+// score the candidates with the evaluator of their problem
+func (w *worker) score(req *scoreRequest) (*scoreReply, error) {
+	w.mu.Lock()
+	e, ok := w.evaluators[req.ID]
+	w.mu.Unlock()
+	if !ok {
+		// e.g. the worker was restarted, the master prepares it again
+		return nil, status.Error(codes.FailedPrecondition, "unknown problem")
+	}
+	scores := make([]int64, len(req.Candidates))
+	err := e.Score(req.Candidates, scores)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot score candidates: %v", err)
+	}
+	return &scoreReply{Scores: scores}, nil
+}
+
+// the ID of a problem, which is the same on every worker it's sent to
+func problemID(problem []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(problem)
+	return h.Sum64()
+}
+
+// ServeWorker serves masters on the listener until it's closed, scoring
+// their candidates with evaluators made by maker for their problems. There's
+// no authentication or encryption, workers are meant for a trusted network.
+func ServeWorker(lis net.Listener, maker EvaluatorMaker) error {
+	s := grpc.NewServer(grpc.MaxRecvMsgSize(maxMessage), grpc.MaxSendMsgSize(maxMessage))
+	s.RegisterService(&workerService, &worker{maker: maker, evaluators: map[uint64]Evaluator{}})
+	return s.Serve(lis)
+}
+
+// Workers are the workers a master scores its candidates on, each batch of
+// candidates is split between them
+type Workers struct {
+	addrs   []string
+	conns   []*grpc.ClientConn
+	problem []byte
+	id      uint64
+}
+
+// DialWorkers connects to the workers at the addresses, e.g. host:7070, and
+// sends every one of them the problem
+func DialWorkers(ctx context.Context, addrs []string, problem []byte) (*Workers, error) {
+	if len(addrs) == 0 {
+		return nil, errors.New("no workers to dial")
+	}
+	w := &Workers{addrs: addrs, problem: problem, id: problemID(problem)}
+	for _, addr := range addrs {
+		conn, err := grpc.NewClient(addr,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithDefaultCallOptions(grpc.CallContentSubtype(gobCodec{}.Name()),
+				grpc.MaxCallRecvMsgSize(maxMessage), grpc.MaxCallSendMsgSize(maxMessage)))
+		if err != nil {
+			w.Close()
+			return nil, fmt.Errorf("cannot dial worker %s: %w", addr, err)
+		}
+		w.conns = append(w.conns, conn)
+	}
+	for i := range w.conns {
+		err := w.prepare(ctx, i)
+		if err != nil {
+			w.Close()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// send the problem to the ith worker
+func (w *Workers) prepare(ctx context.Context, i int) error {
+	var reply prepareReply
+	err := w.conns[i].Invoke(ctx, "/ga.Worker/Prepare", &prepareRequest{Problem: w.problem}, &reply)
+	if err != nil {
+		return fmt.Errorf("cannot prepare worker %s: %w", w.addrs[i], err)
+	}
+	if reply.ID != w.id {
+		return fmt.Errorf("worker %s prepared a different problem", w.addrs[i])
+	}
+	return nil
+}
+
+// Score scores every candidate into the score with the same index. The
+// candidates are split evenly between the workers, the share of a worker
+// that fails is scored by the next one.
+func (w *Workers) Score(ctx context.Context, candidates [][]byte, scores []int64) error {
+	errs := make([]error, len(w.conns))
+	var wg sync.WaitGroup
+	for i := range w.conns {
+		lo, hi := i*len(candidates)/len(w.conns), (i+1)*len(candidates)/len(w.conns)
+		if lo == hi {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for try := 0; try < len(w.conns); try++ {
+				errs[i] = w.score(ctx, (i+try)%len(w.conns), candidates[lo:hi], scores[lo:hi])
+				if errs[i] == nil || ctx.Err() != nil {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// score the candidates on the ith worker, sending it the problem again if
+// it doesn't have it anymore
+func (w *Workers) score(ctx context.Context, i int, candidates [][]byte, scores []int64) error {
+	var reply scoreReply
+	req := &scoreRequest{ID: w.id, Candidates: candidates}
+	err := w.conns[i].Invoke(ctx, "/ga.Worker/Score", req, &reply)
+	if status.Code(err) == codes.FailedPrecondition {
+		err = w.prepare(ctx, i)
+		if err != nil {
+			return err
+		}
+		err = w.conns[i].Invoke(ctx, "/ga.Worker/Score", req, &reply)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot score on worker %s: %w", w.addrs[i], err)
+	}
+	if len(reply.Scores) != len(candidates) {
+		return fmt.Errorf("worker %s sent %d scores for %d candidates", w.addrs[i], len(reply.Scores), len(candidates))
+	}
+	copy(scores, reply.Scores)
+	return nil
+}
+
+// Close the connections to the workers
+func (w *Workers) Close() error {
+	var errs []error
+	for _, conn := range w.conns {
+		errs = append(errs, conn.Close())
+	}
+	return errors.Join(errs...)
+}
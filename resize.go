@@ -0,0 +1,48 @@
+package ga
+
+import (
+	"image"
+)
+
+// Downscale shrinks the image so that neither side is longer than maxDim,
+// keeping its proportions. Images that are already small enough are
+// returned as they are.
+func Downscale(img *image.RGBA, maxDim int) *image.RGBA {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	if maxDim < 1 || (w <= maxDim && h <= maxDim) {
+		return img
+	}
+	if w >= h {
+		return Resize(img, maxDim, max(1, (h*maxDim+w/2)/w))
+	}
+	return Resize(img, max(1, (w*maxDim+h/2)/h), maxDim)
+}
+
+// Resize the image to w x h. Every pixel of the result is the average of the
+// pixels of the image it covers, which keeps detail when shrinking.
+func Resize(img *image.RGBA, w int, h int) *image.RGBA {
+	src := ToRGBA(img)
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := y*sh/h, max((y+1)*sh/h, y*sh/h+1)
+		for x := 0; x < w; x++ {
+			x0, x1 := x*sw/w, max((x+1)*sw/w, x*sw/w+1)
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					i := src.PixOffset(sx, sy)
+					for c := 0; c < 4; c++ {
+						sum[c] += int(src.Pix[i+c])
+					}
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			i := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				dst.Pix[i+c] = uint8(sum[c] / n)
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,190 @@
+package ga
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// Selector picks the genomes that breed the next generation
+type Selector interface {
+	// Pool returns the reproduction pool for the next generation from the
+	// population, which is sorted by fitness with the fittest first. Both
+	// parents of every child are picked at random from the pool, so a genome
+	// can appear in it more than once to make it more likely to breed. Any
+	// randomness must come from rng.
+	Pool(population []Genome, rng *rand.Rand) []Genome
+}
+
+// selectors make each of the built in selectors
+var selectors = map[string]func(poolSize int, tournamentSize int) Selector{
+	"pool": func(poolSize int, tournamentSize int) Selector {
+		return PoolSelector{Size: poolSize}
+	},
+	"tournament": func(poolSize int, tournamentSize int) Selector {
+		return TournamentSelector{Size: tournamentSize}
+	},
+	"roulette": func(poolSize int, tournamentSize int) Selector {
+		return RouletteSelector{}
+	},
+	"rank": func(poolSize int, tournamentSize int) Selector {
+		return RankSelector{}
+	},
+	"truncation": func(poolSize int, tournamentSize int) Selector {
+		return TruncationSelector{Size: poolSize}
+	},
+}
+
+// SelectorNames returns the names of the built in selectors
+func SelectorNames() []string {
+	names := make([]string, 0, len(selectors))
+	for name := range selectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewSelector returns the built in selector with the given name. poolSize is
+// used by the pool and truncation selectors and tournamentSize by the
+// tournament selector.
+func NewSelector(name string, poolSize int, tournamentSize int) (Selector, error) {
+	maker, ok := selectors[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown selector %q, use one of %s", name, strings.Join(SelectorNames(), ", "))
+	}
+	if poolSize < 1 {
+		return nil, errors.New("pool size must be at least 1")
+	}
+	if tournamentSize < 1 {
+		return nil, errors.New("tournament size must be at least 1")
+	}
+	return maker(poolSize, tournamentSize), nil
+}
+
+// PoolSelector fills the pool with the top Size genomes, each of them picked
+// with a chance proportional to how much fitter it is than the genome right
+// after them
+type PoolSelector struct {
+	Size int
+}
+
+// Pool creates the reproduction pool that creates the next generation
+func (s PoolSelector) Pool(population []Genome, rng *rand.Rand) []Genome {
+	poolSize := min(s.Size, len(population)-1)
+	// get top best fitting genomes
+	top := population[0 : poolSize+1]
+	// if there is no difference between the top genomes, the population is
+	// stable and we can't get generate a proper breeding pool so we make the
+	// pool equal to the population and reproduce the next generation
+	if top[len(top)-1].Fitness()-top[0].Fitness() == 0 {
+		return population
+	}
+	// pick from the top genomes with the chance each of them used to have
+	// when it was added once for every point of fitness it's better by, the
+	// pool is the size of the population however big the differences are
+	worst := top[poolSize].Fitness()
+	return spinN(top[:poolSize], len(population), rng, func(i int) float64 {
+		return float64(worst - top[i].Fitness())
+	})
+}
+
+// Picker is a Selector that can pick every parent straight from the
+// population, which doesn't have to be sorted. When a Picker is used and no
+// genomes are carried over as elite Evolve skips sorting the population and
+// building the pool, and calls Pick for both parents of every child instead.
+type Picker interface {
+	Selector
+	// Pick returns a genome of the population to breed, any randomness must
+	// come from rng. Pick is called concurrently.
+	Pick(population []Genome, rng *rand.Rand) Genome
+}
+
+// TournamentSelector picks the winners of tournaments between Size genomes
+// picked at random. It's a Picker, so it needs neither a sorted population
+// nor a pool.
+type TournamentSelector struct {
+	Size int
+}
+
+// Pool holds a tournament for every place in the pool
+func (s TournamentSelector) Pool(population []Genome, rng *rand.Rand) []Genome {
+	pool := make([]Genome, len(population))
+	for i := range pool {
+		pool[i] = s.Pick(population, rng)
+	}
+	return pool
+}
+
+// Pick holds a tournament and returns its winner, the fittest genome in it
+func (s TournamentSelector) Pick(population []Genome, rng *rand.Rand) Genome {
+	winner := population[rng.Intn(len(population))]
+	for n := 1; n < s.Size; n++ {
+		if g := population[rng.Intn(len(population))]; g.Fitness() < winner.Fitness() {
+			winner = g
+		}
+	}
+	return winner
+}
+
+// RouletteSelector fills the pool with genomes picked with a chance
+// proportional to how much fitter they are than the least fit genome
+type RouletteSelector struct{}
+
+// Pool spins the roulette wheel for every place in the pool
+func (s RouletteSelector) Pool(population []Genome, rng *rand.Rand) []Genome {
+	worst := population[len(population)-1].Fitness()
+	return spin(population, rng, func(i int) float64 {
+		return float64(worst-population[i].Fitness()) + 1
+	})
+}
+
+// RankSelector fills the pool with genomes picked with a chance proportional
+// to their rank, so the fittest genome is picked n times as often as the
+// least fit one in a population of n
+type RankSelector struct{}
+
+// Pool picks a genome by rank for every place in the pool
+func (s RankSelector) Pool(population []Genome, rng *rand.Rand) []Genome {
+	return spin(population, rng, func(i int) float64 {
+		return float64(len(population) - i)
+	})
+}
+
+// TruncationSelector puts the top Size genomes in the pool once each
+type TruncationSelector struct {
+	Size int
+}
+
+// Pool keeps the fittest genomes
+func (s TruncationSelector) Pool(population []Genome, rng *rand.Rand) []Genome {
+	return population[:min(s.Size, len(population))]
+}
+
+// fill a pool the size of the population with genomes picked at random, the
+// chance of each genome being picked is proportional to its weight
+func spin(population []Genome, rng *rand.Rand, weight func(i int) float64) []Genome {
+	return spinN(population, len(population), rng, weight)
+}
+
+// fill a pool of n genomes picked at random from the candidates, the chance of
+// each candidate being picked is proportional to its weight
+func spinN(candidates []Genome, n int, rng *rand.Rand, weight func(i int) float64) []Genome {
+	cumulative := make([]float64, len(candidates))
+	total := 0.0
+	for i := range candidates {
+		total += weight(i)
+		cumulative[i] = total
+	}
+
+	pool := make([]Genome, n)
+	for i := range pool {
+		r := rng.Float64() * total
+		// the first candidate whose range goes past r, a candidate with no
+		// weight has an empty range and is never picked
+		pool[i] = candidates[sort.Search(len(cumulative), func(j int) bool { return cumulative[j] > r })]
+	}
+	return pool
+}
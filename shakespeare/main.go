@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"math/rand"
+	"sort"
 	"time"
 )
 
@@ -15,10 +16,11 @@ var PopSize = 500
 
 func main() {
 	start := time.Now()
-	rand.Seed(time.Now().UTC().UnixNano())
+	// every random number comes from rng
+	rng := rand.New(rand.NewSource(time.Now().UTC().UnixNano()))
 
 	target := []byte("To be or not to be")
-	population := createPopulation(target)
+	population := createPopulation(target, rng)
 
 	found := false
 	generation := 0
@@ -31,8 +33,8 @@ func main() {
 			found = true
 		} else {
 			maxFitness := bestOrganism.Fitness
-			pool := createPool(population, target, maxFitness)
-			population = naturalSelection(pool, population, target)
+			pool := createPool(population, target, maxFitness, rng)
+			population = naturalSelection(pool, population, target, rng)
 		}
 
 	}
@@ -47,10 +49,10 @@ type Organism struct {
 }
 
 // creates a Organism
-func createOrganism(target []byte) (organism Organism) {
+func createOrganism(target []byte, rng *rand.Rand) (organism Organism) {
 	ba := make([]byte, len(target))
 	for i := 0; i < len(target); i++ {
-		ba[i] = byte(rand.Intn(95) + 32)
+		ba[i] = byte(rng.Intn(95) + 32)
 	}
 	organism = Organism{
 		DNA:     ba,
@@ -61,10 +63,10 @@ func createOrganism(target []byte) (organism Organism) {
 }
 
 // creates the initial population
-func createPopulation(target []byte) (population []Organism) {
+func createPopulation(target []byte, rng *rand.Rand) (population []Organism) {
 	population = make([]Organism, PopSize)
 	for i := 0; i < PopSize; i++ {
-		population[i] = createOrganism(target)
+		population[i] = createOrganism(target, rng)
 	}
 	return
 }
@@ -82,30 +84,39 @@ func (d *Organism) calcFitness(target []byte) {
 }
 
 // create the breeding pool that creates the next generation
-func createPool(population []Organism, target []byte, maxFitness float64) (pool []Organism) {
-	pool = make([]Organism, 0)
-	// create a pool for next generation
+func createPool(population []Organism, target []byte, maxFitness float64, rng *rand.Rand) (pool []Organism) {
+	if maxFitness == 0 {
+		// nothing matches the target yet, so any organism will do
+		return population
+	}
+	// each organism is picked with a chance proportional to its fitness
+	cumulative := make([]float64, len(population))
+	total := 0.0
 	for i := 0; i < len(population); i++ {
 		population[i].calcFitness(target)
-		num := int((population[i].Fitness / maxFitness) * 100)
-		for n := 0; n < num; n++ {
-			pool = append(pool, population[i])
-		}
+		total += population[i].Fitness / maxFitness
+		cumulative[i] = total
+	}
+	// create a pool for next generation
+	pool = make([]Organism, len(population))
+	for i := range pool {
+		r := rng.Float64() * total
+		pool[i] = population[sort.Search(len(cumulative), func(j int) bool { return cumulative[j] > r })]
 	}
 	return
 }
 
 // perform natural selection to create the next generation
-func naturalSelection(pool []Organism, population []Organism, target []byte) []Organism {
+func naturalSelection(pool []Organism, population []Organism, target []byte, rng *rand.Rand) []Organism {
 	next := make([]Organism, len(population))
 
 	for i := 0; i < len(population); i++ {
-		r1, r2 := rand.Intn(len(pool)), rand.Intn(len(pool))
+		r1, r2 := rng.Intn(len(pool)), rng.Intn(len(pool))
 		a := pool[r1]
 		b := pool[r2]
 
-		child := crossover(a, b)
-		child.mutate()
+		child := crossover(a, b, rng)
+		child.mutate(rng)
 		child.calcFitness(target)
 
 		next[i] = child
@@ -114,12 +125,12 @@ func naturalSelection(pool []Organism, population []Organism, target []byte) []O
 }
 
 // crosses over 2 Organisms
-func crossover(d1 Organism, d2 Organism) Organism {
+func crossover(d1 Organism, d2 Organism, rng *rand.Rand) Organism {
 	child := Organism{
 		DNA:     make([]byte, len(d1.DNA)),
 		Fitness: 0,
 	}
-	mid := rand.Intn(len(d1.DNA))
+	mid := rng.Intn(len(d1.DNA))
 	for i := 0; i < len(d1.DNA); i++ {
 		if i > mid {
 			child.DNA[i] = d1.DNA[i]
@@ -132,10 +143,10 @@ func crossover(d1 Organism, d2 Organism) Organism {
 }
 
 // mutate the Organism
-func (d *Organism) mutate() {
+func (d *Organism) mutate(rng *rand.Rand) {
 	for i := 0; i < len(d.DNA); i++ {
-		if rand.Float64() < MutationRate {
-			d.DNA[i] = byte(rand.Intn(95) + 32)
+		if rng.Float64() < MutationRate {
+			d.DNA[i] = byte(rng.Intn(95) + 32)
 		}
 	}
 }
@@ -0,0 +1,58 @@
+package ga
+
+import (
+	"image"
+)
+
+// SSIMScale is what the structural dissimilarity 1 - SSIM is multiplied by
+// in SSIMDiff, so that it fits in an int64 fitness
+const SSIMScale = 1000000
+
+// size of the windows SSIM is worked out over, and how far apart they are
+const (
+	ssimWindow = 8
+	ssimStep   = 4
+)
+
+// SSIMDiff is the structural dissimilarity between 2 images, 1 - SSIM scaled
+// up by SSIMScale. SSIM compares the mean, contrast and structure of small
+// windows of the images, which is closer to how people see differences than
+// comparing pixels one by one. 0 means the images are the same.
+func SSIMDiff(a, b *image.RGBA) int64 {
+	const (
+		c1 = (0.01 * 255) * (0.01 * 255)
+		c2 = (0.03 * 255) * (0.03 * 255)
+	)
+	w, h := a.Rect.Dx(), a.Rect.Dy()
+	win := min(ssimWindow, w, h)
+	total := 0.0
+	n := 0
+	for y := 0; y+win <= h; y += ssimStep {
+		for x := 0; x+win <= w; x += ssimStep {
+			// compare the red, green and blue channels separately
+			for c := 0; c < 3; c++ {
+				var sa, sb, saa, sbb, sab float64
+				for wy := y; wy < y+win; wy++ {
+					i := wy*a.Stride + x*4 + c
+					for wx := 0; wx < win; wx++ {
+						va, vb := float64(a.Pix[i]), float64(b.Pix[i])
+						sa += va
+						sb += vb
+						saa += va * va
+						sbb += vb * vb
+						sab += va * vb
+						i += 4
+					}
+				}
+				count := float64(win * win)
+				ma, mb := sa/count, sb/count
+				va := saa/count - ma*ma
+				vb := sbb/count - mb*mb
+				cov := sab/count - ma*mb
+				total += ((2*ma*mb + c1) * (2*cov + c2)) / ((ma*ma + mb*mb + c1) * (va + vb + c2))
+				n++
+			}
+		}
+	}
+	return int64((1 - total/float64(n)) * SSIMScale)
+}
@@ -0,0 +1,64 @@
+package ga
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// the columns of a stats CSV
+var statsColumns = []string{"generation", "best", "mean", "worst", "pool_size", "mutation_rate", "elapsed_seconds"}
+
+// StatsCSV appends a row of stats for every generation to a CSV file, to be
+// looked at in a spreadsheet or with pandas
+type StatsCSV struct {
+	f *os.File
+	w *csv.Writer
+}
+
+// NewStatsCSV opens the CSV file at filePath to append to, writing the header
+// first if the file is new or empty
+func NewStatsCSV(filePath string) (*StatsCSV, error) {
+	f, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open stats file: %w", err)
+	}
+	s := &StatsCSV{f: f, w: csv.NewWriter(f)}
+	info, err := f.Stat()
+	if err == nil && info.Size() == 0 {
+		err = s.w.Write(statsColumns)
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("cannot write stats header: %w", err)
+	}
+	return s, nil
+}
+
+// Add the row of a generation's stats bred with the mutation rate. The best
+// and worst fitness are those of the generation, not of the whole run.
+func (s *StatsCSV) Add(stats Stats, mutationRate float64) error {
+	s.w.Write([]string{
+		strconv.Itoa(stats.Generations),
+		strconv.FormatInt(stats.Best, 10),
+		strconv.FormatFloat(stats.Mean, 'f', 2, 64),
+		strconv.FormatInt(stats.Worst, 10),
+		strconv.Itoa(stats.PoolSize),
+		strconv.FormatFloat(mutationRate, 'g', -1, 64),
+		strconv.FormatFloat(stats.Elapsed.Seconds(), 'f', 3, 64),
+	})
+	// every row is written out as it's added, so the file can be followed
+	// while the run goes
+	s.w.Flush()
+	err := s.w.Error()
+	if err != nil {
+		return fmt.Errorf("cannot write stats: %w", err)
+	}
+	return nil
+}
+
+// Close the file
+func (s *StatsCSV) Close() error {
+	return s.f.Close()
+}
@@ -0,0 +1,105 @@
+package ga
+
+import (
+	"math/rand"
+	"slices"
+	"sort"
+)
+
+// strategies are the built in ways of breeding the next generation of an
+// island by name, given the ranked genomes at the start of its population
+var strategies = map[string]func(cfg Config, ranked []Genome, population []Genome, seed int64, generations int) generation{
+	// a genetic algorithm, the selector picks the parents of every child
+	"generational": Config.generational,
+	// the (μ+λ) evolution strategy, the μ genomes of the population and
+	// their λ children compete to survive
+	"plus": Config.plus,
+	// the (μ,λ) evolution strategy, only the children survive
+	"comma": Config.comma,
+	// simulated annealing of every genome on its own
+	"anneal": Config.anneal,
+	// the (1+1) hill climber, climbing every genome on its own
+	"hill-climb": Config.hillClimb,
+}
+
+// StrategyNames returns the names of the built in strategies
+func StrategyNames() []string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// breed Lambda children of parents picked at random, and keep the fittest of
+// them and the population
+func (cfg Config) plus(_ []Genome, population []Genome, seed int64, generations int) generation {
+	return cfg.evolutionStrategy(population, seed, generations, true)
+}
+
+// breed Lambda children of parents picked at random, and keep the fittest of
+// them
+func (cfg Config) comma(_ []Genome, population []Genome, seed int64, generations int) generation {
+	return cfg.evolutionStrategy(population, seed, generations, false)
+}
+
+// breed Lambda children of parents picked at random from the whole
+// population, the selector isn't used. The fittest of the children survive,
+// with the population as well if plus is set. None of the population is
+// estimated as survivors never keeps estimated children.
+func (cfg Config) evolutionStrategy(population []Genome, seed int64, generations int, plus bool) generation {
+	lambda := cfg.Lambda
+	if lambda == 0 {
+		lambda = len(population)
+	}
+	pick := func(rng *rand.Rand) Genome {
+		return population[rng.Intn(len(population))]
+	}
+	g := generation{poolSize: len(population), children: lambda}
+	g.population, g.replaced, g.improved = survivors(pick, population, lambda, plus, cfg.childRands(seed, generations), cfg.Evaluate)
+	return g
+}
+
+// breed n children like naturalSelection does and pick the ones that
+// survive into the next generation, as many as there are genomes. With plus
+// the fittest of the population and the children survive, the genomes
+// winning ties with the children, otherwise the fittest of the children. It
+// returns the next generation sorted by fitness, so none of it has an
+// estimated fitness, the genomes and children that didn't survive and the
+// number of children fitter than both of their parents.
+func survivors(pick func(rng *rand.Rand) Genome, population []Genome, n int, plus bool, rngs func(i int) *rand.Rand, evaluate func([]Genome)) ([]Genome, []Genome, int) {
+	sorted := slices.Clone(population)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Fitness() < sorted[j].Fitness()
+	})
+	// a child that isn't fitter than the least fit genome never survives
+	// with plus, the ones whose fitness is only known to be above it are
+	// dropped without ranking them
+	bound := int64(-1)
+	if plus {
+		bound = sorted[len(sorted)-1].Fitness()
+	}
+	children, improved, estimated := naturalSelection(pick, make([]Genome, n), 0, bound, rngs, evaluate)
+	children, dropped := children[:n-estimated], children[n-estimated:]
+	sort.SliceStable(children, func(i, j int) bool {
+		return children[i].Fitness() < children[j].Fitness()
+	})
+	if !plus {
+		return children[:len(population)], slices.Concat(population, children[len(population):]), improved
+	}
+
+	// the genomes and the children are merged fittest first
+	merged := make([]Genome, 0, len(sorted)+len(children))
+	i, j := 0, 0
+	for i < len(sorted) || j < len(children) {
+		if j == len(children) || (i < len(sorted) && sorted[i].Fitness() <= children[j].Fitness()) {
+			merged = append(merged, sorted[i])
+			i++
+		} else {
+			merged = append(merged, children[j])
+			j++
+		}
+	}
+	return merged[:len(population)], slices.Concat(merged[len(population):], dropped), improved
+}
@@ -0,0 +1,157 @@
+package ga
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// how long DetectImageProtocol waits for the terminal to answer its queries
+const terminalTimeout = 300 * time.Millisecond
+
+// the answer of kitty to the query of DetectImageProtocol, and the primary
+// device attributes every terminal answers with, 4 meaning sixels
+var (
+	kittyAnswer     = []byte("\x1b_Gi=31;OK")
+	attributesReply = regexp.MustCompile(`\x1b\[\?([0-9;]*)c`)
+)
+
+// DetectImageProtocol returns the name of the protocol the terminal shows
+// images with, see SetImageProtocol. It goes by the environment variables
+// of the terminals that set them, and asks the terminal otherwise, falling
+// back on blocks for a terminal that can't show images or doesn't answer.
+func DetectImageProtocol() string {
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "" || os.Getenv("TERM") == "xterm-kitty" || os.Getenv("TERM_PROGRAM") == "ghostty":
+		return "kitty"
+	// LC_TERMINAL gets through ssh
+	case os.Getenv("TERM_PROGRAM") == "iTerm.app" || os.Getenv("LC_TERMINAL") == "iTerm2" || os.Getenv("TERM_PROGRAM") == "WezTerm":
+		return "iterm2"
+	case os.Getenv("TERM") == "foot" || strings.HasPrefix(os.Getenv("TERM"), "mlterm") || os.Getenv("MLTERM") != "":
+		return "sixel"
+	}
+	answer := queryTerminal()
+	if bytes.Contains(answer, kittyAnswer) {
+		return "kitty"
+	}
+	if m := attributesReply.FindSubmatch(answer); m != nil {
+		for _, attribute := range strings.Split(string(m[1]), ";") {
+			if attribute == "4" {
+				return "sixel"
+			}
+		}
+	}
+	return "blocks"
+}
+
+// ask the terminal whether it shows images with kitty's graphics protocol,
+// followed by its primary device attributes, which every terminal answers,
+// and return what it answered until then, or nothing if it's not a terminal
+func queryTerminal() []byte {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil
+	}
+	defer tty.Close()
+	// the descriptor is used through SyscallConn, as Fd would make reads
+	// block past the deadline
+	conn, err := tty.SyscallConn()
+	if err != nil {
+		return nil
+	}
+	var state *term.State
+	conn.Control(func(fd uintptr) {
+		state, err = term.MakeRaw(int(fd))
+	})
+	if err != nil {
+		return nil
+	}
+	defer conn.Control(func(fd uintptr) {
+		term.Restore(int(fd), state)
+	})
+	// a 1x1 RGB image kitty is asked whether it could show, without it
+	// being shown
+	query := []byte("\x1b_Gi=31,s=1,v=1,a=q,t=d,f=24;AAAA\x1b\\")
+	if inTmux() {
+		query = tmuxPassthrough(query)
+	}
+	_, err = tty.Write(append(query, "\x1b[c"...))
+	if err != nil {
+		return nil
+	}
+	tty.SetReadDeadline(time.Now().Add(terminalTimeout))
+	var answer []byte
+	buf := make([]byte, 256)
+	for !attributesReply.Match(answer) {
+		n, err := tty.Read(buf)
+		answer = append(answer, buf[:n]...)
+		if err != nil {
+			break
+		}
+	}
+	return answer
+}
+
+// the most bytes of a sequence passed through tmux in one envelope, tmux
+// drops sequences much longer than that
+const tmuxChunk = 1 << 16
+
+// whether the program runs inside tmux
+func inTmux() bool {
+	return os.Getenv("TMUX") != ""
+}
+
+// tmuxPassthrough wraps the OSC, DCS and APC sequences in b, which tmux
+// would swallow, in tmux's passthrough envelope with the escapes in them
+// doubled, splitting long ones over envelopes of up to tmuxChunk bytes. The
+// rest of b is left as it is.
+func tmuxPassthrough(b []byte) []byte {
+	var out bytes.Buffer
+	for len(b) > 0 {
+		start := sequenceStart(b)
+		if start < 0 {
+			out.Write(b)
+			break
+		}
+		out.Write(b[:start])
+		b = b[start:]
+		seq := b[:sequenceEnd(b)]
+		b = b[len(seq):]
+		for len(seq) > 0 {
+			chunk := seq[:min(tmuxChunk, len(seq))]
+			seq = seq[len(chunk):]
+			out.WriteString("\x1bPtmux;")
+			out.Write(bytes.ReplaceAll(chunk, []byte("\x1b"), []byte("\x1b\x1b")))
+			out.WriteString("\x1b\\")
+		}
+	}
+	return out.Bytes()
+}
+
+// the index of the first OSC, DCS or APC sequence in b, or -1 if there's none
+func sequenceStart(b []byte) int {
+	for i := 0; i+1 < len(b); i++ {
+		if b[i] == 0x1b && (b[i+1] == ']' || b[i+1] == 'P' || b[i+1] == '_') {
+			return i
+		}
+	}
+	return -1
+}
+
+// the length of the sequence b starts with, up to the BEL or string
+// terminator that ends it, or all of b if it doesn't end
+func sequenceEnd(b []byte) int {
+	for i := 2; i < len(b); i++ {
+		if b[i] == '\a' {
+			return i + 1
+		}
+		if b[i] == 0x1b && i+1 < len(b) && b[i+1] == '\\' {
+			return i + 2
+		}
+	}
+	return len(b)
+}
@@ -0,0 +1,98 @@
+package ga
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// topologies are the built in ways of linking islands by name, each returns
+// the islands island i of n sends its migrants to
+var topologies = map[string]func(i int, n int, rng *rand.Rand) []int{
+	// every island sends to the next one, and the last to the first
+	"ring": func(i int, n int, rng *rand.Rand) []int {
+		return []int{(i + 1) % n}
+	},
+	// the first island sends to all the others and they send to it
+	"star": func(i int, n int, rng *rand.Rand) []int {
+		if i > 0 {
+			return []int{0}
+		}
+		to := make([]int, 0, n-1)
+		for j := 1; j < n; j++ {
+			to = append(to, j)
+		}
+		return to
+	},
+	// every island sends to all the others
+	"full": func(i int, n int, rng *rand.Rand) []int {
+		to := make([]int, 0, n-1)
+		for j := 0; j < n; j++ {
+			if j != i {
+				to = append(to, j)
+			}
+		}
+		return to
+	},
+	// every island sends to another one picked at random every migration
+	"random": func(i int, n int, rng *rand.Rand) []int {
+		return []int{(i + 1 + rng.Intn(n-1)) % n}
+	},
+}
+
+// TopologyNames returns the names of the built in island topologies
+func TopologyNames() []string {
+	names := make([]string, 0, len(topologies))
+	for name := range topologies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// migrate copies of the fittest n genomes of every island to the islands the
+// topology links it to, the last estimated[i] genomes of island i only
+// having a fitness known to be above a bound. The fittest n of the genomes
+// arriving at an island replace its least fit n genomes, the estimated ones
+// first, so an island taking in migrants from many islands keeps its size.
+// Every island gets copies of its own, made by crossing the migrants over
+// with themselves, so changing a genome on one island doesn't change it on
+// others. It returns the genomes replaced and updates estimated.
+func migrate(islands [][]Genome, estimated []int, n int, topology func(i int, n int, rng *rand.Rand) []int, rng *rand.Rand) []Genome {
+	if n == 0 {
+		return nil
+	}
+	for i, island := range islands {
+		ranked := island[:len(island)-estimated[i]]
+		sort.SliceStable(ranked, func(i, j int) bool {
+			return ranked[i].Fitness() < ranked[j].Fitness()
+		})
+	}
+	// every island's emigrants are picked before any of them arrive, so
+	// they don't travel on to further islands
+	arrivals := make([][]Genome, len(islands))
+	var replaced []Genome
+	for i, island := range islands {
+		ranked := island[:len(island)-estimated[i]]
+		for _, j := range topology(i, len(islands), rng) {
+			arrivals[j] = append(arrivals[j], ranked[:min(n, len(ranked))]...)
+		}
+	}
+	for j, island := range islands {
+		arriving := arrivals[j]
+		sort.SliceStable(arriving, func(a, b int) bool {
+			return arriving[a].Fitness() < arriving[b].Fitness()
+		})
+		arriving = arriving[:min(n, len(arriving))]
+		staying := len(island) - len(arriving)
+		replaced = append(replaced, island[staying:]...)
+		// the migrants are ranked, so they go before the estimated genomes
+		// that stay
+		cut := min(len(island)-estimated[j], staying)
+		copy(island[cut+len(arriving):], island[cut:staying])
+		for k, g := range arriving {
+			island[cut+k] = g.Crossover(g, rng)
+		}
+		estimated[j] = staying - cut
+	}
+	return replaced
+}
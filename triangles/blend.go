@@ -0,0 +1,136 @@
+package triangles
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+
+	"github.com/sensorphalanx/ga"
+)
+
+// blender blends the shapes of two parents for the blend crossover, each
+// number of a shape taken from somewhere around the two parents' numbers
+type blender struct {
+	rng   *rand.Rand
+	alpha float64
+	// w and h are the size of the canvas the points stay inside
+	w int
+	h int
+}
+
+// blend a shape with the shape of the other parent in the same place. Only
+// shapes of the same kind can be blended, a polygon only with one with as
+// many vertices, otherwise a is returned as it is. The parts of a shape that
+// aren't numbers, like the character of a glyph, come from a.
+func (bl blender) blend(a Shape, b Shape) Shape {
+	switch a := a.(type) {
+	case Triangle:
+		if b, ok := b.(Triangle); ok {
+			a.P1, a.P2, a.P3 = bl.point(a.P1, b.P1), bl.point(a.P2, b.P2), bl.point(a.P3, b.P3)
+			a.Color = bl.color(a.Color, b.Color)
+		}
+		return a
+	case Gradient:
+		if b, ok := b.(Gradient); ok {
+			a.P1, a.P2, a.P3 = bl.point(a.P1, b.P1), bl.point(a.P2, b.P2), bl.point(a.P3, b.P3)
+			a.C1, a.C2, a.C3 = bl.color(a.C1, b.C1), bl.color(a.C2, b.C2), bl.color(a.C3, b.C3)
+		}
+		return a
+	case Circle:
+		if b, ok := b.(Circle); ok {
+			a.Center = bl.point(a.Center, b.Center)
+			a.R = bl.length(a.R, b.R)
+			a.Color = bl.color(a.Color, b.Color)
+		}
+		return a
+	case Ellipse:
+		if b, ok := b.(Ellipse); ok {
+			a.Center = bl.point(a.Center, b.Center)
+			a.RX, a.RY = bl.length(a.RX, b.RX), bl.length(a.RY, b.RY)
+			a.Angle = turn(bl.angle(a.Angle, b.Angle, math.Pi), 0)
+			a.Color = bl.color(a.Color, b.Color)
+		}
+		return a
+	case Rectangle:
+		if b, ok := b.(Rectangle); ok {
+			a = a.corners(bl.point(a.Min, b.Min), bl.point(a.Max, b.Max))
+			a.Color = bl.color(a.Color, b.Color)
+		}
+		return a
+	case Block:
+		// the grid is too coarse for blocks to be anywhere in between, so
+		// only their colors are blended
+		if b, ok := b.(Block); ok {
+			a.Color = bl.color(a.Color, b.Color)
+		}
+		return a
+	case Stroke:
+		if b, ok := b.(Stroke); ok {
+			a.P1, a.C, a.P2 = bl.point(a.P1, b.P1), bl.point(a.C, b.C), bl.point(a.P2, b.P2)
+			a.Width = bl.length(a.Width, b.Width)
+			a.Color = bl.color(a.Color, b.Color)
+		}
+		return a
+	case Glyph:
+		if b, ok := b.(Glyph); ok {
+			a.Center = bl.point(a.Center, b.Center)
+			a.Size = bl.length(a.Size, b.Size)
+			a.Angle = spin(bl.angle(a.Angle, b.Angle, 2*math.Pi), 0)
+			a.Color = bl.color(a.Color, b.Color)
+		}
+		return a
+	case Polygon:
+		if b, ok := b.(Polygon); ok && len(a.Points) == len(b.Points) {
+			points := make([]Point, len(a.Points))
+			for i := range points {
+				points[i] = bl.point(a.Points[i], b.Points[i])
+			}
+			a.Points = points
+			a.Color = bl.color(a.Color, b.Color)
+		}
+		return a
+	case Site:
+		if b, ok := b.(Site); ok {
+			a.Center = bl.point(a.Center, b.Center)
+			a.Color = bl.color(a.Color, b.Color)
+		}
+		return a
+	}
+	return a
+}
+
+// blend two numbers, rounded to the nearest whole one
+func (bl blender) number(a int, b int) int {
+	return int(math.Round(ga.Blend(float64(a), float64(b), bl.alpha, bl.rng)))
+}
+
+// blend two points, kept inside the canvas
+func (bl blender) point(p Point, q Point) Point {
+	return Point{
+		X: clamp(bl.number(p.X, q.X), 0, bl.w-1),
+		Y: clamp(bl.number(p.Y, q.Y), 0, bl.h-1),
+	}
+}
+
+// blend two sizes, at least 1
+func (bl blender) length(a int, b int) int {
+	return max(1, bl.number(a, b))
+}
+
+// blend two angles the short way round, angles a period apart being the same
+func (bl blender) angle(a float64, b float64, period float64) float64 {
+	b -= math.Round((b-a)/period) * period
+	return ga.Blend(a, b, bl.alpha, bl.rng)
+}
+
+// blend two colors channel by channel, premultiplied like the random colors
+// are made, so the channels but alpha are kept no more than alpha
+func (bl blender) color(c color.Color, d color.Color) color.Color {
+	r1, g1, b1, a1 := c.RGBA()
+	r2, g2, b2, a2 := d.RGBA()
+	a := clamp(bl.number(int(a1>>8), int(a2>>8)), 0, 255)
+	channel := func(v1 uint32, v2 uint32) uint8 {
+		return uint8(clamp(bl.number(int(v1>>8), int(v2>>8)), 0, a))
+	}
+	return color.RGBA{channel(r1, r2), channel(g1, g2), channel(b1, b2), uint8(a)}
+}
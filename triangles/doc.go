@@ -0,0 +1,7 @@
+// Package triangles evolves a picture of a target image drawn with
+// semi-transparent shapes, triangles by default. Run evolves a picture with
+// the given Config, and Evolve is the same with a context and returns the
+// fittest organism, whose Genome doesn't depend on the size of the target
+// and can be saved, scaled and drawn again. NewEvaluator is what a worker
+// scores the organisms of a remote master with.
+package triangles
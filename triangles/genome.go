@@ -0,0 +1,124 @@
+package triangles
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sensorphalanx/ga"
+)
+
+// Genome is what's saved of an organism so that a run can be resumed from it
+type Genome struct {
+	Width  int
+	Height int
+	// Background is the color the shapes are drawn over, genomes saved
+	// before it was evolved have a transparent one
+	Background color.RGBA
+	Shapes     []Shape
+}
+
+// SaveGenome saves the genome, as JSON if the file ends in .json and with gob otherwise
+func SaveGenome(filePath string, g Genome) error {
+	if strings.EqualFold(filepath.Ext(filePath), ".json") {
+		return saveJSONGenome(filePath, g)
+	}
+	return ga.WriteGob(filePath, g)
+}
+
+// LoadGenome loads the genome, from JSON if the file ends in .json and with gob otherwise
+func LoadGenome(filePath string) (g Genome, err error) {
+	if strings.EqualFold(filepath.Ext(filePath), ".json") {
+		g, err = loadJSONGenome(filePath)
+	} else {
+		err = ga.ReadGob(filePath, &g)
+	}
+	if err != nil {
+		return g, err
+	}
+	if !g.valid() {
+		return g, fmt.Errorf("%s has no shapes to resume from", filePath)
+	}
+	return g, nil
+}
+
+// check that the genome has shapes to draw
+func (g Genome) valid() bool {
+	return g.Width > 0 && g.Height > 0 && len(g.Shapes) > 0
+}
+
+// Fit fits the genome to a w x h target. Shapes don't depend on the resolution of
+// the picture so a genome evolved for a differently sized target is scaled
+// to the new size.
+func (g Genome) Fit(w int, h int) Genome {
+	if g.Width == w && g.Height == h {
+		return g
+	}
+	sx, sy := float64(w)/float64(g.Width), float64(h)/float64(g.Height)
+	shapes := make([]Shape, len(g.Shapes))
+	for i, shape := range g.Shapes {
+		shapes[i] = shape.Scale(sx, sy)
+	}
+	return Genome{Width: w, Height: h, Background: g.Background, Shapes: shapes}
+}
+
+// Draw draws the picture of the genome
+func (g Genome) Draw() *image.RGBA {
+	return draw(g.Width, g.Height, g.Background, g.Shapes)
+}
+
+// Checkpoint is what's saved of a whole run so that it can be continued
+// exactly where it stopped
+type Checkpoint = ga.Checkpoint[Genome]
+
+// SaveCheckpoint saves the checkpoint
+func SaveCheckpoint(filePath string, c Checkpoint) error {
+	return ga.WriteGob(filePath, c)
+}
+
+// SaveHallOfFame saves the pictures and genomes of the organisms of the hall
+// of fame entries to the directory, fittest first, and returns the paths of the pictures
+func SaveHallOfFame(dir string, entries []ga.Fame) ([]string, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create directory: %w", err)
+	}
+	paths := make([]string, len(entries))
+	for i, e := range entries {
+		o := e.Genome.(*Organism)
+		name := filepath.Join(dir, fmt.Sprintf("%02d_gen%d", i+1, e.Generation))
+		err := ga.Save(name+".png", o.DNA)
+		if err != nil {
+			return nil, err
+		}
+		err = SaveGenome(name+".gob", o.Genome())
+		if err != nil {
+			return nil, err
+		}
+		paths[i] = name + ".png"
+	}
+	return paths, nil
+}
+
+// LoadCheckpoint loads the checkpoint
+func LoadCheckpoint(filePath string) (c Checkpoint, err error) {
+	err = ga.ReadGob(filePath, &c)
+	if err != nil {
+		return c, err
+	}
+	if len(c.Population) == 0 {
+		return c, fmt.Errorf("%s doesn't hold a population", filePath)
+	}
+	for i, g := range c.Population {
+		if !g.valid() {
+			return c, fmt.Errorf("organism %d in %s has no shapes", i, filePath)
+		}
+	}
+	if c.Best != nil && !c.Best.valid() {
+		return c, fmt.Errorf("the best organism in %s has no shapes", filePath)
+	}
+	return c, nil
+}
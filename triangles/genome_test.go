@@ -0,0 +1,112 @@
+package triangles
+
+import (
+	"image"
+	"path/filepath"
+	"testing"
+)
+
+// a checkpoint of a short run towards target
+func checkpointOf(t *testing.T, w, h int) Checkpoint {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.PopSize = 20
+	cfg.PoolSize = 10
+	cfg.FitnessLimit = 0
+	cfg.MaxGenerations = 5
+	cfg.Seed = 1
+	var last Checkpoint
+	cfg.Checkpoint = func(c Checkpoint) {
+		last = c
+	}
+	_, _, err := Run(gradient(w, h), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return last
+}
+
+// resume the checkpoint towards target for a few more generations
+func resume(c Checkpoint, w, h int) error {
+	cfg := DefaultConfig()
+	cfg.PopSize = len(c.Population)
+	cfg.PoolSize = 10
+	cfg.FitnessLimit = 0
+	cfg.MaxGenerations = c.Generation + 5
+	cfg.Resume = &c
+	_, _, err := Run(gradient(w, h), cfg)
+	return err
+}
+
+func TestResumeMatchingCheckpoint(t *testing.T) {
+	c := checkpointOf(t, 16, 12)
+	checkpointPath := filepath.Join(t.TempDir(), "checkpoint.gob")
+	err := SaveCheckpoint(checkpointPath, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadCheckpoint(checkpointPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = resume(loaded, 16, 12)
+	if err != nil {
+		t.Errorf("cannot resume a checkpoint on the target it was made for: %v", err)
+	}
+}
+
+func TestResumeMismatchedCheckpoint(t *testing.T) {
+	// a checkpoint continues a run exactly so it can't be rescaled, only a
+	// genome can
+	c := checkpointOf(t, 16, 12)
+	err := resume(c, 32, 24)
+	if err == nil {
+		t.Error("resumed a checkpoint for a 16x12 target on a 32x24 one")
+	}
+}
+
+func TestRescaleGenome(t *testing.T) {
+	c := checkpointOf(t, 16, 12)
+	genomePath := filepath.Join(t.TempDir(), "genome.gob")
+	err := SaveGenome(genomePath, *c.Best)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := LoadGenome(genomePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fitted := g.Fit(16, 12); len(fitted.Shapes) != len(g.Shapes) || fitted.Draw().Rect != image.Rect(0, 0, 16, 12) {
+		t.Error("fitting the genome to the target it was made for changed it")
+	}
+
+	fitted := g.Fit(32, 24)
+	if img := fitted.Draw(); img.Rect != image.Rect(0, 0, 32, 24) {
+		t.Fatalf("the rescaled genome draws a %v picture, want 32x24", img.Rect.Size())
+	}
+	for i, shape := range fitted.Shapes {
+		tri := shape.(Triangle)
+		if !onCanvas(t, "rescaled", tri, 32, 24) {
+			break
+		}
+		// every point is twice as far from the corner
+		if old := g.Shapes[i].(Triangle); tri.P1.X < old.P1.X*2-1 || tri.P1.X > old.P1.X*2+1 {
+			t.Errorf("the point %v was rescaled to %v", old.P1, tri.P1)
+		}
+	}
+
+	// a rescaled genome can start a run on the bigger target
+	cfg := DefaultConfig()
+	cfg.PopSize = 20
+	cfg.PoolSize = 10
+	cfg.FitnessLimit = 0
+	cfg.MaxGenerations = 5
+	cfg.Start, cfg.StartBackground = fitted.Shapes, fitted.Background
+	img, _, err := Run(gradient(32, 24), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Rect.Size() != image.Pt(32, 24) {
+		t.Errorf("got a %v picture from a rescaled genome, want 32x24", img.Rect.Size())
+	}
+}
@@ -0,0 +1,372 @@
+package triangles
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// Glyph represents a drawn character of a font, Size pixels to the em,
+// centered on Center and turned by Angle radians clockwise. Only fonts with
+// vector outlines can be drawn with, not color emoji fonts.
+type Glyph struct {
+	Font   string
+	Char   rune
+	Center Point
+	Size   int
+	Angle  float64
+	Color  color.Color
+}
+
+// the chance of a moved glyph turning into another character
+const glyphCharRate = 0.1
+
+// DefaultGlyphs are the characters glyphs are picked from unless they're
+// given, the printable ASCII characters but space
+var DefaultGlyphs = func() string {
+	var b strings.Builder
+	for c := '!'; c <= '~'; c++ {
+		b.WriteRune(c)
+	}
+	return b.String()
+}()
+
+// create a glyph of a random character of the font in opts, centered on p
+// and upright with an em of up to size
+func newGlyph(rng *rand.Rand, p Point, w int, h int, size int, opts shapeOptions, c color.Color) Shape {
+	g := Glyph{Font: opts.Font, Center: p, Size: rng.Intn(size) + 1, Color: c}
+	if f, err := loadFont(opts.Font); err == nil {
+		g.Char = f.random(rng)
+	}
+	return g
+}
+
+// Draw the glyph, nothing is drawn if its font can't be loaded
+func (g Glyph) Draw(img *image.RGBA) {
+	f, err := loadFont(g.Font)
+	if err != nil {
+		return
+	}
+	o := f.outline(g.Char)
+	fillContours(img, g.place(o.points), o.ends, g.Color)
+}
+
+// Mutate returns a new random glyph of the same font
+func (g Glyph) Mutate(rng *rand.Rand, w int, h int, size int) Shape {
+	return createShape(rng, "glyph", w, h, size, shapeOptions{Font: g.Font})
+}
+
+// Move the center of the glyph, change its size by up to d and turn it so
+// its edge moves by up to d. Once in a while it becomes another character
+// too.
+func (g Glyph) Move(rng *rand.Rand, w int, h int, d int) Shape {
+	g.Center = movePoint(rng, g.Center, w, h, d)
+	g.Size = max(1, g.Size+rng.Intn(2*d+1)-d)
+	g.Angle = spin(g.Angle, (rng.Float64()*2-1)*float64(d)/float64(g.Size))
+	if rng.Float64() < glyphCharRate {
+		if f, err := loadFont(g.Font); err == nil {
+			g.Char = f.random(rng)
+		}
+	}
+	return g
+}
+
+// Shade the glyph
+func (g Glyph) Shade(rng *rand.Rand, d int) Shape {
+	g.Color = shadeColor(rng, g.Color, d)
+	return g
+}
+
+// Fade the glyph
+func (g Glyph) Fade(rng *rand.Rand, d int, lo uint8, hi uint8) Shape {
+	g.Color = fadeColor(rng, g.Color, d, lo, hi)
+	return g
+}
+
+// Recolor the glyph
+func (g Glyph) Recolor(f func(color.Color) color.Color) Shape {
+	g.Color = f(g.Color)
+	return g
+}
+
+// Translate the glyph
+func (g Glyph) Translate(dx int, dy int, w int, h int) Shape {
+	g.Center = g.Center.translate(dx, dy, w, h)
+	return g
+}
+
+// Rotate the glyph about its center
+func (g Glyph) Rotate(angle float64, w int, h int) Shape {
+	g.Angle = spin(g.Angle, angle)
+	return g
+}
+
+// Resize the glyph
+func (g Glyph) Resize(f float64, w int, h int) Shape {
+	g.Size = max(1, int(math.Round(float64(g.Size)*f)))
+	return g
+}
+
+// Scale the glyph, its size is scaled by the smaller of sx and sy
+func (g Glyph) Scale(sx float64, sy float64) Shape {
+	g.Center = g.Center.scale(sx, sy)
+	g.Size = max(1, int(math.Round(float64(g.Size)*min(sx, sy))))
+	return g
+}
+
+// SVG returns the outline of the glyph as a path, so the font isn't needed
+// to show it
+func (g Glyph) SVG() string {
+	f, err := loadFont(g.Font)
+	if err != nil {
+		return ""
+	}
+	o := f.outline(g.Char)
+	points := g.place(o.points)
+	var path []byte
+	start := 0
+	for _, end := range o.ends {
+		for i, p := range points[start:end] {
+			op := 'L'
+			if i == 0 {
+				op = 'M'
+			}
+			path = fmt.Appendf(path, "%c%.1f,%.1f ", op, p[0], p[1])
+		}
+		path = append(path, 'Z')
+		start = end
+	}
+	return fmt.Sprintf(`<path d="%s" %s/>`, path, svgFill(g.Color))
+}
+
+// Bounds of the glyph, as far as its outline reaches however it's turned
+func (g Glyph) Bounds() image.Rectangle {
+	var reach float64
+	if f, err := loadFont(g.Font); err == nil {
+		reach = f.outline(g.Char).reach
+	}
+	r := int(math.Ceil(reach*float64(g.Size))) + 1
+	return image.Rect(g.Center.X-r, g.Center.Y-r, g.Center.X+r+1, g.Center.Y+r+1)
+}
+
+// the points of the glyph's outline where they're drawn on the picture
+func (g Glyph) place(outline [][2]float64) [][2]float64 {
+	sin, cos := math.Sincos(g.Angle)
+	s := float64(g.Size)
+	points := make([][2]float64, len(outline))
+	for i, p := range outline {
+		x, y := p[0]*s, p[1]*s
+		points[i] = [2]float64{float64(g.Center.X) + x*cos - y*sin, float64(g.Center.Y) + x*sin + y*cos}
+	}
+	return points
+}
+
+// turn the angle by by, kept in [0, 2π)
+func spin(angle float64, by float64) float64 {
+	angle = math.Mod(angle+by, 2*math.Pi)
+	if angle < 0 {
+		angle += 2 * math.Pi
+	}
+	return angle
+}
+
+// glyphFont is a font glyphs are drawn with, and the outlines of the
+// characters drawn with it so far by rune. Glyphs are drawn concurrently so
+// the outlines are in a sync.Map, which is quick to read.
+type glyphFont struct {
+	font *sfnt.Font
+	// mu guards chars, the characters new glyphs are picked from
+	mu       sync.Mutex
+	chars    []rune
+	outlines sync.Map
+}
+
+// glyphOutline is the outline of a character as contours of straight edges,
+// in ems from the middle of the character. The contours are the points up to
+// each end, and reach is how far the farthest point is from the middle.
+type glyphOutline struct {
+	points [][2]float64
+	ends   []int
+	reach  float64
+}
+
+const (
+	// the pixels per em outlines are loaded at before they're scaled down
+	// to ems, which only has to be large enough to be precise
+	glyphPPEM = 1024
+	// the number of straight pieces the curves of an outline are made of
+	glyphSegments = 4
+)
+
+// the fonts loaded so far by file, loading is locked so a font is only
+// loaded once
+var (
+	fonts   sync.Map
+	loading sync.Mutex
+)
+
+// the font in the file, loaded the first time it's needed. New glyphs are
+// picked from the characters of DefaultGlyphs it has until others are
+// picked with pick.
+func loadFont(filePath string) (*glyphFont, error) {
+	if f, ok := fonts.Load(filePath); ok {
+		return f.(*glyphFont), nil
+	}
+	loading.Lock()
+	defer loading.Unlock()
+	if f, ok := fonts.Load(filePath); ok {
+		return f.(*glyphFont), nil
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read font: %w", err)
+	}
+	font, err := sfnt.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse font: %w", err)
+	}
+	f := &glyphFont{font: font}
+	for _, r := range DefaultGlyphs {
+		if f.check(r) == nil {
+			f.chars = append(f.chars, r)
+		}
+	}
+	if len(f.chars) == 0 {
+		return nil, fmt.Errorf("the font has none of %q", DefaultGlyphs)
+	}
+	fonts.Store(filePath, f)
+	return f, nil
+}
+
+// pick the characters new glyphs are picked from, every one of them must be
+// in the font
+func (f *glyphFont) pick(chars string) error {
+	var runes []rune
+	for _, r := range chars {
+		err := f.check(r)
+		if err != nil {
+			return err
+		}
+		runes = append(runes, r)
+	}
+	if len(runes) == 0 {
+		return errors.New("there must be at least 1 character to draw")
+	}
+	f.mu.Lock()
+	f.chars = runes
+	f.mu.Unlock()
+	return nil
+}
+
+// a random character to draw
+func (f *glyphFont) random(rng *rand.Rand) rune {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.chars[rng.Intn(len(f.chars))]
+}
+
+// check the font has an outline for the character
+func (f *glyphFont) check(r rune) error {
+	var b sfnt.Buffer
+	i, err := f.font.GlyphIndex(&b, r)
+	if err != nil {
+		return fmt.Errorf("cannot find %q in the font: %w", r, err)
+	}
+	if i == 0 {
+		return fmt.Errorf("the font has no %q", r)
+	}
+	_, err = f.font.LoadGlyph(&b, i, fixed.I(glyphPPEM), nil)
+	if err != nil {
+		return fmt.Errorf("cannot load the outline of %q: %w", r, err)
+	}
+	return nil
+}
+
+// the outline of the character, nothing if the font doesn't have it
+func (f *glyphFont) outline(r rune) glyphOutline {
+	if o, ok := f.outlines.Load(r); ok {
+		return o.(glyphOutline)
+	}
+
+	var o glyphOutline
+	var b sfnt.Buffer
+	i, err := f.font.GlyphIndex(&b, r)
+	var segments sfnt.Segments
+	if err == nil && i != 0 {
+		segments, err = f.font.LoadGlyph(&b, i, fixed.I(glyphPPEM), nil)
+	}
+	if err == nil {
+		o = flatten(segments)
+	}
+
+	f.outlines.Store(r, o)
+	return o
+}
+
+// flatten the segments of an outline into contours of straight edges, moved
+// so the middle of the outline is at 0, 0 and scaled down to ems
+func flatten(segments sfnt.Segments) glyphOutline {
+	var o glyphOutline
+	point := func(p fixed.Point26_6) [2]float64 {
+		return [2]float64{float64(p.X) / 64 / glyphPPEM, float64(p.Y) / 64 / glyphPPEM}
+	}
+	var last [2]float64
+	for _, s := range segments {
+		switch s.Op {
+		case sfnt.SegmentOpMoveTo:
+			if len(o.points) > 0 {
+				o.ends = append(o.ends, len(o.points))
+			}
+			last = point(s.Args[0])
+			o.points = append(o.points, last)
+		case sfnt.SegmentOpLineTo:
+			last = point(s.Args[0])
+			o.points = append(o.points, last)
+		case sfnt.SegmentOpQuadTo:
+			c, end := point(s.Args[0]), point(s.Args[1])
+			for i := 1; i <= glyphSegments; i++ {
+				t := float64(i) / glyphSegments
+				a, b, d := (1-t)*(1-t), 2*(1-t)*t, t*t
+				o.points = append(o.points, [2]float64{a*last[0] + b*c[0] + d*end[0], a*last[1] + b*c[1] + d*end[1]})
+			}
+			last = end
+		case sfnt.SegmentOpCubeTo:
+			c1, c2, end := point(s.Args[0]), point(s.Args[1]), point(s.Args[2])
+			for i := 1; i <= glyphSegments; i++ {
+				t := float64(i) / glyphSegments
+				a, b, c, d := (1-t)*(1-t)*(1-t), 3*(1-t)*(1-t)*t, 3*(1-t)*t*t, t*t*t
+				o.points = append(o.points, [2]float64{
+					a*last[0] + b*c1[0] + c*c2[0] + d*end[0],
+					a*last[1] + b*c1[1] + c*c2[1] + d*end[1],
+				})
+			}
+			last = end
+		}
+	}
+	if len(o.points) == 0 {
+		return o
+	}
+	o.ends = append(o.ends, len(o.points))
+
+	lo, hi := o.points[0], o.points[0]
+	for _, p := range o.points {
+		lo = [2]float64{min(lo[0], p[0]), min(lo[1], p[1])}
+		hi = [2]float64{max(hi[0], p[0]), max(hi[1], p[1])}
+	}
+	cx, cy := (lo[0]+hi[0])/2, (lo[1]+hi[1])/2
+	for i := range o.points {
+		o.points[i][0] -= cx
+		o.points[i][1] -= cy
+		o.reach = max(o.reach, math.Hypot(o.points[i][0], o.points[i][1]))
+	}
+	return o
+}
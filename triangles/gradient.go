@@ -0,0 +1,135 @@
+package triangles
+
+import (
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"math/rand"
+)
+
+// Gradient represents a drawn triangle with a color at each corner, blended
+// smoothly across it so one of them can paint what would take many flat
+// triangles
+type Gradient struct {
+	P1 Point
+	P2 Point
+	P3 Point
+	C1 color.Color
+	C2 color.Color
+	C3 color.Color
+}
+
+// how far the colors of the other corners of a new gradient are from the
+// color it's made with
+const gradientShade = 64
+
+// create a gradient with p as its 1st point, all its points are inside a
+// w x h canvas and its corners have colors around c
+func newGradient(rng *rand.Rand, p Point, w int, h int, size int, opts shapeOptions, c color.Color) Shape {
+	t := newTriangle(rng, p, w, h, size, opts, c).(Triangle)
+	return Gradient{
+		P1: t.P1, P2: t.P2, P3: t.P3,
+		C1: c, C2: shadeColor(rng, c, gradientShade), C3: shadeColor(rng, c, gradientShade),
+	}
+}
+
+// Draw the gradient
+func (g Gradient) Draw(img *image.RGBA) {
+	fillGradient(img, g.P1, g.P2, g.P3, g.C1, g.C2, g.C3)
+}
+
+// Mutate returns a new random gradient
+func (g Gradient) Mutate(rng *rand.Rand, w int, h int, size int) Shape {
+	return createShape(rng, "gradient", w, h, size, shapeOptions{})
+}
+
+// Move the points of the gradient, the colors stay with them
+func (g Gradient) Move(rng *rand.Rand, w int, h int, d int) Shape {
+	return g.reshape(g.triangle().Move(rng, w, h, d))
+}
+
+// Shade the color of each corner of the gradient
+func (g Gradient) Shade(rng *rand.Rand, d int) Shape {
+	g.C1, g.C2, g.C3 = shadeColor(rng, g.C1, d), shadeColor(rng, g.C2, d), shadeColor(rng, g.C3, d)
+	return g
+}
+
+// Fade the color of each corner of the gradient
+func (g Gradient) Fade(rng *rand.Rand, d int, lo uint8, hi uint8) Shape {
+	g.C1, g.C2, g.C3 = fadeColor(rng, g.C1, d, lo, hi), fadeColor(rng, g.C2, d, lo, hi), fadeColor(rng, g.C3, d, lo, hi)
+	return g
+}
+
+// Recolor each corner of the gradient
+func (g Gradient) Recolor(f func(color.Color) color.Color) Shape {
+	g.C1, g.C2, g.C3 = f(g.C1), f(g.C2), f(g.C3)
+	return g
+}
+
+// Translate the gradient
+func (g Gradient) Translate(dx int, dy int, w int, h int) Shape {
+	return g.reshape(g.triangle().Translate(dx, dy, w, h))
+}
+
+// Rotate the gradient about its centroid
+func (g Gradient) Rotate(angle float64, w int, h int) Shape {
+	return g.reshape(g.triangle().Rotate(angle, w, h))
+}
+
+// Resize the gradient about its centroid
+func (g Gradient) Resize(f float64, w int, h int) Shape {
+	return g.reshape(g.triangle().Resize(f, w, h))
+}
+
+// Scale the gradient
+func (g Gradient) Scale(sx float64, sy float64) Shape {
+	return g.reshape(g.triangle().Scale(sx, sy))
+}
+
+// SVG returns the gradient as a polygon filled with a linear gradient from
+// the color of P1 to the colors of the other corners. SVG can't blend three
+// colors across a triangle, so the other corners get their average color,
+// which is only exact when they're the same.
+func (g Gradient) SVG() string {
+	// the gradient runs at right angles to the side opposite P1, from P1 to
+	// where it's nearest that side
+	ax, ay := float64(g.P1.X), float64(g.P1.Y)
+	bx, by := float64(g.P2.X), float64(g.P2.Y)
+	dx, dy := float64(g.P3.X)-bx, float64(g.P3.Y)-by
+	fx, fy := bx, by
+	if l := dx*dx + dy*dy; l > 0 {
+		t := ((ax-bx)*dx + (ay-by)*dy) / l
+		fx, fy = bx+t*dx, by+t*dy
+	}
+	r2, g2, b2, a2 := g.C2.RGBA()
+	r3, g3, b3, a3 := g.C3.RGBA()
+	average := color.RGBA64{uint16((r2 + r3) / 2), uint16((g2 + g3) / 2), uint16((b2 + b3) / 2), uint16((a2 + a3) / 2)}
+
+	// identical gradients get the same id, which is harmless as they'd be
+	// defined the same
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%v", g)
+	id := fmt.Sprintf("gradient%08x", h.Sum32())
+	return fmt.Sprintf(`<linearGradient id="%s" gradientUnits="userSpaceOnUse" x1="%d" y1="%d" x2="%.1f" y2="%.1f">%s%s</linearGradient>`+
+		`<polygon points="%d,%d %d,%d %d,%d" fill="url(#%s)"/>`,
+		id, g.P1.X, g.P1.Y, fx, fy, svgStop(0, g.C1), svgStop(1, average),
+		g.P1.X, g.P1.Y, g.P2.X, g.P2.Y, g.P3.X, g.P3.Y, id)
+}
+
+// Bounds of the gradient
+func (g Gradient) Bounds() image.Rectangle {
+	return g.triangle().Bounds()
+}
+
+// the triangle of the gradient's points
+func (g Gradient) triangle() Triangle {
+	return Triangle{P1: g.P1, P2: g.P2, P3: g.P3}
+}
+
+// the gradient with the points of the triangle
+func (g Gradient) reshape(t Shape) Shape {
+	tr := t.(Triangle)
+	g.P1, g.P2, g.P3 = tr.P1, tr.P2, tr.P3
+	return g
+}
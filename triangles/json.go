@@ -0,0 +1,162 @@
+package triangles
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"os"
+)
+
+// jsonGenome is how a genome is written as JSON
+type jsonGenome struct {
+	Width      int         `json:"width"`
+	Height     int         `json:"height"`
+	Background *jsonColor  `json:"background,omitempty"`
+	Shapes     []jsonShape `json:"shapes"`
+}
+
+// jsonShape is how a shape is written as JSON. The points are the corners of
+// a triangle, the vertices of a polygon, the center of a circle, an ellipse
+// or a glyph, the min and max corners of a rectangle, the top left corner of
+// a block, the start, control point and end of a stroke or a Voronoi site.
+// Angles are in radians. The color is premultiplied by its alpha like a
+// color.RGBA. A gradient has a color at each of its corners, the first one's
+// is the color and the others' are the colors.
+type jsonShape struct {
+	Kind   string      `json:"kind"`
+	Points []Point     `json:"points"`
+	R      int         `json:"r,omitempty"`
+	RX     int         `json:"rx,omitempty"`
+	RY     int         `json:"ry,omitempty"`
+	Angle  float64     `json:"angle,omitempty"`
+	Size   int         `json:"size,omitempty"`
+	Width  int         `json:"width,omitempty"`
+	Font   string      `json:"font,omitempty"`
+	Char   string      `json:"char,omitempty"`
+	Color  jsonColor   `json:"color"`
+	Colors []jsonColor `json:"colors,omitempty"`
+}
+
+// jsonColor is a color as JSON
+type jsonColor struct {
+	R uint8 `json:"r"`
+	G uint8 `json:"g"`
+	B uint8 `json:"b"`
+	A uint8 `json:"a"`
+}
+
+// convert the color to JSON
+func toJSONColor(c color.Color) jsonColor {
+	r, g, b, a := c.RGBA()
+	return jsonColor{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: uint8(a >> 8)}
+}
+
+// save the genome as JSON
+func saveJSONGenome(filePath string, g Genome) error {
+	jg := jsonGenome{Width: g.Width, Height: g.Height, Shapes: make([]jsonShape, len(g.Shapes))}
+	if g.Background.A > 0 {
+		background := toJSONColor(g.Background)
+		jg.Background = &background
+	}
+	for i, shape := range g.Shapes {
+		switch s := shape.(type) {
+		case Triangle:
+			jg.Shapes[i] = jsonShape{Kind: "triangle", Points: []Point{s.P1, s.P2, s.P3}, Color: toJSONColor(s.Color)}
+		case Gradient:
+			jg.Shapes[i] = jsonShape{Kind: "gradient", Points: []Point{s.P1, s.P2, s.P3}, Color: toJSONColor(s.C1), Colors: []jsonColor{toJSONColor(s.C2), toJSONColor(s.C3)}}
+		case Circle:
+			jg.Shapes[i] = jsonShape{Kind: "circle", Points: []Point{s.Center}, R: s.R, Color: toJSONColor(s.Color)}
+		case Ellipse:
+			jg.Shapes[i] = jsonShape{Kind: "ellipse", Points: []Point{s.Center}, RX: s.RX, RY: s.RY, Angle: s.Angle, Color: toJSONColor(s.Color)}
+		case Rectangle:
+			jg.Shapes[i] = jsonShape{Kind: "rectangle", Points: []Point{s.Min, s.Max}, Color: toJSONColor(s.Color)}
+		case Polygon:
+			jg.Shapes[i] = jsonShape{Kind: "polygon", Points: s.Points, Color: toJSONColor(s.Color)}
+		case Block:
+			jg.Shapes[i] = jsonShape{Kind: "block", Points: []Point{s.Min}, Size: s.Size, Color: toJSONColor(s.Color)}
+		case Glyph:
+			jg.Shapes[i] = jsonShape{Kind: "glyph", Points: []Point{s.Center}, Size: s.Size, Angle: s.Angle, Font: s.Font, Char: string(s.Char), Color: toJSONColor(s.Color)}
+		case Stroke:
+			jg.Shapes[i] = jsonShape{Kind: "stroke", Points: []Point{s.P1, s.C, s.P2}, Width: s.Width, Color: toJSONColor(s.Color)}
+		case Site:
+			jg.Shapes[i] = jsonShape{Kind: "site", Points: []Point{s.Center}, Color: toJSONColor(s.Color)}
+		default:
+			return fmt.Errorf("cannot write a %T as JSON", shape)
+		}
+	}
+
+	data, err := json.MarshalIndent(jg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot encode genome: %w", err)
+	}
+	return os.WriteFile(filePath, data, 0644)
+}
+
+// load the genome from JSON
+func loadJSONGenome(filePath string) (g Genome, err error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return g, fmt.Errorf("cannot read file: %w", err)
+	}
+	var jg jsonGenome
+	err = json.Unmarshal(data, &jg)
+	if err != nil {
+		return g, fmt.Errorf("cannot decode file: %w", err)
+	}
+
+	g = Genome{Width: jg.Width, Height: jg.Height, Shapes: make([]Shape, len(jg.Shapes))}
+	if b := jg.Background; b != nil {
+		g.Background = color.RGBA{b.R, b.G, b.B, b.A}
+	}
+	for i, s := range jg.Shapes {
+		c := color.RGBA{s.Color.R, s.Color.G, s.Color.B, s.Color.A}
+		if s.Kind == "polygon" {
+			if len(s.Points) < minVertices || len(s.Points) > maxVertices {
+				return g, fmt.Errorf("shape %d is a polygon so it must have %d to %d points", i, minVertices, maxVertices)
+			}
+			g.Shapes[i] = Polygon{Points: s.Points, Color: c}
+			continue
+		}
+		points := map[string]int{"triangle": 3, "gradient": 3, "circle": 1, "ellipse": 1, "rectangle": 2, "block": 1, "stroke": 3, "glyph": 1, "site": 1}[s.Kind]
+		if points == 0 {
+			return g, fmt.Errorf("shape %d is an unknown kind of shape %q", i, s.Kind)
+		}
+		if len(s.Points) != points {
+			return g, fmt.Errorf("shape %d is a %s so it must have %d points", i, s.Kind, points)
+		}
+		switch s.Kind {
+		case "triangle":
+			g.Shapes[i] = Triangle{P1: s.Points[0], P2: s.Points[1], P3: s.Points[2], Color: c}
+		case "gradient":
+			if len(s.Colors) != 2 {
+				return g, fmt.Errorf("shape %d is a gradient so it must have 2 more colors", i)
+			}
+			rgba := func(c jsonColor) color.RGBA {
+				return color.RGBA{c.R, c.G, c.B, c.A}
+			}
+			g.Shapes[i] = Gradient{P1: s.Points[0], P2: s.Points[1], P3: s.Points[2], C1: c, C2: rgba(s.Colors[0]), C3: rgba(s.Colors[1])}
+		case "circle":
+			g.Shapes[i] = Circle{Center: s.Points[0], R: s.R, Color: c}
+		case "ellipse":
+			g.Shapes[i] = Ellipse{Center: s.Points[0], RX: s.RX, RY: s.RY, Angle: s.Angle, Color: c}
+		case "rectangle":
+			g.Shapes[i] = Rectangle{Min: s.Points[0], Max: s.Points[1], Color: c}
+		case "block":
+			if s.Size < 1 {
+				return g, fmt.Errorf("shape %d is a block so its size must be at least 1", i)
+			}
+			g.Shapes[i] = Block{Min: s.Points[0], Size: s.Size, Color: c}
+		case "glyph":
+			chars := []rune(s.Char)
+			if len(chars) != 1 {
+				return g, fmt.Errorf("shape %d is a glyph so it must be of 1 character", i)
+			}
+			g.Shapes[i] = Glyph{Font: s.Font, Char: chars[0], Center: s.Points[0], Size: max(1, s.Size), Angle: s.Angle, Color: c}
+		case "stroke":
+			g.Shapes[i] = Stroke{P1: s.Points[0], C: s.Points[1], P2: s.Points[2], Width: max(1, s.Width), Color: c}
+		case "site":
+			g.Shapes[i] = Site{Center: s.Points[0], Color: c}
+		}
+	}
+	return g, nil
+}
@@ -0,0 +1,556 @@
+package triangles
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/maphash"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/sensorphalanx/ga"
+)
+
+// Organism represents an individual in the population
+type Organism struct {
+	DNA    *image.RGBA
+	Shapes []Shape
+	// Background is the color the shapes are drawn over
+	Background color.RGBA
+	// fitness is -1 until it's calculated
+	fitness int64
+	// scoring is how the fitness was calculated
+	scoring ga.Scoring
+	// sqErr is the sum of the squared differences of the bytes of DNA and
+	// the target, kept up to date through mutations once sqErrKnown
+	sqErr      int64
+	sqErrKnown bool
+	problem    *problem
+}
+
+// create an organism
+func createOrganism(p *problem, rng *rand.Rand) (organism *Organism) {
+	target, cfg := p.target, p.cfg
+	// randomly make shapes
+	shapes := make([]Shape, cfg.NumShapes)
+	for i := 0; i < cfg.NumShapes; i++ {
+		at := p.startPoint(rng)
+		if cfg.SeedFromTarget && rng.Intn(2) == 0 {
+			shapes[i] = createSeededShape(rng, cfg.Shape, target, at, cfg.ShapeSize, p.shapeOptions(), cfg.Jitter)
+		} else {
+			shapes[i] = createShapeAt(rng, cfg.Shape, at, target.Rect.Dx(), target.Rect.Dy(), cfg.ShapeSize, p.shapeOptions())
+		}
+		shapes[i] = p.fade(rng, shapes[i], 0)
+	}
+
+	// the background starts as the average color of the target when the
+	// shapes are seeded from it
+	var background color.RGBA
+	if cfg.SeedFromTarget {
+		background = averageColor(target)
+	} else {
+		background = color.RGBA{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), 255}
+	}
+	if p.palette != nil {
+		background = p.nearest(background).(color.RGBA)
+	}
+
+	organism = &Organism{
+		DNA:        draw(target.Rect.Dx(), target.Rect.Dy(), background, shapes),
+		Shapes:     shapes,
+		Background: background,
+		fitness:    -1,
+		problem:    p,
+	}
+	return
+}
+
+// Fitness of the Organism to the target, the lower the better
+func (d *Organism) Fitness() int64 {
+	fitness, _ := d.FitnessBelow(math.MaxInt64)
+	return fitness
+}
+
+// FitnessBelow is like Fitness but stops working out the fitness once it's
+// known to be above bound, returning false then. A fitness that isn't exact
+// isn't kept, so it's worked out again when it's asked for.
+func (d *Organism) FitnessBelow(bound int64) (int64, bool) {
+	run := d.problem.run
+	if d.fitness >= 0 && d.scoring == run.Scoring() {
+		return d.fitness, true
+	}
+	if !run.Incremental() {
+		fitness, exact := run.Score(d.DNA, bound, d.hash)
+		if !exact {
+			return fitness, false
+		}
+		d.SetFitness(fitness)
+		return fitness, true
+	}
+	if !d.sqErrKnown {
+		d.sqErr = ga.SquaredDiff(d.DNA, d.problem.target)
+		d.sqErrKnown = true
+	}
+	d.SetFitness(int64(math.Sqrt(float64(d.sqErr))))
+	return d.fitness, true
+}
+
+// Unscored returns the picture of the Organism if its fitness isn't known.
+// The error of a picture drawn as it was bred is already known with the
+// incremental fitness, so only its square root is left to work out here.
+func (d *Organism) Unscored() *image.RGBA {
+	if d.fitness >= 0 {
+		return nil
+	}
+	if d.sqErrKnown && d.problem.run.Incremental() {
+		d.Fitness()
+		return nil
+	}
+	return d.DNA
+}
+
+// SetFitness of the Organism, worked out with the current scoring
+func (d *Organism) SetFitness(fitness int64) {
+	d.fitness = fitness
+	d.scoring = d.problem.run.Scoring()
+}
+
+// Genome returns the genome of the organism
+func (d *Organism) Genome() Genome {
+	return Genome{Width: d.DNA.Rect.Dx(), Height: d.DNA.Rect.Dy(), Background: d.Background, Shapes: d.Shapes}
+}
+
+// hash of the organism's background and shapes
+func (d *Organism) hash(seed maphash.Seed) uint64 {
+	var h maphash.Hash
+	h.SetSeed(seed)
+	b := []byte{d.Background.R, d.Background.G, d.Background.B, d.Background.A}
+	h.Write(b)
+	for _, shape := range d.Shapes {
+		b = appendShape(b[:0], shape)
+		h.Write(b)
+	}
+	return h.Sum64()
+}
+
+// append what the shape looks like to b for hashing. Colors are appended as
+// their premultiplied values as that's what they're drawn with.
+func appendShape(b []byte, shape Shape) []byte {
+	points := func(points ...Point) {
+		for _, p := range points {
+			b = binary.LittleEndian.AppendUint32(b, uint32(p.X))
+			b = binary.LittleEndian.AppendUint32(b, uint32(p.Y))
+		}
+	}
+	fill := func(c color.Color) {
+		r, g, bl, a := c.RGBA()
+		b = binary.LittleEndian.AppendUint64(b, uint64(r)<<48|uint64(g)<<32|uint64(bl)<<16|uint64(a))
+	}
+	switch s := shape.(type) {
+	case Triangle:
+		b = append(b, 't')
+		points(s.P1, s.P2, s.P3)
+		fill(s.Color)
+	case Gradient:
+		b = append(b, 'd')
+		points(s.P1, s.P2, s.P3)
+		fill(s.C1)
+		fill(s.C2)
+		fill(s.C3)
+	case Circle:
+		b = append(b, 'c')
+		points(s.Center, Point{X: s.R})
+		fill(s.Color)
+	case Ellipse:
+		b = append(b, 'e')
+		points(s.Center, Point{X: s.RX, Y: s.RY})
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(s.Angle))
+		fill(s.Color)
+	case Rectangle:
+		b = append(b, 'r')
+		points(s.Min, s.Max)
+		fill(s.Color)
+	case Polygon:
+		b = append(b, 'p', byte(len(s.Points)))
+		points(s.Points...)
+		fill(s.Color)
+	case Block:
+		b = append(b, 'b')
+		points(s.Min, Point{X: s.Size})
+		fill(s.Color)
+	case Glyph:
+		b = append(b, 'g')
+		b = append(b, s.Font...)
+		points(s.Center, Point{X: s.Size, Y: int(s.Char)})
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(s.Angle))
+		fill(s.Color)
+	case Stroke:
+		b = append(b, 's')
+		points(s.P1, s.C, s.P2, Point{X: s.Width})
+		fill(s.Color)
+	case Site:
+		b = append(b, 'v')
+		points(s.Center)
+		fill(s.Color)
+	default:
+		b = fmt.Appendf(b, "%#v", shape)
+	}
+	return b
+}
+
+// Crossover the organism with another one
+func (d *Organism) Crossover(other ga.Genome, rng *rand.Rand) ga.Genome {
+	d1, d2 := d, other.(*Organism)
+	child := &Organism{
+		Shapes:     make([]Shape, len(d1.Shapes)),
+		Background: d1.Background,
+		fitness:    -1,
+		problem:    d1.problem,
+	}
+	if rng.Intn(2) == 0 {
+		child.Background = d2.Background
+	}
+	w, h := d1.DNA.Rect.Dx(), d1.DNA.Rect.Dy()
+	bl := blender{rng: rng, alpha: d1.problem.cfg.BlendAlpha, w: w, h: h}
+	if d1.problem.blend {
+		child.Background = bl.color(d1.Background, d2.Background).(color.RGBA)
+	}
+
+	if d1.problem.spatial {
+		child.Shapes = cutShapes(d1.Shapes, d2.Shapes, ga.NewCut(w, h, rng), d1.problem.cfg.MaxShapes)
+	} else {
+		// the shapes are drawn in the order they're in, so each parent's part
+		// keeps its shapes in the order they're drawn in. The organisms can have
+		// different numbers of shapes, the child has as many as d1, those d2
+		// doesn't have coming from d1.
+		n := min(len(d1.Shapes), len(d2.Shapes))
+		fromFirst := d1.problem.run.Crossover(n, rng)
+		for i := 0; i < len(d1.Shapes); i++ {
+			switch {
+			case i >= n:
+				child.Shapes[i] = d1.Shapes[i]
+			case d1.problem.blend:
+				// the parent picked gives what of the shape can't be blended
+				a, b := d1.Shapes[i], d2.Shapes[i]
+				if !fromFirst(i) {
+					a, b = b, a
+				}
+				child.Shapes[i] = d1.problem.fade(rng, bl.blend(a, b), 0)
+			case fromFirst(i):
+				child.Shapes[i] = d1.Shapes[i]
+			default:
+				child.Shapes[i] = d2.Shapes[i]
+			}
+		}
+	}
+	child.DNA = draw(w, h, child.Background, child.Shapes)
+	if d1.problem.run.Incremental() {
+		child.sqErr = ga.SquaredDiff(child.DNA, d1.problem.target)
+		child.sqErrKnown = true
+	}
+	return child
+}
+
+// the shapes of first on the first side of the cut and those of second on
+// the other, in the order they're drawn in. A child can have fewer or more
+// shapes than its parents, up to limit if it isn't 0, and has those of first
+// if none are on their sides.
+func cutShapes(first []Shape, second []Shape, cut func(x int, y int) bool, limit int) []Shape {
+	shapes := make([]Shape, 0, len(first))
+	for i := 0; i < len(first) || i < len(second); i++ {
+		if i < len(first) {
+			if p := shapeCenter(first[i]); cut(p.X, p.Y) {
+				shapes = append(shapes, first[i])
+			}
+		}
+		if i < len(second) {
+			if p := shapeCenter(second[i]); !cut(p.X, p.Y) {
+				shapes = append(shapes, second[i])
+			}
+		}
+	}
+	if len(shapes) == 0 {
+		return slices.Clone(first)
+	}
+	if limit > 0 && len(shapes) > limit {
+		shapes = shapes[:limit]
+	}
+	return shapes
+}
+
+// the point the shape is at, the middle of what it covers. A site covers
+// the whole picture, so it's at its center.
+func shapeCenter(shape Shape) Point {
+	if s, ok := shape.(Site); ok {
+		return s.Center
+	}
+	b := shape.Bounds()
+	return Point{X: (b.Min.X + b.Max.X) / 2, Y: (b.Min.Y + b.Max.Y) / 2}
+}
+
+// Mutate the organism
+func (d *Organism) Mutate(rng *rand.Rand) {
+	d.mutate(rng, d.problem.run.MutationRate())
+}
+
+// mutate the organism, a mutated shape is replaced by a new random one
+// once in a while and nudged into a slightly different place or color
+// otherwise
+func (d *Organism) mutate(rng *rand.Rand, rate float64) {
+	cfg := d.problem.cfg
+	w, h := d.DNA.Rect.Dx(), d.DNA.Rect.Dy()
+	// only where the mutated shapes were and are now has to be redrawn
+	var dirty image.Rectangle
+	// only the shapes that mutate are visited
+	for i := ga.Skip(rng, rate); i < len(d.Shapes); i += 1 + ga.Skip(rng, rate) {
+		shape, changed := d.Shapes[i], false
+		if rng.Float64() < cfg.Replace {
+			shape, changed = d.problem.fade(rng, shape.Mutate(rng, w, h, cfg.ShapeSize), 0), true
+		} else {
+			// the geometry and the color are nudged independently
+			if rng.Float64() < cfg.GeometryRate {
+				shape, changed = shape.Move(rng, w, h, cfg.Move), true
+			}
+			if rng.Float64() < cfg.ColorRate {
+				shape, changed = d.problem.shade(rng, shape), true
+			}
+			if rng.Float64() < cfg.AlphaRate {
+				shape, changed = d.problem.fade(rng, shape, cfg.Fade), true
+			}
+			if rng.Float64() < cfg.TransformRate {
+				shape, changed = transform(rng, shape, w, h, cfg.ShapeSize), true
+			}
+			if polygon, ok := shape.(Polygon); ok && rng.Float64() < cfg.VertexRate {
+				shape, changed = polygon.reshape(rng, w, h, cfg.Move, cfg.MaxVertices), true
+			}
+		}
+		if changed {
+			dirty = dirty.Union(d.Shapes[i].Bounds()).Union(shape.Bounds())
+			d.Shapes[i] = shape
+		}
+	}
+	// the background is under every pixel so changing it redraws them all
+	if rng.Float64() < cfg.BackgroundRate {
+		// a transparent background from an old genome becomes opaque
+		d.Background.A = 255
+		if d.problem.palette != nil {
+			d.Background = d.problem.anyColor(rng, d.Background).(color.RGBA)
+		} else {
+			d.Background = shadeColor(rng, d.Background, cfg.Shade).(color.RGBA)
+		}
+		dirty = d.DNA.Rect
+	}
+	// the order shapes are drawn in matters where they overlap, so it
+	// evolves too by swapping two shapes or moving one above or below the
+	// others. Only where the reordered shapes are can change.
+	if rng.Float64() < cfg.SwapRate && len(d.Shapes) > 1 {
+		i, j := rng.Intn(len(d.Shapes)), rng.Intn(len(d.Shapes))
+		d.Shapes[i], d.Shapes[j] = d.Shapes[j], d.Shapes[i]
+		dirty = dirty.Union(d.Shapes[i].Bounds()).Union(d.Shapes[j].Bounds())
+	}
+	if rng.Float64() < cfg.ShiftRate && len(d.Shapes) > 1 {
+		i, j := rng.Intn(len(d.Shapes)), rng.Intn(len(d.Shapes))
+		shape := d.Shapes[i]
+		d.Shapes = slices.Insert(slices.Delete(d.Shapes, i, i+1), j, shape)
+		dirty = dirty.Union(shape.Bounds())
+	}
+	// grown pictures get their new shapes on top, smaller the more shapes
+	// there are so they fill in the details
+	if n := d.problem.shapes - len(d.Shapes); n > 0 {
+		size := max(1, int(float64(cfg.ShapeSize)*math.Sqrt(float64(cfg.NumShapes)/float64(d.problem.shapes))))
+		for range n {
+			shape := d.problem.fade(rng, createShape(rng, cfg.Shape, w, h, size, d.problem.shapeOptions()), 0)
+			d.Shapes = append(d.Shapes, shape)
+			dirty = dirty.Union(shape.Bounds())
+		}
+	}
+	// shapes come and go so the number of them can evolve too
+	if rng.Float64() < cfg.AddRate && (cfg.MaxShapes == 0 || len(d.Shapes) < cfg.MaxShapes) {
+		shape := d.problem.fade(rng, createShape(rng, cfg.Shape, w, h, cfg.ShapeSize, d.problem.shapeOptions()), 0)
+		d.Shapes = slices.Insert(d.Shapes, rng.Intn(len(d.Shapes)+1), shape)
+		dirty = dirty.Union(shape.Bounds())
+	}
+	if rng.Float64() < cfg.RemoveRate && len(d.Shapes) > 1 {
+		i := rng.Intn(len(d.Shapes))
+		dirty = dirty.Union(d.Shapes[i].Bounds())
+		d.Shapes = slices.Delete(d.Shapes, i, i+1)
+	}
+	if !dirty.Empty() {
+		d.redraw(dirty)
+	}
+	d.fitness = -1
+}
+
+// a point to make a shape of the initial population around, near the edges
+// of the target more often than not with SeedEdges
+func (p *problem) startPoint(rng *rand.Rand) Point {
+	w, h := p.target.Rect.Dx(), p.target.Rect.Dy()
+	if p.edges == nil {
+		return Point{X: rng.Intn(w), Y: rng.Intn(h)}
+	}
+	i := sort.SearchFloat64s(p.edges, rng.Float64()*p.edges[len(p.edges)-1])
+	i = min(i, len(p.edges)-1)
+	return Point{X: i % w, Y: i / w}
+}
+
+// the options shapes are made with
+func (p *problem) shapeOptions() shapeOptions {
+	return shapeOptions{Vertices: p.cfg.Vertices, Font: p.cfg.Font}
+}
+
+// change the alpha of the shape by up to d each way, keeping it within the
+// range allowed. New shapes are faded by 0 to bring them into the range, and
+// into the palette if there's one.
+func (p *problem) fade(rng *rand.Rand, shape Shape, d int) Shape {
+	shape = shape.Fade(rng, d, uint8(p.cfg.MinAlpha), uint8(p.cfg.MaxAlpha))
+	if p.palette != nil {
+		// fading scales the channels, which can round them off the palette
+		shape = shape.Recolor(p.nearest)
+	}
+	return shape
+}
+
+// shade the shape by up to Shade, or give it other colors of the palette if
+// there's one
+func (p *problem) shade(rng *rand.Rand, shape Shape) Shape {
+	if p.palette == nil {
+		return shape.Shade(rng, p.cfg.Shade)
+	}
+	return shape.Recolor(func(c color.Color) color.Color {
+		return p.anyColor(rng, c)
+	})
+}
+
+// a random color of the palette with the alpha of c
+func (p *problem) anyColor(rng *rand.Rand, c color.Color) color.Color {
+	return withAlpha(p.palette[rng.Intn(len(p.palette))], c)
+}
+
+// the color of the palette nearest to c, with the alpha of c
+func (p *problem) nearest(c color.Color) color.Color {
+	r, g, b, a := c.RGBA()
+	straight := func(v uint32) int {
+		if a == 0 {
+			return 0
+		}
+		return int(min(v*0xffff/a, 0xffff) >> 8)
+	}
+	cr, cg, cb := straight(r), straight(g), straight(b)
+	best, bestDist := p.palette[0], -1
+	for _, pc := range p.palette {
+		dr, dg, db := int(pc.R)-cr, int(pc.G)-cg, int(pc.B)-cb
+		if d := dr*dr + dg*dg + db*db; bestDist < 0 || d < bestDist {
+			best, bestDist = pc, d
+		}
+	}
+	return withAlpha(best, c)
+}
+
+// the opaque color with the alpha of c, premultiplied by it
+func withAlpha(opaque color.RGBA, c color.Color) color.Color {
+	_, _, _, a := c.RGBA()
+	a >>= 8
+	scale := func(v uint8) uint8 {
+		return uint8(uint32(v) * a / 255)
+	}
+	return color.RGBA{scale(opaque.R), scale(opaque.G), scale(opaque.B), uint8(a)}
+}
+
+// move, turn or resize the shape as a whole, which keeps what it looks like
+// but lets it find a better place on the w x h canvas. It's moved by up to
+// size pixels, turned by up to an eighth of a turn or resized by up to a
+// third.
+func transform(rng *rand.Rand, shape Shape, w int, h int, size int) Shape {
+	switch rng.Intn(3) {
+	case 0:
+		return shape.Translate(rng.Intn(2*size+1)-size, rng.Intn(2*size+1)-size, w, h)
+	case 1:
+		return shape.Rotate((rng.Float64()*2-1)*math.Pi/4, w, h)
+	default:
+		return shape.Resize(math.Exp((rng.Float64()*2-1)*math.Log(4.0/3)), w, h)
+	}
+}
+
+// redraw the part of the organism's image inside r, and update its error
+// for only that part
+func (d *Organism) redraw(r image.Rectangle) {
+	r = r.Intersect(d.DNA.Rect)
+	target := d.problem.target
+	if d.sqErrKnown {
+		d.sqErr -= ga.SquaredDiffRect(d.DNA, target, r)
+	}
+	region := drawRegion(r, d.Background, d.Shapes)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		copy(d.DNA.Pix[d.DNA.PixOffset(r.Min.X, y):], region.Pix[region.PixOffset(r.Min.X, y):region.PixOffset(r.Max.X, y)])
+	}
+	recycleImage(&regionPool, region)
+	if d.sqErrKnown {
+		d.sqErr += ga.SquaredDiffRect(d.DNA, target, r)
+	}
+}
+
+// Recycle the organism's image once it's out of the population
+func (d *Organism) Recycle() {
+	recycleImage(&imagePool, d.DNA)
+	d.DNA = nil
+}
+
+// images are recycled instead of being left to the garbage collector, as
+// every child is drawn on a new image and every mutation on a new region.
+// Regions are kept apart as they're all sizes.
+var imagePool, regionPool sync.Pool
+
+// get a blank image with the bounds r from the pool, or a new one if there's
+// no image in the pool that's big enough
+func newImage(pool *sync.Pool, r image.Rectangle) *image.RGBA {
+	n := r.Dx() * r.Dy() * 4
+	if pix, ok := pool.Get().(*[]uint8); ok && cap(*pix) >= n {
+		img := &image.RGBA{Pix: (*pix)[:n], Stride: r.Dx() * 4, Rect: r}
+		clear(img.Pix)
+		return img
+	}
+	return image.NewRGBA(r)
+}
+
+// put the image back in the pool, it mustn't be used afterwards
+func recycleImage(pool *sync.Pool, img *image.RGBA) {
+	pix := img.Pix
+	pool.Put(&pix)
+}
+
+// draw the shapes over the background onto a w x h image. The cells of any
+// sites are under the other shapes.
+func draw(w int, h int, background color.RGBA, shapes []Shape) *image.RGBA {
+	dest := newImage(&imagePool, image.Rect(0, 0, w, h))
+	if background.A > 0 {
+		fillRect(dest, dest.Rect, background)
+	}
+	drawCells(dest, shapes)
+
+	for _, shape := range shapes {
+		shape.Draw(dest)
+	}
+
+	return dest
+}
+
+// draw the part of the shapes inside r, onto an image with r as its bounds
+func drawRegion(r image.Rectangle, background color.RGBA, shapes []Shape) *image.RGBA {
+	dest := newImage(&regionPool, r)
+	if background.A > 0 {
+		fillRect(dest, r, background)
+	}
+	drawCells(dest, shapes)
+
+	for _, shape := range shapes {
+		if shape.Bounds().Overlaps(r) {
+			shape.Draw(dest)
+		}
+	}
+
+	return dest
+}
@@ -0,0 +1,375 @@
+package triangles
+
+import (
+	"cmp"
+	"image"
+	"image/color"
+	"math"
+	"slices"
+	"sort"
+)
+
+// The shapes are filled with a scanline rasterizer: a pixel is covered when
+// its center is inside the shape, and covered pixels are blended with the
+// shape's color over what's already there. There's no antialiasing, which
+// doesn't matter at the sizes the shapes are evolved at, and every pixel only
+// depends on the shapes so any part of a picture can be redrawn on its own.
+
+// fill the triangle
+func fillTriangle(img *image.RGBA, p1 Point, p2 Point, p3 Point, c color.Color) {
+	src := premultiply(c)
+	triangleSpans(img, p1, p2, p3, func(y int, x0 int, x1 int) {
+		fillSpan(img, y, x0, x1, src)
+	})
+}
+
+// fill the triangle with the colors of its corners blended across it, so
+// each channel of the premultiplied color is a plane through the corners
+func fillGradient(img *image.RGBA, p1 Point, p2 Point, p3 Point, c1 color.Color, c2 color.Color, c3 color.Color) {
+	x1, y1 := float64(p1.X), float64(p1.Y)
+	x2, y2 := float64(p2.X), float64(p2.Y)
+	x3, y3 := float64(p3.X), float64(p3.Y)
+	det := (y2-y3)*(x1-x3) + (x3-x2)*(y1-y3)
+	if det == 0 {
+		return
+	}
+	// the planes are v = a*x + b*y + c for r, g, b and alpha
+	var planes [4][3]float64
+	s1, s2, s3 := premultiply(c1), premultiply(c2), premultiply(c3)
+	v1 := [4]float64{float64(s1.r), float64(s1.g), float64(s1.b), float64(s1.a)}
+	v2 := [4]float64{float64(s2.r), float64(s2.g), float64(s2.b), float64(s2.a)}
+	v3 := [4]float64{float64(s3.r), float64(s3.g), float64(s3.b), float64(s3.a)}
+	for i := range planes {
+		a := ((y2-y3)*(v1[i]-v3[i]) + (y3-y1)*(v2[i]-v3[i])) / det
+		b := ((x3-x2)*(v1[i]-v3[i]) + (x1-x3)*(v2[i]-v3[i])) / det
+		planes[i] = [3]float64{a, b, v3[i] - a*x3 - b*y3}
+	}
+	triangleSpans(img, p1, p2, p3, func(y int, x0 int, x1 int) {
+		x0, x1 = max(x0, img.Rect.Min.X), min(x1, img.Rect.Max.X)
+		cy := float64(y) + 0.5
+		// the part of each plane that's the same along the row
+		var row [4]float64
+		for i, p := range planes {
+			row[i] = p[1]*cy + p[2]
+		}
+		channel := func(i int, cx float64) uint32 {
+			return uint32(min(max(math.Round(planes[i][0]*cx+row[i]), 0), 0xffff))
+		}
+		for x := x0; x < x1; x++ {
+			cx := float64(x) + 0.5
+			i := img.PixOffset(x, y)
+			blend(img.Pix[i:i+4], rgba64{channel(0, cx), channel(1, cx), channel(2, cx), channel(3, cx)})
+		}
+	})
+}
+
+// call fill with the span of every row of the image the triangle covers,
+// from x0 up to x1
+func triangleSpans(img *image.RGBA, p1 Point, p2 Point, p3 Point, fill func(y int, x0 int, x1 int)) {
+	edges := [3][2]Point{{p1, p2}, {p2, p3}, {p3, p1}}
+	y0 := max(min(p1.Y, p2.Y, p3.Y), img.Rect.Min.Y)
+	y1 := min(max(p1.Y, p2.Y, p3.Y), img.Rect.Max.Y)
+	for y := y0; y < y1; y++ {
+		cy := float64(y) + 0.5
+		left, right := math.Inf(1), math.Inf(-1)
+		for _, e := range edges {
+			a, b := e[0], e[1]
+			// the edges the row crosses, half open so a vertex on the row
+			// is only counted once
+			if (float64(a.Y) <= cy) == (float64(b.Y) <= cy) {
+				continue
+			}
+			x := float64(a.X) + (cy-float64(a.Y))*float64(b.X-a.X)/float64(b.Y-a.Y)
+			left, right = min(left, x), max(right, x)
+		}
+		if left < right {
+			fill(y, centerAfter(left), centerAfter(right))
+		}
+	}
+}
+
+// fill the polygon, leaving out where it covers itself an even number of
+// times
+func fillPolygon(img *image.RGBA, points []Point, c color.Color) {
+	if len(points) < 3 {
+		return
+	}
+	src := premultiply(c)
+	y0, y1 := points[0].Y, points[0].Y
+	for _, p := range points[1:] {
+		y0, y1 = min(y0, p.Y), max(y1, p.Y)
+	}
+	y0, y1 = max(y0, img.Rect.Min.Y), min(y1, img.Rect.Max.Y)
+	xs := make([]float64, 0, len(points))
+	for y := y0; y < y1; y++ {
+		cy := float64(y) + 0.5
+		xs = xs[:0]
+		for i, a := range points {
+			b := points[(i+1)%len(points)]
+			// half open like the triangle's edges
+			if (float64(a.Y) <= cy) == (float64(b.Y) <= cy) {
+				continue
+			}
+			xs = append(xs, float64(a.X)+(cy-float64(a.Y))*float64(b.X-a.X)/float64(b.Y-a.Y))
+		}
+		sort.Float64s(xs)
+		for i := 0; i+1 < len(xs); i += 2 {
+			fillSpan(img, y, centerAfter(xs[i]), centerAfter(xs[i+1]), src)
+		}
+	}
+}
+
+// fill the inside of the contours, the points up to each end, where they
+// wind round a pixel other than zero times like the outlines of fonts expect
+func fillContours(img *image.RGBA, points [][2]float64, ends []int, c color.Color) {
+	if len(points) == 0 {
+		return
+	}
+	src := premultiply(c)
+	y0, y1 := points[0][1], points[0][1]
+	for _, p := range points[1:] {
+		y0, y1 = min(y0, p[1]), max(y1, p[1])
+	}
+	top := max(int(math.Floor(y0)), img.Rect.Min.Y)
+	bottom := min(int(math.Ceil(y1))+1, img.Rect.Max.Y)
+	type crossing struct {
+		x       float64
+		winding int
+	}
+	var crossings []crossing
+	for y := top; y < bottom; y++ {
+		cy := float64(y) + 0.5
+		crossings = crossings[:0]
+		start := 0
+		for _, end := range ends {
+			contour := points[start:end]
+			for i, a := range contour {
+				b := contour[(i+1)%len(contour)]
+				// half open like the triangle's edges
+				if (a[1] <= cy) == (b[1] <= cy) {
+					continue
+				}
+				winding := 1
+				if b[1] < a[1] {
+					winding = -1
+				}
+				crossings = append(crossings, crossing{a[0] + (cy-a[1])*(b[0]-a[0])/(b[1]-a[1]), winding})
+			}
+			start = end
+		}
+		slices.SortFunc(crossings, func(a, b crossing) int { return cmp.Compare(a.x, b.x) })
+		winding, from := 0, 0.0
+		for _, c := range crossings {
+			if winding == 0 {
+				from = c.x
+			}
+			winding += c.winding
+			if winding == 0 {
+				fillSpan(img, y, centerAfter(from), centerAfter(c.x), src)
+			}
+		}
+	}
+}
+
+// fill the circle
+func fillCircle(img *image.RGBA, center Point, r int, c color.Color) {
+	src := premultiply(c)
+	y0 := max(center.Y-r, img.Rect.Min.Y)
+	y1 := min(center.Y+r, img.Rect.Max.Y)
+	for y := y0; y < y1; y++ {
+		dy := float64(y) + 0.5 - float64(center.Y)
+		half := math.Sqrt(float64(r*r) - dy*dy)
+		x := float64(center.X)
+		fillSpan(img, y, centerAfter(x-half), centerAfter(x+half), src)
+	}
+}
+
+// fill the ellipse with radius rx along the direction angle radians from
+// the x axis and ry across it
+func fillEllipse(img *image.RGBA, center Point, rx int, ry int, angle float64, c color.Color) {
+	src := premultiply(c)
+	_, hy := ellipseExtent(rx, ry, angle)
+	sin, cos := math.Sincos(angle)
+	ia, ib := 1/float64(rx*rx), 1/float64(ry*ry)
+	// a point dx, dy from the center is inside when
+	// qa*dx^2 + qb*dx + qc <= 0, which is solved for dx on each row
+	qa := cos*cos*ia + sin*sin*ib
+	y0 := max(center.Y-hy, img.Rect.Min.Y)
+	y1 := min(center.Y+hy+1, img.Rect.Max.Y)
+	for y := y0; y < y1; y++ {
+		dy := float64(y) + 0.5 - float64(center.Y)
+		qb := 2 * dy * sin * cos * (ia - ib)
+		qc := dy*dy*(sin*sin*ia+cos*cos*ib) - 1
+		disc := qb*qb - 4*qa*qc
+		if disc < 0 {
+			continue
+		}
+		root := math.Sqrt(disc)
+		x := float64(center.X)
+		fillSpan(img, y, centerAfter(x+(-qb-root)/(2*qa)), centerAfter(x+(-qb+root)/(2*qa)), src)
+	}
+}
+
+// fill the pixels whose centers are within r of the line through the points.
+// What's within r of each straight piece of the line is a capsule, and as a
+// capsule is convex it covers a single span of each row, so the spans of the
+// pieces are worked out and merged row by row.
+func fillStroke(img *image.RGBA, points [][2]float64, r float64, c color.Color) {
+	src := premultiply(c)
+	var buf [strokeSegments]capsule
+	capsules := buf[:len(points)-1]
+	y0, y1 := math.Inf(1), math.Inf(-1)
+	for i := range capsules {
+		capsules[i] = newCapsule(points[i], points[i+1], r)
+		y0, y1 = min(y0, capsules[i].top), max(y1, capsules[i].bottom)
+	}
+	top := max(int(math.Floor(y0)), img.Rect.Min.Y)
+	bottom := min(int(math.Ceil(y1))+1, img.Rect.Max.Y)
+	var spanBuf [strokeSegments][2]int
+	spans := spanBuf[:0]
+	for y := top; y < bottom; y++ {
+		cy := float64(y) + 0.5
+		spans = spans[:0]
+		for i := range capsules {
+			lo, hi, ok := capsules[i].span(cy)
+			if !ok {
+				continue
+			}
+			// the pixels whose centers are in the span
+			x0, x1 := centerAfter(lo), int(math.Floor(hi-0.5))+1
+			if x0 < x1 {
+				spans = append(spans, [2]int{x0, x1})
+			}
+		}
+		slices.SortFunc(spans, func(a, b [2]int) int { return a[0] - b[0] })
+		// the pieces overlap where they join, and every pixel must only be
+		// blended once
+		for i := 0; i < len(spans); {
+			x0, x1 := spans[i][0], spans[i][1]
+			for i++; i < len(spans) && spans[i][0] <= x1; i++ {
+				x1 = max(x1, spans[i][1])
+			}
+			fillSpan(img, y, x0, x1, src)
+		}
+	}
+}
+
+// capsule is what's within r of the segment from a to b. Between its round
+// ends it's a band along the segment, and both how far along the segment a
+// point is and how far from it are k*x + m + n*y for the point x, y.
+type capsule struct {
+	a, b        [2]float64
+	r           float64
+	top, bottom float64
+	// how far along, from 0 at a to 1 at b
+	alongK, alongM, alongN float64
+	// how far from, either side
+	acrossK, acrossM, acrossN float64
+	band                      bool
+}
+
+// the capsule within r of the segment from a to b
+func newCapsule(a [2]float64, b [2]float64, r float64) capsule {
+	c := capsule{a: a, b: b, r: r, top: min(a[1], b[1]) - r, bottom: max(a[1], b[1]) + r}
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	if l := math.Hypot(dx, dy); l > 0 {
+		c.band = true
+		c.alongK, c.alongM, c.alongN = dx/(l*l), -(a[0]*dx+a[1]*dy)/(l*l), dy/(l*l)
+		c.acrossK, c.acrossM, c.acrossN = dy/l, (a[1]*dx-a[0]*dy)/l, -dx/l
+	}
+	return c
+}
+
+// the span of the row at y inside the capsule, false if there isn't one
+func (c *capsule) span(y float64) (float64, float64, bool) {
+	if y < c.top || y > c.bottom {
+		return 0, 0, false
+	}
+	lo, hi := math.Inf(1), math.Inf(-1)
+	// the round ends
+	for _, p := range [2][2]float64{c.a, c.b} {
+		if dy := y - p[1]; dy*dy <= c.r*c.r {
+			half := math.Sqrt(c.r*c.r - dy*dy)
+			lo, hi = min(lo, p[0]-half), max(hi, p[0]+half)
+		}
+	}
+	if c.band {
+		alongLo, alongHi, ok1 := linearRange(c.alongK, c.alongM+c.alongN*y, 0, 1)
+		acrossLo, acrossHi, ok2 := linearRange(c.acrossK, c.acrossM+c.acrossN*y, -c.r, c.r)
+		if ok1 && ok2 && max(alongLo, acrossLo) <= min(alongHi, acrossHi) {
+			lo, hi = min(lo, max(alongLo, acrossLo)), max(hi, min(alongHi, acrossHi))
+		}
+	}
+	return lo, hi, lo <= hi
+}
+
+// the range of x where lo <= k*x + m <= hi, false if there's none
+func linearRange(k float64, m float64, lo float64, hi float64) (float64, float64, bool) {
+	if k == 0 {
+		if m < lo || m > hi {
+			return 0, 0, false
+		}
+		return math.Inf(-1), math.Inf(1), true
+	}
+	x0, x1 := (lo-m)/k, (hi-m)/k
+	return min(x0, x1), max(x0, x1), true
+}
+
+// fill the rectangle
+func fillRect(img *image.RGBA, r image.Rectangle, c color.Color) {
+	src := premultiply(c)
+	r = r.Intersect(img.Rect)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		fillSpan(img, y, r.Min.X, r.Max.X, src)
+	}
+}
+
+// the first pixel whose center is at or after x
+func centerAfter(x float64) int {
+	return int(math.Ceil(x - 0.5))
+}
+
+// premultiplied 16 bit color
+type rgba64 struct {
+	r, g, b, a uint32
+}
+
+func premultiply(c color.Color) rgba64 {
+	r, g, b, a := c.RGBA()
+	return rgba64{r, g, b, a}
+}
+
+// blend the color over the pixels of row y from x0 up to x1
+func fillSpan(img *image.RGBA, y int, x0 int, x1 int, src rgba64) {
+	x0, x1 = max(x0, img.Rect.Min.X), min(x1, img.Rect.Max.X)
+	if x0 >= x1 {
+		return
+	}
+	const m = 0xffff
+	pix := img.Pix[img.PixOffset(x0, y):img.PixOffset(x1, y)]
+	if src.a == m {
+		// nothing of the color underneath is left, which is what blocks
+		// mostly are once they've evolved
+		c := [4]uint8{uint8(min(src.r>>8, 0xff)), uint8(min(src.g>>8, 0xff)), uint8(min(src.b>>8, 0xff)), 0xff}
+		for i := 0; i < len(pix); i += 4 {
+			copy(pix[i:i+4], c[:])
+		}
+		return
+	}
+	blend(pix, src)
+}
+
+// blend the color over the pixels
+func blend(pix []uint8, src rgba64) {
+	const m = 0xffff
+	// what's left of the color underneath
+	a := m - src.a
+	for i := 0; i < len(pix); i += 4 {
+		// colors with a channel above their alpha aren't really
+		// premultiplied, so the sum can go past white
+		pix[i] = uint8(min((uint32(pix[i])*0x101*a/m+src.r)>>8, 0xff))
+		pix[i+1] = uint8(min((uint32(pix[i+1])*0x101*a/m+src.g)>>8, 0xff))
+		pix[i+2] = uint8(min((uint32(pix[i+2])*0x101*a/m+src.b)>>8, 0xff))
+		pix[i+3] = uint8(min((uint32(pix[i+3])*0x101*a/m+src.a)>>8, 0xff))
+	}
+}
@@ -0,0 +1,49 @@
+package triangles
+
+import (
+	"image"
+	"math/rand"
+	"testing"
+
+	"github.com/llgcode/draw2d/draw2dimg"
+)
+
+// the triangles both benchmarks draw, 100 random ones on a 200x200 canvas
+func benchmarkTriangles() []Triangle {
+	rng := rand.New(rand.NewSource(1))
+	triangles := make([]Triangle, 100)
+	for i := range triangles {
+		triangles[i] = createShape(rng, "triangle", 200, 200, 40, shapeOptions{}).(Triangle)
+	}
+	return triangles
+}
+
+func BenchmarkRaster(b *testing.B) {
+	triangles := benchmarkTriangles()
+	img := image.NewRGBA(image.Rect(0, 0, 200, 200))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, t := range triangles {
+			t.Draw(img)
+		}
+	}
+}
+
+// the triangles were filled as paths with draw2d before the rasterizer
+func BenchmarkDraw2D(b *testing.B) {
+	triangles := benchmarkTriangles()
+	img := image.NewRGBA(image.Rect(0, 0, 200, 200))
+	gc := draw2dimg.NewGraphicContext(img)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, t := range triangles {
+			gc.SetFillColor(t.Color)
+			gc.SetStrokeColor(t.Color)
+			gc.MoveTo(float64(t.P1.X), float64(t.P1.Y))
+			gc.LineTo(float64(t.P2.X), float64(t.P2.Y))
+			gc.LineTo(float64(t.P3.X), float64(t.P3.Y))
+			gc.Close()
+			gc.Fill()
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package triangles
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"image"
+	"runtime"
+	"sync"
+
+	"github.com/sensorphalanx/ga"
+)
+
+// remoteProblem is what a worker is sent to score organisms with, the
+// target they're compared with and how
+type remoteProblem struct {
+	Width   int
+	Height  int
+	Pix     []uint8
+	Fitness string
+	Weights []float64
+}
+
+// the problem as it's sent to the workers
+func (p *problem) remote() ([]byte, error) {
+	var b bytes.Buffer
+	err := gob.NewEncoder(&b).Encode(remoteProblem{
+		Width:   p.target.Rect.Dx(),
+		Height:  p.target.Rect.Dy(),
+		Pix:     ga.ToRGBA(p.target).Pix,
+		Fitness: p.cfg.Fitness,
+		Weights: p.cfg.Weights,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode problem: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// score the genomes that haven't got a fitness on the workers or the queue,
+// those that can't be scored there work out their own fitness as they would
+// without them
+func (p *problem) evaluateRemote(ctx context.Context, remote ga.Remote, genomes []ga.Genome) {
+	var organisms []*Organism
+	var candidates [][]byte
+	for _, g := range genomes {
+		o := g.(*Organism)
+		if o.Unscored() == nil {
+			continue
+		}
+		var b bytes.Buffer
+		if gob.NewEncoder(&b).Encode(o.Genome()) != nil {
+			continue
+		}
+		organisms = append(organisms, o)
+		candidates = append(candidates, b.Bytes())
+	}
+	if len(candidates) == 0 {
+		return
+	}
+	scores := make([]int64, len(candidates))
+	err := remote.Score(ctx, candidates, scores)
+	if err != nil {
+		if p.cfg.RemoteError != nil {
+			p.remoteFailed.Do(func() {
+				p.cfg.RemoteError(err)
+			})
+		}
+		return
+	}
+	for i, o := range organisms {
+		o.SetFitness(scores[i])
+	}
+}
+
+// genomeEvaluator draws the genomes a master sends and scores the pictures
+// on the CPU
+type genomeEvaluator struct {
+	width   int
+	height  int
+	backend ga.Backend
+}
+
+// NewEvaluator makes the evaluator of a problem sent by a master
+func NewEvaluator(problem []byte) (ga.Evaluator, error) {
+	var rp remoteProblem
+	err := gob.NewDecoder(bytes.NewReader(problem)).Decode(&rp)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode problem: %w", err)
+	}
+	if rp.Width <= 0 || rp.Height <= 0 || len(rp.Pix) != rp.Width*rp.Height*4 {
+		return nil, errors.New("the target doesn't have as many pixels as its size")
+	}
+	target := &image.RGBA{Pix: rp.Pix, Stride: rp.Width * 4, Rect: image.Rect(0, 0, rp.Width, rp.Height)}
+	fitness, err := ga.NewFitness(rp.Fitness)
+	if err != nil {
+		return nil, err
+	}
+	if rp.Weights != nil {
+		weighted, ok := fitness.(ga.WeightedFitness)
+		if !ok {
+			return nil, fmt.Errorf("the %s fitness cannot be weighted", rp.Fitness)
+		}
+		fitness = weighted.Weighted(rp.Weights)
+	}
+	backend, _, err := ga.NewBackend("cpu", target, fitness)
+	if err != nil {
+		return nil, err
+	}
+	return genomeEvaluator{width: rp.Width, height: rp.Height, backend: backend}, nil
+}
+
+// Score draws the genomes with a worker for every CPU and scores them
+func (e genomeEvaluator) Score(candidates [][]byte, scores []int64) error {
+	images := make([]*image.RGBA, len(candidates))
+	errs := make([]error, len(candidates))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				var g Genome
+				errs[i] = gob.NewDecoder(bytes.NewReader(candidates[i])).Decode(&g)
+				if errs[i] == nil && (g.Width != e.width || g.Height != e.height) {
+					errs[i] = fmt.Errorf("genome is %dx%d, the target is %dx%d", g.Width, g.Height, e.width, e.height)
+				}
+				if errs[i] == nil {
+					images[i] = g.Draw()
+				}
+			}
+		}()
+	}
+	for i := range candidates {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	err := errors.Join(errs...)
+	if err != nil {
+		return err
+	}
+	return e.backend.Score(images, scores)
+}
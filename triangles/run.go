@@ -0,0 +1,476 @@
+package triangles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math/rand"
+	"slices"
+	"sync"
+
+	"github.com/sensorphalanx/ga"
+)
+
+// Config holds the parameters of a run
+type Config struct {
+	ga.RunConfig
+	// Shape is the kind of shape to draw with, one of shapeKinds or mix
+	Shape string
+	// NumShapes is the number of shapes to draw in each picture at the
+	// start, AddRate and RemoveRate let it change from there
+	NumShapes int
+	// BackgroundRate is the chance of the background color of a picture
+	// changing by up to Shade when it's mutated
+	BackgroundRate float64
+	// SwapRate is the chance of two shapes of a picture swapping places
+	// when it's mutated. Shapes are drawn in order so later ones cover
+	// earlier ones, and the order evolves like the shapes do.
+	SwapRate float64
+	// ShiftRate is the chance of a shape of a picture moving to another
+	// place in the order when it's mutated
+	ShiftRate float64
+	// AddRate is the chance of a new random shape being added to a picture
+	// when it's mutated, up to MaxShapes shapes
+	AddRate float64
+	// RemoveRate is the chance of a shape being removed from a picture when
+	// it's mutated, down to 1 shape
+	RemoveRate float64
+	// MaxShapes is the max number of shapes in a picture, 0 means no limit
+	MaxShapes int
+	// Grow is the number of generations the best fitness can go without
+	// improving before every picture gets GrowBy more shapes, up to
+	// MaxShapes. 0 never adds them. The shapes added are smaller the more
+	// there are, so a run can start with a few big shapes and fill in the
+	// details with more and more small ones.
+	Grow int
+	// GrowBy is the number of shapes added to every picture with Grow
+	GrowBy int
+	// ShapeSize is the max span of a shape, a triangle's 2nd and 3rd points
+	// are placed within ShapeSize/2 of the 1st point
+	ShapeSize int
+	// Vertices is the number of vertices polygons start with, from 3 to 8
+	Vertices int
+	// MaxVertices is the most vertices a polygon can grow to, from Vertices
+	// to 8
+	MaxVertices int
+	// VertexRate is the chance of a nudged polygon gaining or losing a
+	// vertex, keeping between 3 and MaxVertices of them
+	VertexRate float64
+	// Replace is the chance of a mutated shape being replaced with a new
+	// random one. Otherwise it's nudged: its points are moved by up to Move
+	// pixels with a chance of GeometryRate, and its color channels changed
+	// by up to Shade with a chance of ColorRate.
+	Replace float64
+	// GeometryRate is the chance of the points of a nudged shape moving
+	GeometryRate float64
+	// ColorRate is the chance of the color of a nudged shape changing, alpha
+	// aside
+	ColorRate float64
+	// AlphaRate is the chance of the alpha of a nudged shape changing by up
+	// to Fade, the color staying the same
+	AlphaRate float64
+	// Fade is the max amount the alpha of a nudged shape changes by
+	Fade int
+	// MinAlpha and MaxAlpha are the range from 0 to 255 the alpha of every
+	// shape is kept within
+	MinAlpha int
+	MaxAlpha int
+	// TransformRate is the chance of a nudged shape also being moved by up
+	// to ShapeSize pixels, turned about its center or resized as a whole
+	TransformRate float64
+	// Move is the max number of pixels each point of a nudged shape moves
+	Move int
+	// Shade is the max amount each color channel of a nudged shape changes
+	Shade int
+	// SeedFromTarget colors about half of the shapes in the initial
+	// population with the colors of the target under them instead of random
+	// colors
+	SeedFromTarget bool
+	// Jitter is the max amount each color channel of a seeded shape is moved
+	// away from the target's color
+	Jitter int
+	// SeedEdges makes the shapes of the initial population more likely to be
+	// near the edges of the target than in its flat regions, where the
+	// details that need many small shapes are
+	SeedEdges bool
+	// Palette is the number of colors of a palette picked from the target
+	// that shapes and the background are colored with, alpha aside. 0 lets
+	// them have any color.
+	Palette int
+	// Font is the file of the TrueType or OpenType font glyphs are drawn
+	// with
+	Font string
+	// Glyphs are the characters glyphs are picked from, empty picks from
+	// the printable ASCII characters the font has
+	Glyphs string
+	// Workers are the addresses of workers started with the worker command
+	// the children of every generation are drawn and scored on, split
+	// between them. Empty scores them here, and so does the diff fitness
+	// when it can be worked out as the children are bred.
+	Workers []string
+	// Queue is the URL of a NATS server the children of every generation
+	// are pushed onto in batches, for any number of workers pulling from it
+	// to draw and score. Empty scores them here.
+	Queue string
+	// RemoteError is called with the error the first time the workers or
+	// the queue can't score a generation, whose children are then scored
+	// here. It can be nil.
+	RemoteError func(err error)
+	// StartBackground is the background color to start evolving from with
+	// Start
+	StartBackground color.RGBA
+	// Start holds the shapes to start evolving from instead of random ones,
+	// the rest of the initial population are mutated copies of them
+	Start []Shape
+	// Resume continues the run saved in the checkpoint instead of starting
+	// from a new population, PopSize must be the size of its population
+	Resume *Checkpoint
+	// Progress is called after every generation with the stats so far and
+	// the best organism, it can be nil
+	Progress func(stats ga.RunStats, best *Organism)
+	// Improved is called with the generation and the organism whenever a
+	// generation has an organism fitter than any found before, it can be
+	// nil
+	Improved func(generation int, best *Organism)
+	// Checkpoint is called after every generation with what's needed to
+	// continue the run from there, it can be nil
+	Checkpoint func(c Checkpoint)
+}
+
+// DefaultConfig returns the parameters the monalisa_triangles demo is tuned
+// with
+func DefaultConfig() Config {
+	return Config{
+		RunConfig: ga.RunConfig{
+			MutationRate:        0.021,
+			PopSize:             100,
+			PoolSize:            20,
+			Selection:           "pool",
+			Crossover:           "one-point",
+			Strategy:            "generational",
+			Temperature:         10,
+			Cooling:             "exponential",
+			CoolingRate:         0.999,
+			BlendAlpha:          0.5,
+			TournamentSize:      3,
+			FitnessLimit:        7500,
+			Fitness:             "diff",
+			SampleRate:          1,
+			PyramidStep:         0.1,
+			Restart:             0.5,
+			MigrationInterval:   50,
+			Topology:            "ring",
+			Migrants:            2,
+			MutationStart:       10,
+			MutationGenerations: 1000,
+			HypermutationFactor: 10,
+			HypermutationBurst:  50,
+		},
+		Shape:          "triangle",
+		NumShapes:      150,
+		ShapeSize:      30,
+		Vertices:       5,
+		MaxVertices:    8,
+		VertexRate:     0.05,
+		Replace:        0.1,
+		GeometryRate:   1,
+		ColorRate:      1,
+		AlphaRate:      1,
+		TransformRate:  0.05,
+		GrowBy:         10,
+		SwapRate:       0.05,
+		BackgroundRate: 0.05,
+		ShiftRate:      0.05,
+		Move:           5,
+		Shade:          20,
+		Fade:           20,
+		MaxAlpha:       255,
+		Jitter:         50,
+	}
+}
+
+// how many times more likely shapes are to start on the strongest edges of
+// the target than in its flat regions with SeedEdges
+const seedEdgeStrength = 10
+
+// problem is the target every organism of a run is evolved towards and the
+// parameters it's evolved with
+type problem struct {
+	target *image.RGBA
+	cfg    Config
+	// run is how the organisms are scored and mutated
+	run *ga.ImageRun
+	// blend is whether children are blended from their parents rather than
+	// copied from them
+	blend bool
+	// spatial is whether children take what's on each side of a cut across
+	// the picture from each parent
+	spatial bool
+	// palette holds the colors shapes are colored with, it's nil when they
+	// can have any color
+	palette []color.RGBA
+	// edges holds the running total of the edge weights of the target's
+	// pixels, which the initial shapes are placed by with SeedEdges
+	edges []float64
+
+	// shapes is the number of shapes pictures are grown to when Grow is
+	// set, and growth tells when to grow them
+	shapes int
+	growth *ga.Plateau
+
+	// remoteFailed tells RemoteError only once
+	remoteFailed sync.Once
+}
+
+// check that the parameters of the organisms can be used to evolve the
+// target, NewImageRun checks the rest
+func (cfg Config) validate(target *image.RGBA) error {
+	if _, ok := shapeMakers[cfg.Shape]; !ok && cfg.Shape != MixedShapes {
+		return fmt.Errorf("unknown shape %q", cfg.Shape)
+	}
+	if cfg.Shape == "glyph" && cfg.Font == "" {
+		return errors.New("drawing glyphs needs a font")
+	}
+	if cfg.NumShapes < 1 {
+		return errors.New("there must be at least 1 shape")
+	}
+	if cfg.BackgroundRate < 0 || cfg.BackgroundRate > 1 {
+		return errors.New("background rate must be between 0 and 1")
+	}
+	if cfg.SwapRate < 0 || cfg.SwapRate > 1 || cfg.ShiftRate < 0 || cfg.ShiftRate > 1 {
+		return errors.New("swap and shift rates must be between 0 and 1")
+	}
+	if cfg.AddRate < 0 || cfg.AddRate > 1 || cfg.RemoveRate < 0 || cfg.RemoveRate > 1 {
+		return errors.New("add and remove rates must be between 0 and 1")
+	}
+	if cfg.MaxShapes < 0 {
+		return errors.New("max shapes cannot be negative")
+	}
+	if cfg.MaxShapes > 0 && cfg.MaxShapes < cfg.NumShapes {
+		return fmt.Errorf("max shapes must be at least the %d shapes to start with", cfg.NumShapes)
+	}
+	if cfg.Grow < 0 {
+		return errors.New("grow cannot be negative")
+	}
+	if cfg.Grow > 0 && cfg.GrowBy < 1 {
+		return errors.New("pictures must grow by at least 1 shape")
+	}
+	if cfg.ShapeSize < 1 {
+		return errors.New("shape size must be at least 1")
+	}
+	if cfg.Vertices < minVertices || cfg.MaxVertices < cfg.Vertices || cfg.MaxVertices > maxVertices {
+		return fmt.Errorf("polygons must have from %d to %d vertices, and can grow to no more than %d", minVertices, maxVertices, maxVertices)
+	}
+	if cfg.VertexRate < 0 || cfg.VertexRate > 1 {
+		return errors.New("vertex rate must be between 0 and 1")
+	}
+	if cfg.Replace < 0 || cfg.Replace > 1 {
+		return errors.New("replace chance must be between 0 and 1")
+	}
+	if cfg.GeometryRate < 0 || cfg.GeometryRate > 1 || cfg.ColorRate < 0 || cfg.ColorRate > 1 {
+		return errors.New("geometry and color rates must be between 0 and 1")
+	}
+	if cfg.TransformRate < 0 || cfg.TransformRate > 1 {
+		return errors.New("transform rate must be between 0 and 1")
+	}
+	if cfg.AlphaRate < 0 || cfg.AlphaRate > 1 {
+		return errors.New("alpha rate must be between 0 and 1")
+	}
+	if cfg.Move < 0 || cfg.Shade < 0 || cfg.Fade < 0 {
+		return errors.New("move, shade and fade cannot be negative")
+	}
+	if cfg.MinAlpha < 0 || cfg.MaxAlpha > 255 || cfg.MinAlpha > cfg.MaxAlpha {
+		return errors.New("the alpha range must be within 0 to 255")
+	}
+	if cfg.Jitter < 0 {
+		return errors.New("jitter cannot be negative")
+	}
+	if cfg.Palette < 0 || cfg.Palette > 256 {
+		return errors.New("the palette must have from 0 to 256 colors")
+	}
+	remotes := 0
+	for _, set := range []bool{cfg.Backend != "", len(cfg.Workers) > 0, cfg.Queue != ""} {
+		if set {
+			remotes++
+		}
+	}
+	if remotes > 1 {
+		return errors.New("children are scored on only one of a backend, workers or a queue")
+	}
+	if (len(cfg.Workers) > 0 || cfg.Queue != "") && (cfg.SampleRate > 1 || cfg.Pyramid > 0) {
+		return errors.New("workers don't work with sampling or the pyramid")
+	}
+	if cfg.Resume != nil {
+		// every genome is checked, a checkpoint that's been tampered with
+		// or cut short could have any of them wrong
+		genomes := cfg.Resume.Population
+		if cfg.Resume.Best != nil {
+			genomes = append(slices.Clip(genomes), *cfg.Resume.Best)
+		}
+		for i, g := range genomes {
+			if !g.valid() {
+				return fmt.Errorf("genome %d of the checkpoint has no shapes to draw a %dx%d image with", i, g.Width, g.Height)
+			}
+			if g.Width != target.Rect.Dx() || g.Height != target.Rect.Dy() {
+				return fmt.Errorf("the checkpoint is for a %dx%d target but the target is %dx%d",
+					g.Width, g.Height, target.Rect.Dx(), target.Rect.Dy())
+			}
+		}
+	}
+	return nil
+}
+
+// Run evolves a picture of shapes that looks like the target and returns
+// the best image found. It doesn't print or save anything, use cfg.Progress for that.
+func Run(target *image.RGBA, cfg Config) (*image.RGBA, ga.Stats, error) {
+	best, stats, err := Evolve(context.Background(), target, cfg)
+	if err != nil {
+		return nil, stats, err
+	}
+	return best.DNA, stats, nil
+}
+
+// Evolve is like Run but also stops when the context is done, in which case
+// the best organism found so far is returned.
+func Evolve(ctx context.Context, target *image.RGBA, cfg Config) (*Organism, ga.Stats, error) {
+	err := cfg.validate(target)
+	if err != nil {
+		return nil, ga.Stats{}, err
+	}
+	run, err := ga.NewImageRun(target, cfg.RunConfig)
+	if err != nil {
+		return nil, ga.Stats{}, err
+	}
+	defer run.Close()
+
+	if cfg.Font != "" {
+		font, err := loadFont(cfg.Font)
+		if err != nil {
+			return nil, ga.Stats{}, err
+		}
+		if cfg.Glyphs != "" {
+			err = font.pick(cfg.Glyphs)
+			if err != nil {
+				return nil, ga.Stats{}, err
+			}
+		}
+	}
+
+	p := &problem{target: target, cfg: cfg, run: run, blend: cfg.Crossover == "blend", spatial: cfg.Crossover == "spatial"}
+	if cfg.Palette > 0 {
+		p.palette = ga.Palette(target, cfg.Palette)
+	}
+	if cfg.SeedEdges {
+		p.edges = ga.EdgeWeights(target, seedEdgeStrength)
+		for i := 1; i < len(p.edges); i++ {
+			p.edges[i] += p.edges[i-1]
+		}
+	}
+	gaCfg := run.Config(func(stats ga.RunStats, best ga.Genome) {
+		// more shapes for every organism bred from now on
+		if p.growth != nil && p.growth.Reached(stats.Stats) {
+			p.shapes += cfg.GrowBy
+			if cfg.MaxShapes > 0 {
+				p.shapes = min(p.shapes, cfg.MaxShapes)
+			}
+		}
+		if cfg.Progress != nil {
+			cfg.Progress(stats, best.(*Organism))
+		}
+	})
+	gaCfg.NewGenome = func(rng *rand.Rand) ga.Genome {
+		return createOrganism(p, rng)
+	}
+	if cfg.Checkpoint != nil {
+		gaCfg.Checkpoint = func(state ga.State) {
+			cfg.Checkpoint(ga.NewCheckpoint(run, state, func(g ga.Genome) Genome {
+				return g.(*Organism).Genome()
+			}))
+		}
+	}
+	if cfg.Improved != nil {
+		gaCfg.Improved = func(generation int, best ga.Genome) {
+			cfg.Improved(generation, best.(*Organism))
+		}
+	}
+	if len(cfg.Workers) > 0 || cfg.Queue != "" {
+		problem, err := p.remote()
+		if err != nil {
+			return nil, ga.Stats{}, err
+		}
+		var remote ga.Remote
+		if cfg.Queue != "" {
+			remote, err = ga.NewQueue(cfg.Queue, problem)
+		} else {
+			remote, err = ga.DialWorkers(ctx, cfg.Workers, problem)
+		}
+		if err != nil {
+			return nil, ga.Stats{}, err
+		}
+		defer remote.Close()
+		gaCfg.Evaluate = func(children []ga.Genome) {
+			p.evaluateRemote(ctx, remote, children)
+		}
+	}
+	var population []ga.Genome
+	if cfg.Resume != nil {
+		population, err = ga.Resume(run, &gaCfg, *cfg.Resume, p.load)
+		if err != nil {
+			return nil, ga.Stats{}, err
+		}
+	} else {
+		// the generations are bred with other streams of the seed
+		population = createPopulation(p, run.Rand(run.Seed()))
+	}
+	if cfg.Grow > 0 {
+		p.growth = ga.NewPlateau(cfg.Grow)
+		for _, g := range population {
+			p.shapes = max(p.shapes, len(g.(*Organism).Shapes))
+		}
+	}
+
+	best, stats, err := ga.Evolve(ctx, population, gaCfg)
+	if err != nil {
+		return nil, stats, err
+	}
+	return best.(*Organism), stats, nil
+}
+
+// creates the initial population with the random numbers of rng
+func createPopulation(p *problem, rng *rand.Rand) (population []ga.Genome) {
+	target, cfg := p.target, p.cfg
+	population = make([]ga.Genome, cfg.PopSize)
+	for i := 0; i < cfg.PopSize; i++ {
+		if cfg.Start == nil {
+			population[i] = createOrganism(p, rng)
+			continue
+		}
+		// start from the given shapes, every organism but the first is
+		// mutated so the population isn't all the same
+		organism := &Organism{
+			DNA:        draw(target.Rect.Dx(), target.Rect.Dy(), cfg.StartBackground, cfg.Start),
+			Shapes:     append([]Shape(nil), cfg.Start...),
+			Background: cfg.StartBackground,
+			fitness:    -1,
+			problem:    p,
+		}
+		if i > 0 {
+			organism.Mutate(rng)
+		}
+		population[i] = organism
+	}
+	return
+}
+
+// make the organism saved in a checkpoint
+func (p *problem) load(g Genome) ga.Genome {
+	return &Organism{
+		DNA:        g.Draw(),
+		Shapes:     g.Shapes,
+		Background: g.Background,
+		fitness:    -1,
+		problem:    p,
+	}
+}
@@ -0,0 +1,1074 @@
+package triangles
+
+import (
+	"encoding/gob"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"slices"
+	"sort"
+)
+
+// Shape is a shape drawn as part of a picture
+type Shape interface {
+	// Draw the shape onto the image
+	Draw(img *image.RGBA)
+	// Mutate returns a new random shape of the same kind inside a w x h
+	// canvas
+	Mutate(rng *rand.Rand, w int, h int, size int) Shape
+	// Move returns the shape with its points moved by up to d pixels each
+	// way, staying inside a w x h canvas
+	Move(rng *rand.Rand, w int, h int, d int) Shape
+	// Shade returns the shape with each channel of its color but alpha
+	// changed by up to d each way
+	Shade(rng *rand.Rand, d int) Shape
+	// Fade returns the shape with the alpha of its color changed by up to d
+	// each way and kept between lo and hi, without changing the color
+	Fade(rng *rand.Rand, d int, lo uint8, hi uint8) Shape
+	// Recolor returns the shape with every color it's painted with replaced
+	// by f of it
+	Recolor(f func(color.Color) color.Color) Shape
+	// Translate returns the shape moved as a whole by dx, dy, staying inside
+	// a w x h canvas
+	Translate(dx int, dy int, w int, h int) Shape
+	// Rotate returns the shape turned by angle radians about its center,
+	// staying inside a w x h canvas
+	Rotate(angle float64, w int, h int) Shape
+	// Resize returns the shape grown by f about its center, staying inside a
+	// w x h canvas
+	Resize(f float64, w int, h int) Shape
+	// Scale returns the shape stretched by sx horizontally and sy vertically
+	Scale(sx float64, sy float64) Shape
+	// SVG returns the shape as an SVG element
+	SVG() string
+	// Bounds returns the pixels the shape covers at most
+	Bounds() image.Rectangle
+}
+
+// shapeMakers make a shape of each kind around the point p
+var shapeMakers = map[string]func(rng *rand.Rand, p Point, w int, h int, size int, opts shapeOptions, c color.Color) Shape{
+	"triangle":  newTriangle,
+	"gradient":  newGradient,
+	"circle":    newCircle,
+	"ellipse":   newEllipse,
+	"rectangle": newRectangle,
+	"block":     newBlock,
+	"stroke":    newStroke,
+	"glyph":     newGlyph,
+	"polygon":   newPolygon,
+	"site":      newSite,
+}
+
+// shapeOptions are what some kinds of shapes need to be made
+type shapeOptions struct {
+	// Vertices is the number of vertices of a polygon
+	Vertices int
+	// Font is the file of the font glyphs are drawn with
+	Font string
+}
+
+// the number of vertices a polygon can have
+const (
+	minVertices = 3
+	maxVertices = 8
+)
+
+// MixedShapes is the shape kind that picks one of the other kinds at random
+// for every shape
+const MixedShapes = "mix"
+
+func init() {
+	// shapes are stored as Shape interfaces so gob needs to know about every
+	// concrete type to serialize them
+	gob.Register(Triangle{})
+	gob.Register(Gradient{})
+	gob.Register(Circle{})
+	gob.Register(Ellipse{})
+	gob.Register(Rectangle{})
+	gob.Register(Block{})
+	gob.Register(Stroke{})
+	gob.Register(Glyph{})
+	gob.Register(Polygon{})
+	gob.Register(Site{})
+	gob.Register(color.RGBA{})
+	gob.Register(color.NRGBA{})
+}
+
+// ShapeKinds returns the names of the kinds of shapes that can be used
+func ShapeKinds() []string {
+	kinds := make([]string, 0, len(shapeMakers))
+	for kind := range shapeMakers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// pick the maker for a kind of shape, glyphs are only mixed in when there's
+// a font to draw them with. Sites aren't mixed in, a few of them would cover
+// the whole picture.
+func shapeMaker(rng *rand.Rand, kind string, opts shapeOptions) func(rng *rand.Rand, p Point, w int, h int, size int, opts shapeOptions, c color.Color) Shape {
+	mixed := kind == MixedShapes
+	for kind == MixedShapes || (kind == "glyph" && opts.Font == "") || (mixed && kind == "site") {
+		kinds := ShapeKinds()
+		kind = kinds[rng.Intn(len(kinds))]
+	}
+	return shapeMakers[kind]
+}
+
+// create a random shape of the given kind inside a w x h canvas
+func createShape(rng *rand.Rand, kind string, w int, h int, size int, opts shapeOptions) Shape {
+	return createShapeAt(rng, kind, Point{X: rng.Intn(w), Y: rng.Intn(h)}, w, h, size, opts)
+}
+
+// create a random shape of the given kind around p inside a w x h canvas
+func createShapeAt(rng *rand.Rand, kind string, p Point, w int, h int, size int, opts shapeOptions) Shape {
+	return shapeMaker(rng, kind, opts)(rng, p, w, h, size, opts, randomColor(rng))
+}
+
+// the number of pixels around the center of a seeded shape the target is
+// averaged over to color it, so a stray pixel doesn't decide its color
+const seedRadius = 2
+
+// create a random shape around p colored with the average color of the
+// target where it is, moved randomly by up to jitter
+func createSeededShape(rng *rand.Rand, kind string, target *image.RGBA, p Point, size int, opts shapeOptions, jitter int) Shape {
+	w, h := target.Rect.Dx(), target.Rect.Dy()
+	shape := shapeMaker(rng, kind, opts)(rng, p, w, h, size, opts, color.RGBA{})
+	center := seedPoint(shape, w, h)
+	region := image.Rect(center.X-seedRadius, center.Y-seedRadius, center.X+seedRadius+1, center.Y+seedRadius+1).
+		Intersect(image.Rect(0, 0, w, h))
+
+	var sum [3]int
+	n := 0
+	for y := region.Min.Y; y < region.Max.Y; y++ {
+		for x := region.Min.X; x < region.Max.X; x++ {
+			i := target.PixOffset(x+target.Rect.Min.X, y+target.Rect.Min.Y)
+			for c := 0; c < 3; c++ {
+				sum[c] += int(target.Pix[i+c])
+			}
+			n++
+		}
+	}
+	alpha := uint8(rng.Intn(255))
+	// every color of the shape is jittered on its own
+	return shape.Recolor(func(color.Color) color.Color {
+		var rgb [3]uint8
+		for c := 0; c < 3; c++ {
+			rgb[c] = uint8(clamp(sum[c]/n+rng.Intn(2*jitter+1)-jitter, 0, 255))
+		}
+		return color.NRGBA{rgb[0], rgb[1], rgb[2], alpha}
+	})
+}
+
+// where a seeded shape is colored from: the centroid of a triangle, a site
+// itself as its cell can reach anywhere, or the middle of where any other
+// shape is on the w x h canvas
+func seedPoint(shape Shape, w int, h int) Point {
+	switch s := shape.(type) {
+	case Triangle:
+		cx, cy := s.centroid()
+		return Point{X: int(cx), Y: int(cy)}
+	case Gradient:
+		cx, cy := s.triangle().centroid()
+		return Point{X: int(cx), Y: int(cy)}
+	case Site:
+		return s.Center
+	}
+	b := shape.Bounds().Intersect(image.Rect(0, 0, w, h))
+	if b.Empty() {
+		return Point{X: w / 2, Y: h / 2}
+	}
+	return Point{X: (b.Min.X + b.Max.X) / 2, Y: (b.Min.Y + b.Max.Y) / 2}
+}
+
+// create a random color
+func randomColor(rng *rand.Rand) color.Color {
+	return color.RGBA{uint8(rng.Intn(255)), uint8(rng.Intn(255)), uint8(rng.Intn(255)), uint8(rng.Intn(255))}
+}
+
+// the average color of the target, opaque
+func averageColor(target *image.RGBA) color.RGBA {
+	var sum [3]int
+	for y := target.Rect.Min.Y; y < target.Rect.Max.Y; y++ {
+		for x := target.Rect.Min.X; x < target.Rect.Max.X; x++ {
+			i := target.PixOffset(x, y)
+			for c := 0; c < 3; c++ {
+				sum[c] += int(target.Pix[i+c])
+			}
+		}
+	}
+	n := target.Rect.Dx() * target.Rect.Dy()
+	return color.RGBA{uint8(sum[0] / n), uint8(sum[1] / n), uint8(sum[2] / n), 255}
+}
+
+// Point represents a position in the image
+type Point struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// Triangle represents a drawn triangle
+type Triangle struct {
+	P1    Point
+	P2    Point
+	P3    Point
+	Color color.Color
+}
+
+// create a triangle with p as its 1st point, all its points are inside a
+// w x h canvas
+func newTriangle(rng *rand.Rand, p Point, w int, h int, size int, opts shapeOptions, c color.Color) Shape {
+	return Triangle{
+		P1:    p,
+		P2:    nearbyPoint(rng, p, w, h, size),
+		P3:    nearbyPoint(rng, p, w, h, size),
+		Color: c,
+	}
+}
+
+// Draw the triangle
+func (t Triangle) Draw(img *image.RGBA) {
+	fillTriangle(img, t.P1, t.P2, t.P3, t.Color)
+}
+
+// Mutate returns a new random triangle
+func (t Triangle) Mutate(rng *rand.Rand, w int, h int, size int) Shape {
+	return createShape(rng, "triangle", w, h, size, shapeOptions{})
+}
+
+// Move the points of the triangle
+func (t Triangle) Move(rng *rand.Rand, w int, h int, d int) Shape {
+	t.P1, t.P2, t.P3 = movePoint(rng, t.P1, w, h, d), movePoint(rng, t.P2, w, h, d), movePoint(rng, t.P3, w, h, d)
+	return t
+}
+
+// Shade the triangle
+func (t Triangle) Shade(rng *rand.Rand, d int) Shape {
+	t.Color = shadeColor(rng, t.Color, d)
+	return t
+}
+
+// Fade the triangle
+func (t Triangle) Fade(rng *rand.Rand, d int, lo uint8, hi uint8) Shape {
+	t.Color = fadeColor(rng, t.Color, d, lo, hi)
+	return t
+}
+
+// Recolor the triangle
+func (t Triangle) Recolor(f func(color.Color) color.Color) Shape {
+	t.Color = f(t.Color)
+	return t
+}
+
+// Translate the triangle
+func (t Triangle) Translate(dx int, dy int, w int, h int) Shape {
+	t.P1, t.P2, t.P3 = t.P1.translate(dx, dy, w, h), t.P2.translate(dx, dy, w, h), t.P3.translate(dx, dy, w, h)
+	return t
+}
+
+// Rotate the triangle about its centroid
+func (t Triangle) Rotate(angle float64, w int, h int) Shape {
+	cx, cy := t.centroid()
+	t.P1, t.P2, t.P3 = t.P1.about(cx, cy, angle, 1, w, h), t.P2.about(cx, cy, angle, 1, w, h), t.P3.about(cx, cy, angle, 1, w, h)
+	return t
+}
+
+// Resize the triangle about its centroid
+func (t Triangle) Resize(f float64, w int, h int) Shape {
+	cx, cy := t.centroid()
+	t.P1, t.P2, t.P3 = t.P1.about(cx, cy, 0, f, w, h), t.P2.about(cx, cy, 0, f, w, h), t.P3.about(cx, cy, 0, f, w, h)
+	return t
+}
+
+// the centroid of the triangle
+func (t Triangle) centroid() (float64, float64) {
+	return float64(t.P1.X+t.P2.X+t.P3.X) / 3, float64(t.P1.Y+t.P2.Y+t.P3.Y) / 3
+}
+
+// Scale the triangle
+func (t Triangle) Scale(sx float64, sy float64) Shape {
+	t.P1, t.P2, t.P3 = t.P1.scale(sx, sy), t.P2.scale(sx, sy), t.P3.scale(sx, sy)
+	return t
+}
+
+// SVG returns the triangle as a polygon
+func (t Triangle) SVG() string {
+	return fmt.Sprintf(`<polygon points="%d,%d %d,%d %d,%d" %s/>`,
+		t.P1.X, t.P1.Y, t.P2.X, t.P2.Y, t.P3.X, t.P3.Y, svgFill(t.Color))
+}
+
+// Bounds of the triangle
+func (t Triangle) Bounds() image.Rectangle {
+	return image.Rect(
+		min(t.P1.X, t.P2.X, t.P3.X), min(t.P1.Y, t.P2.Y, t.P3.Y),
+		max(t.P1.X, t.P2.X, t.P3.X)+1, max(t.P1.Y, t.P2.Y, t.P3.Y)+1,
+	)
+}
+
+// Circle represents a drawn circle
+type Circle struct {
+	Center Point
+	R      int
+	Color  color.Color
+}
+
+// create a circle centered on p with a diameter of at most size
+func newCircle(rng *rand.Rand, p Point, w int, h int, size int, opts shapeOptions, c color.Color) Shape {
+	return Circle{
+		Center: p,
+		R:      rng.Intn(size/2+1) + 1,
+		Color:  c,
+	}
+}
+
+// Draw the circle
+func (c Circle) Draw(img *image.RGBA) {
+	fillCircle(img, c.Center, c.R, c.Color)
+}
+
+// Mutate returns a new random circle
+func (c Circle) Mutate(rng *rand.Rand, w int, h int, size int) Shape {
+	return createShape(rng, "circle", w, h, size, shapeOptions{})
+}
+
+// Move the center of the circle and change its radius by up to d
+func (c Circle) Move(rng *rand.Rand, w int, h int, d int) Shape {
+	c.Center = movePoint(rng, c.Center, w, h, d)
+	c.R = max(1, c.R+rng.Intn(2*d+1)-d)
+	return c
+}
+
+// Shade the circle
+func (c Circle) Shade(rng *rand.Rand, d int) Shape {
+	c.Color = shadeColor(rng, c.Color, d)
+	return c
+}
+
+// Fade the circle
+func (c Circle) Fade(rng *rand.Rand, d int, lo uint8, hi uint8) Shape {
+	c.Color = fadeColor(rng, c.Color, d, lo, hi)
+	return c
+}
+
+// Recolor the circle
+func (c Circle) Recolor(f func(color.Color) color.Color) Shape {
+	c.Color = f(c.Color)
+	return c
+}
+
+// Translate the circle
+func (c Circle) Translate(dx int, dy int, w int, h int) Shape {
+	c.Center = c.Center.translate(dx, dy, w, h)
+	return c
+}
+
+// Rotate the circle, which looks just the same
+func (c Circle) Rotate(angle float64, w int, h int) Shape {
+	return c
+}
+
+// Resize the circle
+func (c Circle) Resize(f float64, w int, h int) Shape {
+	c.R = max(1, int(math.Round(float64(c.R)*f)))
+	return c
+}
+
+// Scale the circle, the radius is scaled by the smaller of sx and sy so the
+// circle still fits where it used to
+func (c Circle) Scale(sx float64, sy float64) Shape {
+	c.Center = c.Center.scale(sx, sy)
+	c.R = max(1, int(math.Round(float64(c.R)*min(sx, sy))))
+	return c
+}
+
+// SVG returns the circle
+func (c Circle) SVG() string {
+	return fmt.Sprintf(`<circle cx="%d" cy="%d" r="%d" %s/>`, c.Center.X, c.Center.Y, c.R, svgFill(c.Color))
+}
+
+// Bounds of the circle
+func (c Circle) Bounds() image.Rectangle {
+	return image.Rect(c.Center.X-c.R, c.Center.Y-c.R, c.Center.X+c.R+1, c.Center.Y+c.R+1)
+}
+
+// Ellipse represents a drawn ellipse, with radius RX along the direction
+// Angle radians clockwise from the x axis and RY across it
+type Ellipse struct {
+	Center Point
+	RX     int
+	RY     int
+	Angle  float64
+	Color  color.Color
+}
+
+// create an ellipse centered on p with a span of at most size, turned any
+// way
+func newEllipse(rng *rand.Rand, p Point, w int, h int, size int, opts shapeOptions, c color.Color) Shape {
+	return Ellipse{
+		Center: p,
+		RX:     rng.Intn(size/2+1) + 1,
+		RY:     rng.Intn(size/2+1) + 1,
+		Angle:  rng.Float64() * math.Pi,
+		Color:  c,
+	}
+}
+
+// Draw the ellipse
+func (e Ellipse) Draw(img *image.RGBA) {
+	fillEllipse(img, e.Center, e.RX, e.RY, e.Angle, e.Color)
+}
+
+// Mutate returns a new random ellipse
+func (e Ellipse) Mutate(rng *rand.Rand, w int, h int, size int) Shape {
+	return createShape(rng, "ellipse", w, h, size, shapeOptions{})
+}
+
+// Move the center of the ellipse, change its radii by up to d and turn it
+// so the ends of its longer axis move by up to d
+func (e Ellipse) Move(rng *rand.Rand, w int, h int, d int) Shape {
+	e.Center = movePoint(rng, e.Center, w, h, d)
+	e.RX = max(1, e.RX+rng.Intn(2*d+1)-d)
+	e.RY = max(1, e.RY+rng.Intn(2*d+1)-d)
+	e.Angle = turn(e.Angle, (rng.Float64()*2-1)*float64(d)/float64(max(e.RX, e.RY)))
+	return e
+}
+
+// Shade the ellipse
+func (e Ellipse) Shade(rng *rand.Rand, d int) Shape {
+	e.Color = shadeColor(rng, e.Color, d)
+	return e
+}
+
+// Fade the ellipse
+func (e Ellipse) Fade(rng *rand.Rand, d int, lo uint8, hi uint8) Shape {
+	e.Color = fadeColor(rng, e.Color, d, lo, hi)
+	return e
+}
+
+// Recolor the ellipse
+func (e Ellipse) Recolor(f func(color.Color) color.Color) Shape {
+	e.Color = f(e.Color)
+	return e
+}
+
+// Translate the ellipse
+func (e Ellipse) Translate(dx int, dy int, w int, h int) Shape {
+	e.Center = e.Center.translate(dx, dy, w, h)
+	return e
+}
+
+// Rotate the ellipse about its center
+func (e Ellipse) Rotate(angle float64, w int, h int) Shape {
+	e.Angle = turn(e.Angle, angle)
+	return e
+}
+
+// Resize the ellipse
+func (e Ellipse) Resize(f float64, w int, h int) Shape {
+	e.RX = max(1, int(math.Round(float64(e.RX)*f)))
+	e.RY = max(1, int(math.Round(float64(e.RY)*f)))
+	return e
+}
+
+// Scale the ellipse. Its radii are scaled as if it wasn't turned, which is
+// only exact when it isn't or when sx and sy are the same, but pictures are
+// scaled by about as much each way.
+func (e Ellipse) Scale(sx float64, sy float64) Shape {
+	e.Center = e.Center.scale(sx, sy)
+	e.RX = max(1, int(math.Round(float64(e.RX)*sx)))
+	e.RY = max(1, int(math.Round(float64(e.RY)*sy)))
+	return e
+}
+
+// SVG returns the ellipse
+func (e Ellipse) SVG() string {
+	return fmt.Sprintf(`<ellipse cx="%d" cy="%d" rx="%d" ry="%d" transform="rotate(%.2f %d %d)" %s/>`,
+		e.Center.X, e.Center.Y, e.RX, e.RY, e.Angle*180/math.Pi, e.Center.X, e.Center.Y, svgFill(e.Color))
+}
+
+// Bounds of the ellipse
+func (e Ellipse) Bounds() image.Rectangle {
+	hx, hy := ellipseExtent(e.RX, e.RY, e.Angle)
+	return image.Rect(e.Center.X-hx, e.Center.Y-hy, e.Center.X+hx+1, e.Center.Y+hy+1)
+}
+
+// how far an ellipse with radii rx and ry turned by angle reaches from its
+// center horizontally and vertically, rounded up
+func ellipseExtent(rx int, ry int, angle float64) (int, int) {
+	sin, cos := math.Sincos(angle)
+	a, b := float64(rx), float64(ry)
+	hx := math.Sqrt(a*a*cos*cos + b*b*sin*sin)
+	hy := math.Sqrt(a*a*sin*sin + b*b*cos*cos)
+	return int(math.Ceil(hx)), int(math.Ceil(hy))
+}
+
+// turn the angle by by, kept in [0, π) since an ellipse turned half way
+// round looks the same
+func turn(angle float64, by float64) float64 {
+	angle = math.Mod(angle+by, math.Pi)
+	if angle < 0 {
+		angle += math.Pi
+	}
+	return angle
+}
+
+// Rectangle represents a drawn axis-aligned rectangle
+type Rectangle struct {
+	Min   Point
+	Max   Point
+	Color color.Color
+}
+
+// create a rectangle with p as one corner, all its corners are inside a
+// w x h canvas
+func newRectangle(rng *rand.Rand, p Point, w int, h int, size int, opts shapeOptions, c color.Color) Shape {
+	q := nearbyPoint(rng, p, w, h, size)
+	return Rectangle{
+		Min:   Point{X: min(p.X, q.X), Y: min(p.Y, q.Y)},
+		Max:   Point{X: max(p.X, q.X), Y: max(p.Y, q.Y)},
+		Color: c,
+	}
+}
+
+// Draw the rectangle
+func (r Rectangle) Draw(img *image.RGBA) {
+	fillRect(img, r.Bounds(), r.Color)
+}
+
+// Mutate returns a new random rectangle
+func (r Rectangle) Mutate(rng *rand.Rand, w int, h int, size int) Shape {
+	return createShape(rng, "rectangle", w, h, size, shapeOptions{})
+}
+
+// Move the corners of the rectangle
+func (r Rectangle) Move(rng *rand.Rand, w int, h int, d int) Shape {
+	return r.corners(movePoint(rng, r.Min, w, h, d), movePoint(rng, r.Max, w, h, d))
+}
+
+// Shade the rectangle
+func (r Rectangle) Shade(rng *rand.Rand, d int) Shape {
+	r.Color = shadeColor(rng, r.Color, d)
+	return r
+}
+
+// Fade the rectangle
+func (r Rectangle) Fade(rng *rand.Rand, d int, lo uint8, hi uint8) Shape {
+	r.Color = fadeColor(rng, r.Color, d, lo, hi)
+	return r
+}
+
+// Recolor the rectangle
+func (r Rectangle) Recolor(f func(color.Color) color.Color) Shape {
+	r.Color = f(r.Color)
+	return r
+}
+
+// Translate the rectangle
+func (r Rectangle) Translate(dx int, dy int, w int, h int) Shape {
+	r.Min, r.Max = r.Min.translate(dx, dy, w, h), r.Max.translate(dx, dy, w, h)
+	return r
+}
+
+// Rotate the rectangle a quarter turn about its center whatever the angle,
+// which is the only way it can turn and still be axis-aligned
+func (r Rectangle) Rotate(angle float64, w int, h int) Shape {
+	cx, cy := r.center()
+	return r.corners(r.Min.about(cx, cy, math.Pi/2, 1, w, h), r.Max.about(cx, cy, math.Pi/2, 1, w, h))
+}
+
+// Resize the rectangle about its center
+func (r Rectangle) Resize(f float64, w int, h int) Shape {
+	cx, cy := r.center()
+	return r.corners(r.Min.about(cx, cy, 0, f, w, h), r.Max.about(cx, cy, 0, f, w, h))
+}
+
+// the center of the rectangle
+func (r Rectangle) center() (float64, float64) {
+	return float64(r.Min.X+r.Max.X) / 2, float64(r.Min.Y+r.Max.Y) / 2
+}
+
+// the rectangle with opposite corners p and q
+func (r Rectangle) corners(p Point, q Point) Rectangle {
+	r.Min = Point{X: min(p.X, q.X), Y: min(p.Y, q.Y)}
+	r.Max = Point{X: max(p.X, q.X), Y: max(p.Y, q.Y)}
+	return r
+}
+
+// Scale the rectangle. Max is the last pixel covered, so it's the edge after
+// it that's scaled.
+func (r Rectangle) Scale(sx float64, sy float64) Shape {
+	r.Min = r.Min.scale(sx, sy)
+	end := Point{X: r.Max.X + 1, Y: r.Max.Y + 1}.scale(sx, sy)
+	r.Max = Point{X: max(end.X-1, r.Min.X), Y: max(end.Y-1, r.Min.Y)}
+	return r
+}
+
+// SVG returns the rectangle
+func (r Rectangle) SVG() string {
+	return fmt.Sprintf(`<rect x="%d" y="%d" width="%d" height="%d" %s/>`,
+		r.Min.X, r.Min.Y, r.Max.X-r.Min.X+1, r.Max.Y-r.Min.Y+1, svgFill(r.Color))
+}
+
+// Bounds of the rectangle
+func (r Rectangle) Bounds() image.Rectangle {
+	return image.Rect(r.Min.X, r.Min.Y, r.Max.X+1, r.Max.Y+1)
+}
+
+// Polygon represents a drawn polygon with any number of vertices. Where its
+// edges cross, the parts it covers an even number of times are left out.
+type Polygon struct {
+	Points []Point
+	Color  color.Color
+}
+
+// create a polygon of opts.Vertices vertices around p, within size/2 of it and inside a
+// w x h canvas. The vertices go round p in order so its edges don't cross.
+func newPolygon(rng *rand.Rand, p Point, w int, h int, size int, opts shapeOptions, c color.Color) Shape {
+	angles := make([]float64, opts.Vertices)
+	for i := range angles {
+		angles[i] = rng.Float64() * 2 * math.Pi
+	}
+	sort.Float64s(angles)
+	points := make([]Point, opts.Vertices)
+	for i, angle := range angles {
+		r := rng.Float64() * float64(size) / 2
+		sin, cos := math.Sincos(angle)
+		points[i] = Point{
+			X: clamp(p.X+int(math.Round(r*cos)), 0, w-1),
+			Y: clamp(p.Y+int(math.Round(r*sin)), 0, h-1),
+		}
+	}
+	return Polygon{Points: points, Color: c}
+}
+
+// Draw the polygon
+func (pg Polygon) Draw(img *image.RGBA) {
+	fillPolygon(img, pg.Points, pg.Color)
+}
+
+// Mutate returns a new random polygon with as many vertices
+func (pg Polygon) Mutate(rng *rand.Rand, w int, h int, size int) Shape {
+	return createShape(rng, "polygon", w, h, size, shapeOptions{Vertices: len(pg.Points)})
+}
+
+// Move the vertices of the polygon
+func (pg Polygon) Move(rng *rand.Rand, w int, h int, d int) Shape {
+	return pg.each(func(p Point) Point {
+		return movePoint(rng, p, w, h, d)
+	})
+}
+
+// Shade the polygon
+func (pg Polygon) Shade(rng *rand.Rand, d int) Shape {
+	pg.Color = shadeColor(rng, pg.Color, d)
+	return pg
+}
+
+// Fade the polygon
+func (pg Polygon) Fade(rng *rand.Rand, d int, lo uint8, hi uint8) Shape {
+	pg.Color = fadeColor(rng, pg.Color, d, lo, hi)
+	return pg
+}
+
+// Recolor the polygon
+func (pg Polygon) Recolor(f func(color.Color) color.Color) Shape {
+	pg.Color = f(pg.Color)
+	return pg
+}
+
+// Translate the polygon
+func (pg Polygon) Translate(dx int, dy int, w int, h int) Shape {
+	return pg.each(func(p Point) Point {
+		return p.translate(dx, dy, w, h)
+	})
+}
+
+// Rotate the polygon about the average of its vertices
+func (pg Polygon) Rotate(angle float64, w int, h int) Shape {
+	cx, cy := pg.center()
+	return pg.each(func(p Point) Point {
+		return p.about(cx, cy, angle, 1, w, h)
+	})
+}
+
+// Resize the polygon about the average of its vertices
+func (pg Polygon) Resize(f float64, w int, h int) Shape {
+	cx, cy := pg.center()
+	return pg.each(func(p Point) Point {
+		return p.about(cx, cy, 0, f, w, h)
+	})
+}
+
+// Scale the polygon
+func (pg Polygon) Scale(sx float64, sy float64) Shape {
+	return pg.each(func(p Point) Point {
+		return p.scale(sx, sy)
+	})
+}
+
+// SVG returns the polygon
+func (pg Polygon) SVG() string {
+	var points []byte
+	for i, p := range pg.Points {
+		if i > 0 {
+			points = append(points, ' ')
+		}
+		points = fmt.Appendf(points, "%d,%d", p.X, p.Y)
+	}
+	return fmt.Sprintf(`<polygon points="%s" fill-rule="evenodd" %s/>`, points, svgFill(pg.Color))
+}
+
+// Bounds of the polygon
+func (pg Polygon) Bounds() image.Rectangle {
+	var r image.Rectangle
+	for i, p := range pg.Points {
+		pixel := image.Rect(p.X, p.Y, p.X+1, p.Y+1)
+		if i == 0 {
+			r = pixel
+		} else {
+			r = r.Union(pixel)
+		}
+	}
+	return r
+}
+
+// the polygon with f applied to each of its vertices. Polygons are shared
+// between pictures so the vertices are copied rather than changed in place.
+func (pg Polygon) each(f func(p Point) Point) Polygon {
+	points := make([]Point, len(pg.Points))
+	for i, p := range pg.Points {
+		points[i] = f(p)
+	}
+	pg.Points = points
+	return pg
+}
+
+// the average of the vertices of the polygon
+func (pg Polygon) center() (float64, float64) {
+	var x, y int
+	for _, p := range pg.Points {
+		x += p.X
+		y += p.Y
+	}
+	return float64(x) / float64(len(pg.Points)), float64(y) / float64(len(pg.Points))
+}
+
+// add a vertex to the polygon or remove one, keeping it between 3 and most
+// vertices. A new vertex goes somewhere within d pixels of the middle of
+// one of the edges so the polygon doesn't change much.
+func (pg Polygon) reshape(rng *rand.Rand, w int, h int, d int, most int) Polygon {
+	n := len(pg.Points)
+	if n > minVertices && (n >= most || rng.Intn(2) == 0) {
+		i := rng.Intn(n)
+		pg.Points = slices.Delete(slices.Clone(pg.Points), i, i+1)
+		return pg
+	}
+	if n >= most {
+		return pg
+	}
+	i := rng.Intn(n)
+	a, b := pg.Points[i], pg.Points[(i+1)%n]
+	mid := movePoint(rng, Point{X: (a.X + b.X) / 2, Y: (a.Y + b.Y) / 2}, w, h, d)
+	pg.Points = slices.Insert(slices.Clone(pg.Points), i+1, mid)
+	return pg
+}
+
+// Block represents a square tile of a mosaic, a grid of Size x Size cells
+// that Min is the top left corner of one of. Blocks are the quickest shapes
+// to draw, which makes them handy for previews and benchmarks.
+type Block struct {
+	Min   Point
+	Size  int
+	Color color.Color
+}
+
+// create a block in the cell of the grid p is in, the cells are half the
+// size of a shape so blocks cover about as much as the other shapes do
+func newBlock(rng *rand.Rand, p Point, w int, h int, size int, opts shapeOptions, c color.Color) Shape {
+	size = max(1, size/2)
+	return Block{Min: Point{X: p.X / size * size, Y: p.Y / size * size}, Size: size, Color: c}
+}
+
+// Draw the block
+func (b Block) Draw(img *image.RGBA) {
+	fillRect(img, b.Bounds(), b.Color)
+}
+
+// Mutate returns a new random block
+func (b Block) Mutate(rng *rand.Rand, w int, h int, size int) Shape {
+	return createShape(rng, "block", w, h, size, shapeOptions{})
+}
+
+// Move the block to a neighboring cell, the grid is too coarse for it to
+// move by d pixels
+func (b Block) Move(rng *rand.Rand, w int, h int, d int) Shape {
+	return b.Translate((rng.Intn(3)-1)*b.Size, (rng.Intn(3)-1)*b.Size, w, h)
+}
+
+// Shade the block
+func (b Block) Shade(rng *rand.Rand, d int) Shape {
+	b.Color = shadeColor(rng, b.Color, d)
+	return b
+}
+
+// Fade the block
+func (b Block) Fade(rng *rand.Rand, d int, lo uint8, hi uint8) Shape {
+	b.Color = fadeColor(rng, b.Color, d, lo, hi)
+	return b
+}
+
+// Recolor the block
+func (b Block) Recolor(f func(color.Color) color.Color) Shape {
+	b.Color = f(b.Color)
+	return b
+}
+
+// Translate the block to the cell of the grid it's moved into
+func (b Block) Translate(dx int, dy int, w int, h int) Shape {
+	p := b.Min.translate(dx, dy, w, h)
+	b.Min = Point{X: p.X / b.Size * b.Size, Y: p.Y / b.Size * b.Size}
+	return b
+}
+
+// Rotate the block, which looks just the same
+func (b Block) Rotate(angle float64, w int, h int) Shape {
+	return b
+}
+
+// Resize the block, it becomes the cell of the grid of its new size that its
+// center is in
+func (b Block) Resize(f float64, w int, h int) Shape {
+	cx, cy := b.Min.X+b.Size/2, b.Min.Y+b.Size/2
+	b.Size = max(1, int(math.Round(float64(b.Size)*f)))
+	b.Min = Point{X: cx / b.Size * b.Size, Y: cy / b.Size * b.Size}
+	return b
+}
+
+// Scale the block, its size is scaled by the smaller of sx and sy so it
+// stays square
+func (b Block) Scale(sx float64, sy float64) Shape {
+	b.Min = b.Min.scale(sx, sy)
+	b.Size = max(1, int(math.Round(float64(b.Size)*min(sx, sy))))
+	return b
+}
+
+// SVG returns the block
+func (b Block) SVG() string {
+	return fmt.Sprintf(`<rect x="%d" y="%d" width="%d" height="%d" %s/>`, b.Min.X, b.Min.Y, b.Size, b.Size, svgFill(b.Color))
+}
+
+// Bounds of the block
+func (b Block) Bounds() image.Rectangle {
+	return image.Rect(b.Min.X, b.Min.Y, b.Min.X+b.Size, b.Min.Y+b.Size)
+}
+
+// Stroke represents a brush stroke along a quadratic Bézier curve from P1 to
+// P2, bent towards the control point C, Width pixels wide with round ends
+type Stroke struct {
+	P1    Point
+	C     Point
+	P2    Point
+	Width int
+	Color color.Color
+}
+
+// create a stroke starting at p, with its other points within size/2 of it
+// and a width of up to a fifth of size
+func newStroke(rng *rand.Rand, p Point, w int, h int, size int, opts shapeOptions, c color.Color) Shape {
+	return Stroke{
+		P1:    p,
+		C:     nearbyPoint(rng, p, w, h, size),
+		P2:    nearbyPoint(rng, p, w, h, size),
+		Width: rng.Intn(max(1, size/5)) + 1,
+		Color: c,
+	}
+}
+
+// Draw the stroke
+func (s Stroke) Draw(img *image.RGBA) {
+	var points [strokeSegments + 1][2]float64
+	fillStroke(img, s.curve(points[:0]), float64(s.Width)/2, s.Color)
+}
+
+// Mutate returns a new random stroke
+func (s Stroke) Mutate(rng *rand.Rand, w int, h int, size int) Shape {
+	return createShape(rng, "stroke", w, h, size, shapeOptions{})
+}
+
+// Move the points of the stroke and change its width by up to half of d
+func (s Stroke) Move(rng *rand.Rand, w int, h int, d int) Shape {
+	s.P1, s.C, s.P2 = movePoint(rng, s.P1, w, h, d), movePoint(rng, s.C, w, h, d), movePoint(rng, s.P2, w, h, d)
+	dw := max(1, d/2)
+	s.Width = max(1, s.Width+rng.Intn(2*dw+1)-dw)
+	return s
+}
+
+// Shade the stroke
+func (s Stroke) Shade(rng *rand.Rand, d int) Shape {
+	s.Color = shadeColor(rng, s.Color, d)
+	return s
+}
+
+// Fade the stroke
+func (s Stroke) Fade(rng *rand.Rand, d int, lo uint8, hi uint8) Shape {
+	s.Color = fadeColor(rng, s.Color, d, lo, hi)
+	return s
+}
+
+// Recolor the stroke
+func (s Stroke) Recolor(f func(color.Color) color.Color) Shape {
+	s.Color = f(s.Color)
+	return s
+}
+
+// Translate the stroke
+func (s Stroke) Translate(dx int, dy int, w int, h int) Shape {
+	s.P1, s.C, s.P2 = s.P1.translate(dx, dy, w, h), s.C.translate(dx, dy, w, h), s.P2.translate(dx, dy, w, h)
+	return s
+}
+
+// Rotate the stroke about the middle of its points
+func (s Stroke) Rotate(angle float64, w int, h int) Shape {
+	cx, cy := s.center()
+	s.P1, s.C, s.P2 = s.P1.about(cx, cy, angle, 1, w, h), s.C.about(cx, cy, angle, 1, w, h), s.P2.about(cx, cy, angle, 1, w, h)
+	return s
+}
+
+// Resize the stroke about the middle of its points, its width too
+func (s Stroke) Resize(f float64, w int, h int) Shape {
+	cx, cy := s.center()
+	s.P1, s.C, s.P2 = s.P1.about(cx, cy, 0, f, w, h), s.C.about(cx, cy, 0, f, w, h), s.P2.about(cx, cy, 0, f, w, h)
+	s.Width = max(1, int(math.Round(float64(s.Width)*f)))
+	return s
+}
+
+// Scale the stroke, the width is scaled by the smaller of sx and sy
+func (s Stroke) Scale(sx float64, sy float64) Shape {
+	s.P1, s.C, s.P2 = s.P1.scale(sx, sy), s.C.scale(sx, sy), s.P2.scale(sx, sy)
+	s.Width = max(1, int(math.Round(float64(s.Width)*min(sx, sy))))
+	return s
+}
+
+// SVG returns the stroke as a path
+func (s Stroke) SVG() string {
+	return fmt.Sprintf(`<path d="M%d,%d Q%d,%d %d,%d" stroke-width="%d" stroke-linecap="round" %s/>`,
+		s.P1.X, s.P1.Y, s.C.X, s.C.Y, s.P2.X, s.P2.Y, s.Width, svgStroke(s.Color))
+}
+
+// Bounds of the stroke, the curve stays between its points
+func (s Stroke) Bounds() image.Rectangle {
+	r := (s.Width + 1) / 2
+	return image.Rect(
+		min(s.P1.X, s.C.X, s.P2.X)-r, min(s.P1.Y, s.C.Y, s.P2.Y)-r,
+		max(s.P1.X, s.C.X, s.P2.X)+r+1, max(s.P1.Y, s.C.Y, s.P2.Y)+r+1,
+	)
+}
+
+// the middle of the points of the stroke
+func (s Stroke) center() (float64, float64) {
+	return float64(s.P1.X+s.C.X+s.P2.X) / 3, float64(s.P1.Y+s.C.Y+s.P2.Y) / 3
+}
+
+// the most straight pieces the curve of a stroke is drawn with, and how
+// long the pieces are otherwise
+const (
+	strokeSegments = 16
+	strokeSegment  = 6
+)
+
+// append the points along the curve of the stroke that the straight pieces
+// it's drawn with join. The curve is no longer than the lines through its
+// points so that's what the number of pieces is worked out from.
+func (s Stroke) curve(points [][2]float64) [][2]float64 {
+	length := math.Hypot(float64(s.C.X-s.P1.X), float64(s.C.Y-s.P1.Y)) + math.Hypot(float64(s.P2.X-s.C.X), float64(s.P2.Y-s.C.Y))
+	n := clamp(int(math.Ceil(length/strokeSegment)), 1, strokeSegments)
+	for i := 0; i <= n; i++ {
+		t := float64(i) / float64(n)
+		a, b, c := (1-t)*(1-t), 2*(1-t)*t, t*t
+		points = append(points, [2]float64{
+			a*float64(s.P1.X) + b*float64(s.C.X) + c*float64(s.P2.X),
+			a*float64(s.P1.Y) + b*float64(s.C.Y) + c*float64(s.P2.Y),
+		})
+	}
+	return points
+}
+
+// scale the point
+func (p Point) scale(sx float64, sy float64) Point {
+	return Point{X: int(math.Round(float64(p.X) * sx)), Y: int(math.Round(float64(p.Y) * sy))}
+}
+
+// move the point by dx, dy, clamped to the canvas
+func (p Point) translate(dx int, dy int, w int, h int) Point {
+	return Point{X: clamp(p.X+dx, 0, w-1), Y: clamp(p.Y+dy, 0, h-1)}
+}
+
+// turn the point by angle about cx, cy and move it f times as far from
+// there, clamped to the canvas
+func (p Point) about(cx float64, cy float64, angle float64, f float64, w int, h int) Point {
+	x, y := float64(p.X)-cx, float64(p.Y)-cy
+	sin, cos := math.Sincos(angle)
+	return Point{
+		X: clamp(int(math.Round(cx+f*(x*cos-y*sin))), 0, w-1),
+		Y: clamp(int(math.Round(cy+f*(x*sin+y*cos))), 0, h-1),
+	}
+}
+
+// pick a random point within size/2 of p, clamped to the canvas
+func nearbyPoint(rng *rand.Rand, p Point, w int, h int, size int) Point {
+	return Point{
+		X: clamp(p.X+rng.Intn(size)-size/2, 0, w-1),
+		Y: clamp(p.Y+rng.Intn(size)-size/2, 0, h-1),
+	}
+}
+
+// move p by up to d pixels each way, clamped to the canvas
+func movePoint(rng *rand.Rand, p Point, w int, h int, d int) Point {
+	return Point{
+		X: clamp(p.X+rng.Intn(2*d+1)-d, 0, w-1),
+		Y: clamp(p.Y+rng.Intn(2*d+1)-d, 0, h-1),
+	}
+}
+
+// change each channel of c but alpha by up to d each way. The channels are
+// changed as they're drawn, premultiplied, like the random colors are made.
+func shadeColor(rng *rand.Rand, c color.Color, d int) color.Color {
+	r, g, b, a := c.RGBA()
+	shade := func(v uint32) uint8 {
+		return uint8(clamp(int(v>>8)+rng.Intn(2*d+1)-d, 0, 255))
+	}
+	return color.RGBA{shade(r), shade(g), shade(b), uint8(a >> 8)}
+}
+
+// change the alpha of c by up to d each way, kept between lo and hi. The
+// other channels are premultiplied so they're scaled with it to keep the
+// color the same.
+func fadeColor(rng *rand.Rand, c color.Color, d int, lo uint8, hi uint8) color.Color {
+	r, g, b, a := c.RGBA()
+	a >>= 8
+	faded := uint32(clamp(int(a)+rng.Intn(2*d+1)-d, int(lo), int(hi)))
+	if a == faded {
+		return c
+	}
+	scale := func(v uint32) uint8 {
+		if a == 0 {
+			return uint8(v >> 8)
+		}
+		return uint8(min((v>>8)*faded/a, 255))
+	}
+	return color.RGBA{scale(r), scale(g), scale(b), uint8(faded)}
+}
+
+// clamp v to the range [lo, hi]
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
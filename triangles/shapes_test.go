@@ -0,0 +1,133 @@
+package triangles
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sensorphalanx/ga"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// check that every point of the triangle is on the w x h canvas
+func onCanvas(t *testing.T, what string, tri Triangle, w int, h int) bool {
+	t.Helper()
+	for _, p := range []Point{tri.P1, tri.P2, tri.P3} {
+		if p.X < 0 || p.X >= w || p.Y < 0 || p.Y >= h {
+			t.Errorf("%s triangle has the point %v off the %dx%d canvas", what, p, w, h)
+			return false
+		}
+	}
+	return true
+}
+
+func TestTrianglesStayOnCanvas(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	// the triangles are as big as the canvas so they'd go off it often
+	w, h, size := 20, 10, 30
+	for i := 0; i < 1000; i++ {
+		tri := createShape(rng, "triangle", w, h, size, shapeOptions{}).(Triangle)
+		ok := onCanvas(t, "new", tri, w, h) &&
+			onCanvas(t, "mutated", tri.Mutate(rng, w, h, size).(Triangle), w, h) &&
+			onCanvas(t, "moved", tri.Move(rng, w, h, 15).(Triangle), w, h) &&
+			onCanvas(t, "translated", tri.Translate(rng.Intn(41)-20, rng.Intn(21)-10, w, h).(Triangle), w, h) &&
+			onCanvas(t, "rotated", tri.Rotate(rng.Float64()*6, w, h).(Triangle), w, h) &&
+			onCanvas(t, "resized", tri.Resize(rng.Float64()*3, w, h).(Triangle), w, h)
+		if !ok {
+			return
+		}
+	}
+}
+
+func TestEvolvedTrianglesStayOnCanvas(t *testing.T) {
+	target := gradient(20, 10)
+	cfg := DefaultConfig()
+	cfg.Shape = "triangle"
+	cfg.ShapeSize = 30
+	cfg.Move = 15
+	cfg.TransformRate = 1
+	cfg.MutationRate = 0.5
+	cfg.PopSize = 20
+	cfg.PoolSize = 10
+	cfg.FitnessLimit = 0
+	cfg.MaxGenerations = 20
+	cfg.Seed = 1
+	cfg.Progress = func(stats ga.RunStats, best *Organism) {
+		for _, shape := range best.Shapes {
+			if !onCanvas(t, "evolved", shape.(Triangle), 20, 10) {
+				return
+			}
+		}
+	}
+	_, _, err := Run(target, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShapesDrawWithinBounds(t *testing.T) {
+	// glyphs are drawn with a font from a file
+	fontPath := filepath.Join(t.TempDir(), "goregular.ttf")
+	err := os.WriteFile(fontPath, goregular.TTF, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := shapeOptions{Vertices: 5, Font: fontPath}
+	rng := rand.New(rand.NewSource(1))
+	w, h, size := 40, 30, 24
+	white := color.RGBA{255, 255, 255, 255}
+	for _, kind := range ShapeKinds() {
+		for i := 0; i < 100; i++ {
+			shape := createShape(rng, kind, w, h, size, opts).Recolor(func(color.Color) color.Color { return white })
+			img := image.NewRGBA(image.Rect(0, 0, w, h))
+			shape.Draw(img)
+			bounds := shape.Bounds()
+			for y := 0; y < h; y++ {
+				for x := 0; x < w; x++ {
+					if img.RGBAAt(x, y).A != 0 && !image.Pt(x, y).In(bounds) {
+						t.Fatalf("%s %+v drew (%d, %d) outside its bounds %v", kind, shape, x, y, bounds)
+					}
+				}
+			}
+		}
+	}
+}
+
+// how fit the best picture of shapes of the kind is after a few generations
+// of evolving towards the target
+func evolvedFitness(t *testing.T, target *image.RGBA, kind string) int64 {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.Shape = kind
+	cfg.NumShapes = 10
+	cfg.PopSize = 50
+	cfg.FitnessLimit = 0
+	cfg.MaxGenerations = 50
+	cfg.Seed = 1
+	_, stats, err := Run(target, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stats.Fitness
+}
+
+func TestCirclesConvergeOnCircle(t *testing.T) {
+	// a white disc on black
+	target := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			c := color.RGBA{0, 0, 0, 255}
+			if (x-16)*(x-16)+(y-16)*(y-16) <= 10*10 {
+				c = color.RGBA{255, 255, 255, 255}
+			}
+			target.SetRGBA(x, y, c)
+		}
+	}
+	circles, triangles := evolvedFitness(t, target, "circle"), evolvedFitness(t, target, "triangle")
+	if circles >= triangles {
+		t.Errorf("circles got to a fitness of %d on a disc, no better than %d with triangles", circles, triangles)
+	}
+}
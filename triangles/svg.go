@@ -0,0 +1,76 @@
+package triangles
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"os"
+)
+
+// SaveSVG saves the genome as an SVG picture. The shapes are written in the order
+// they're drawn in, so the later ones are on top of each other and of the
+// background. The cells of any sites are written first, under the others.
+func SaveSVG(filePath string, g Genome) error {
+	svgFile, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("cannot create file: %w", err)
+	}
+	buf := bufio.NewWriter(svgFile)
+	fmt.Fprintf(buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", g.Width, g.Height, g.Width, g.Height)
+	if g.Background.A > 0 {
+		fmt.Fprintf(buf, `<rect width="%d" height="%d" %s/>`+"\n", g.Width, g.Height, svgFill(g.Background))
+	}
+	for _, cell := range cellsSVG(g.Width, g.Height, g.Shapes) {
+		fmt.Fprintln(buf, cell)
+	}
+	for _, shape := range g.Shapes {
+		if s := shape.SVG(); s != "" {
+			fmt.Fprintln(buf, s)
+		}
+	}
+	fmt.Fprintln(buf, "</svg>")
+	err = buf.Flush()
+	if err != nil {
+		svgFile.Close()
+		return fmt.Errorf("cannot write SVG: %w", err)
+	}
+	return svgFile.Close()
+}
+
+// the fill attributes of an SVG element for the color
+func svgFill(c color.Color) string {
+	return svgPaint("fill", c)
+}
+
+// the stroke attributes of an SVG element for the color, with fill="none"
+// so only the stroke is drawn
+func svgStroke(c color.Color) string {
+	return `fill="none" ` + svgPaint("stroke", c)
+}
+
+// the attribute painting with the color and its opacity
+func svgPaint(name string, c color.Color) string {
+	if _, _, _, a := c.RGBA(); a == 0 {
+		return name + `="none"`
+	}
+	return svgColor(name, name+"-opacity", c)
+}
+
+// a stop of a gradient at offset, from 0 to 1 along it
+func svgStop(offset float64, c color.Color) string {
+	return fmt.Sprintf(`<stop offset="%g" %s/>`, offset, svgColor("stop-color", "stop-opacity", c))
+}
+
+// the attributes setting the color and its opacity. Colors are drawn
+// premultiplied by their alpha, so they're divided by it to get the color SVG
+// expects.
+func svgColor(name string, opacity string, c color.Color) string {
+	r, g, b, a := c.RGBA()
+	straight := func(v uint32) int {
+		if a == 0 {
+			return 0
+		}
+		return min(int(v*0xffff/a)>>8, 255)
+	}
+	return fmt.Sprintf(`%s="rgb(%d,%d,%d)" %s="%.3f"`, name, straight(r), straight(g), straight(b), opacity, float64(a)/0xffff)
+}
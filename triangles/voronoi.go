@@ -0,0 +1,240 @@
+package triangles
+
+import (
+	"cmp"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"slices"
+)
+
+// Site represents a Voronoi site, the picture is split into cells of the
+// pixels nearest to each site and every cell is filled with the color of its
+// site. Sites are drawn together under the other shapes by drawCells, as the
+// cell of a site depends on where all the others are.
+type Site struct {
+	Center Point
+	Color  color.Color
+}
+
+// everywhere is the bounds of a site, moving one can change the cells of the
+// whole picture
+var everywhere = image.Rect(-1<<30, -1<<30, 1<<30, 1<<30)
+
+// create a site at p
+func newSite(rng *rand.Rand, p Point, w int, h int, size int, opts shapeOptions, c color.Color) Shape {
+	return Site{Center: p, Color: c}
+}
+
+// Draw does nothing, the cells of all the sites are drawn by drawCells
+func (s Site) Draw(img *image.RGBA) {}
+
+// Mutate returns a new random site
+func (s Site) Mutate(rng *rand.Rand, w int, h int, size int) Shape {
+	return createShape(rng, "site", w, h, size, shapeOptions{})
+}
+
+// Move the site
+func (s Site) Move(rng *rand.Rand, w int, h int, d int) Shape {
+	s.Center = movePoint(rng, s.Center, w, h, d)
+	return s
+}
+
+// Shade the site
+func (s Site) Shade(rng *rand.Rand, d int) Shape {
+	s.Color = shadeColor(rng, s.Color, d)
+	return s
+}
+
+// Fade the site
+func (s Site) Fade(rng *rand.Rand, d int, lo uint8, hi uint8) Shape {
+	s.Color = fadeColor(rng, s.Color, d, lo, hi)
+	return s
+}
+
+// Recolor the site
+func (s Site) Recolor(f func(color.Color) color.Color) Shape {
+	s.Color = f(s.Color)
+	return s
+}
+
+// Translate the site
+func (s Site) Translate(dx int, dy int, w int, h int) Shape {
+	s.Center = s.Center.translate(dx, dy, w, h)
+	return s
+}
+
+// Rotate does nothing, a site is only a point
+func (s Site) Rotate(angle float64, w int, h int) Shape {
+	return s
+}
+
+// Resize does nothing, a site is only a point
+func (s Site) Resize(f float64, w int, h int) Shape {
+	return s
+}
+
+// Scale the site
+func (s Site) Scale(sx float64, sy float64) Shape {
+	s.Center = s.Center.scale(sx, sy)
+	return s
+}
+
+// SVG returns nothing, the cells of all the sites are written by cellsSVG
+func (s Site) SVG() string {
+	return ""
+}
+
+// Bounds of the site, which are everywhere
+func (s Site) Bounds() image.Rectangle {
+	return everywhere
+}
+
+// the sites among the shapes, by x so their cells come in order along a row.
+// Sites in the same place keep the order they're drawn in, the first of them
+// gets the cell.
+func sites(shapes []Shape) []Site {
+	var sites []Site
+	for _, shape := range shapes {
+		if s, ok := shape.(Site); ok {
+			sites = append(sites, s)
+		}
+	}
+	slices.SortStableFunc(sites, func(a, b Site) int {
+		return cmp.Compare(a.Center.X, b.Center.X)
+	})
+	return sites
+}
+
+// fill the cells of the sites among the shapes, every pixel of the image
+// with the color of the site nearest to its center. The squared distances
+// of the sites along a row are parabolas, so the cells along each row are
+// found from their lower envelope, which only takes a pass over the sites
+// however many pixels they're nearest to.
+func drawCells(img *image.RGBA, shapes []Shape) {
+	sites := sites(shapes)
+	if len(sites) == 0 {
+		return
+	}
+	colors := make([]rgba64, len(sites))
+	for i, s := range sites {
+		colors[i] = premultiply(s.Color)
+	}
+	// the sites on the lower envelope of the row, and where along the row
+	// each of them starts being the nearest
+	envelope := make([]int, len(sites))
+	starts := make([]float64, len(sites)+1)
+	minX, maxX := float64(img.Rect.Min.X), float64(img.Rect.Max.X)
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		cy := float64(y) + 0.5
+		k := -1
+		for i := range sites {
+			// the sites the new one is nearer than from where they start
+			// being the nearest are never the nearest
+			var x float64
+			for k >= 0 {
+				x = bisect(sites[envelope[k]], sites[i], cy)
+				if x > starts[k] {
+					break
+				}
+				k--
+			}
+			if k >= 0 && math.IsInf(x, 1) {
+				continue
+			}
+			k++
+			envelope[k] = i
+			starts[k] = math.Inf(-1)
+			if k > 0 {
+				starts[k] = x
+			}
+		}
+		starts[k+1] = math.Inf(1)
+		for i := 0; i <= k; i++ {
+			x0, x1 := max(starts[i], minX), min(starts[i+1], maxX)
+			if x0 < x1 {
+				fillSpan(img, y, centerAfter(x0), centerAfter(x1), colors[envelope[i]])
+			}
+		}
+	}
+}
+
+// where along the row at y the site b starts being nearer than the site a,
+// which is at or left of it. A site straight above or below the other is
+// nearer all along the row or nowhere on it.
+func bisect(a Site, b Site, y float64) float64 {
+	ax, ay := float64(a.Center.X), float64(a.Center.Y)
+	bx, by := float64(b.Center.X), float64(b.Center.Y)
+	da, db := (y-ay)*(y-ay), (y-by)*(y-by)
+	if ax == bx {
+		if db < da {
+			return math.Inf(-1)
+		}
+		return math.Inf(1)
+	}
+	return (db + bx*bx - da - ax*ax) / (2 * (bx - ax))
+}
+
+// the cells of the sites among the shapes as SVG paths, clipped to a w x h
+// picture
+func cellsSVG(w int, h int, shapes []Shape) []string {
+	sites := sites(shapes)
+	var paths []string
+	for i, s := range sites {
+		cell := [][2]float64{{0, 0}, {float64(w), 0}, {float64(w), float64(h)}, {0, float64(h)}}
+		for j, other := range sites {
+			if j == i {
+				continue
+			}
+			if other.Center == s.Center {
+				if j < i {
+					// the first site in the same place gets the cell
+					cell = nil
+					break
+				}
+				continue
+			}
+			cell = clipCell(cell, s.Center, other.Center)
+		}
+		if len(cell) < 3 {
+			continue
+		}
+		var path []byte
+		for k, p := range cell {
+			op := 'L'
+			if k == 0 {
+				op = 'M'
+			}
+			path = fmt.Appendf(path, "%c%.1f,%.1f ", op, p[0], p[1])
+		}
+		path = append(path, 'Z')
+		paths = append(paths, fmt.Sprintf(`<path d="%s" %s/>`, path, svgFill(s.Color)))
+	}
+	return paths
+}
+
+// clip the convex cell to the side of the bisector of a and b that's
+// nearer a
+func clipCell(cell [][2]float64, a Point, b Point) [][2]float64 {
+	// the points p nearer a have n·p < c
+	nx, ny := float64(b.X-a.X), float64(b.Y-a.Y)
+	c := (float64(b.X*b.X+b.Y*b.Y) - float64(a.X*a.X+a.Y*a.Y)) / 2
+	side := func(p [2]float64) float64 {
+		return nx*p[0] + ny*p[1] - c
+	}
+	var clipped [][2]float64
+	for i, p := range cell {
+		q := cell[(i+1)%len(cell)]
+		sp, sq := side(p), side(q)
+		if sp <= 0 {
+			clipped = append(clipped, p)
+		}
+		if (sp < 0 && sq > 0) || (sp > 0 && sq < 0) {
+			t := sp / (sp - sq)
+			clipped = append(clipped, [2]float64{p[0] + t*(q[0]-p[0]), p[1] + t*(q[1]-p[1])})
+		}
+	}
+	return clipped
+}
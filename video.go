@@ -0,0 +1,78 @@
+package ga
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Video streams frames to an ffmpeg process which encodes them into a video
+// file, ffmpeg must be installed and on the PATH
+type Video struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr bytes.Buffer
+	size   image.Point
+}
+
+// NewVideo starts encoding a video of w x h frames at fps frames a second
+// to filePath, the format is picked by ffmpeg from the file's extension
+func NewVideo(filePath string, w int, h int, fps int) (*Video, error) {
+	v := &Video{size: image.Pt(w, h)}
+	v.cmd = exec.Command("ffmpeg", "-y", "-loglevel", "error",
+		"-f", "rawvideo", "-pix_fmt", "rgba",
+		"-s", fmt.Sprintf("%dx%d", w, h), "-r", strconv.Itoa(fps), "-i", "-",
+		// most players need yuv420p, which needs an even width and height
+		"-vf", "pad=ceil(iw/2)*2:ceil(ih/2)*2", "-pix_fmt", "yuv420p",
+		filePath)
+	v.cmd.Stderr = &v.stderr
+	stdin, err := v.cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	v.stdin = stdin
+	err = v.cmd.Start()
+	if err != nil {
+		return nil, fmt.Errorf("cannot start ffmpeg: %w", err)
+	}
+	return v, nil
+}
+
+// Add the image as the next frame, it must be the size of the video
+func (v *Video) Add(rgba *image.RGBA) error {
+	if rgba.Rect.Size() != v.size {
+		return fmt.Errorf("frame is %dx%d but the video is %dx%d",
+			rgba.Rect.Dx(), rgba.Rect.Dy(), v.size.X, v.size.Y)
+	}
+	for y := rgba.Rect.Min.Y; y < rgba.Rect.Max.Y; y++ {
+		i := rgba.PixOffset(rgba.Rect.Min.X, y)
+		_, err := v.stdin.Write(rgba.Pix[i : i+v.size.X*4])
+		if err != nil {
+			return fmt.Errorf("cannot write frame: %w", v.error(err))
+		}
+	}
+	return nil
+}
+
+// Close finishes the video and waits for ffmpeg to write it
+func (v *Video) Close() error {
+	v.stdin.Close()
+	err := v.cmd.Wait()
+	if err != nil {
+		return fmt.Errorf("ffmpeg failed: %w", v.error(err))
+	}
+	return nil
+}
+
+// add what ffmpeg printed to the error
+func (v *Video) error(err error) error {
+	msg := strings.TrimSpace(v.stderr.String())
+	if msg == "" {
+		return err
+	}
+	return fmt.Errorf("%w: %s", err, msg)
+}
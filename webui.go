@@ -0,0 +1,247 @@
+package ga
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+// the most generations of fitness a web UI keeps for the chart of a browser
+// that connects during a run, every other one is dropped when there are more
+const webHistory = 4000
+
+//go:embed webui.html
+var webPage []byte
+
+// WebUI serves a page showing the best image of a run, a chart of its
+// fitness and its parameters, pushed to the browser over a WebSocket every
+// generation. A browser that falls behind only gets the latest generation.
+// The best image is also served on its own, as an MJPEG stream on
+// /stream.mjpeg and as a PNG that refreshes itself every second on
+// /best.png, to be watched in any browser or added to OBS.
+type WebUI struct {
+	params  []string
+	mu      sync.Mutex
+	clients map[chan []byte]bool
+	// streams are sent the JPEG frames of the MJPEG streams
+	streams map[chan []byte]bool
+	// the generation, best and mean fitness of the generations so far
+	history [][3]float64
+	best    *image.RGBA
+}
+
+// the messages pushed to the browser
+type (
+	webInit struct {
+		Type    string       `json:"type"`
+		Params  []string     `json:"params"`
+		History [][3]float64 `json:"history"`
+	}
+	webGeneration struct {
+		Type string `json:"type"`
+		eventStats
+		Generation   int     `json:"generation"`
+		MutationRate float64 `json:"mutation_rate"`
+		// Image is the best image as a base64 PNG
+		Image string `json:"image"`
+	}
+)
+
+// NewWebUI makes the web UI of a run with the parameters, e.g. "pop 250"
+func NewWebUI(params []string) *WebUI {
+	return &WebUI{params: params, clients: map[chan []byte]bool{}, streams: map[chan []byte]bool{}}
+}
+
+// Serve the page on the address, e.g. :8080, until the program exits
+func (u *WebUI) Serve(addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("cannot listen: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(webPage)
+	})
+	mux.Handle("/ws", websocket.Handler(u.push))
+	mux.HandleFunc("/stream.mjpeg", u.stream)
+	mux.HandleFunc("/best.png", u.bestPNG)
+	go http.Serve(lis, mux)
+	return nil
+}
+
+// push the generations to a browser until it goes away
+func (u *WebUI) push(ws *websocket.Conn) {
+	defer ws.Close()
+	messages := make(chan []byte, 1)
+	u.mu.Lock()
+	init, err := json.Marshal(webInit{Type: "init", Params: u.params, History: u.history})
+	u.clients[messages] = true
+	u.mu.Unlock()
+	defer func() {
+		u.mu.Lock()
+		delete(u.clients, messages)
+		u.mu.Unlock()
+	}()
+	if err != nil || websocket.Message.Send(ws, string(init)) != nil {
+		return
+	}
+	// the browser doesn't send anything, a read ends when it goes away
+	closed := make(chan struct{})
+	go func() {
+		var discard string
+		for websocket.Message.Receive(ws, &discard) == nil {
+		}
+		close(closed)
+	}()
+	for {
+		select {
+		case m := <-messages:
+			if websocket.Message.Send(ws, string(m)) != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// the boundary between the frames of an MJPEG stream
+const mjpegBoundary = "frame"
+
+// stream the best image as an MJPEG stream, a frame every generation, until
+// the client goes away
+func (u *WebUI) stream(w http.ResponseWriter, r *http.Request) {
+	frames := make(chan []byte, 1)
+	u.mu.Lock()
+	u.streams[frames] = true
+	best := u.best
+	u.mu.Unlock()
+	defer func() {
+		u.mu.Lock()
+		delete(u.streams, frames)
+		u.mu.Unlock()
+	}()
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+mjpegBoundary)
+	w.Header().Set("Cache-Control", "no-cache")
+	// start with the image there is, rather than wait for the next
+	// generation
+	if best != nil {
+		frame, err := encodeJPEG(best)
+		if err == nil {
+			select {
+			case frames <- frame:
+			default:
+			}
+		}
+	}
+	for {
+		select {
+		case frame := <-frames:
+			_, err := fmt.Fprintf(w, "--%s\r\nContent-Type: image/jpeg\r\nContent-Length: %d\r\n\r\n", mjpegBoundary, len(frame))
+			if err == nil {
+				_, err = w.Write(append(frame, "\r\n"...))
+			}
+			if err != nil {
+				return
+			}
+			http.NewResponseController(w).Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// serve the best image as a PNG that tells the browser to fetch it again
+// every second
+func (u *WebUI) bestPNG(w http.ResponseWriter, r *http.Request) {
+	u.mu.Lock()
+	best := u.best
+	u.mu.Unlock()
+	if best == nil {
+		http.Error(w, "no image yet", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Refresh", "1")
+	png.Encode(w, best)
+}
+
+// encode a frame of an MJPEG stream
+func encodeJPEG(img *image.RGBA) ([]byte, error) {
+	var b bytes.Buffer
+	err := jpeg.Encode(&b, img, &jpeg.Options{Quality: 90})
+	return b.Bytes(), err
+}
+
+// Update the browsers with the stats of the generation that was just bred
+// with the mutation rate, and the best image, which is copied
+func (u *WebUI) Update(stats Stats, mutationRate float64, best *image.RGBA) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	// the organism the image belongs to may be recycled, and the image
+	// drawn on again, once Update returns
+	u.best = &image.RGBA{Pix: slices.Clone(best.Pix), Stride: best.Stride, Rect: best.Rect}
+	if len(u.streams) > 0 {
+		frame, err := encodeJPEG(u.best)
+		if err == nil {
+			for frames := range u.streams {
+				select {
+				case <-frames:
+				default:
+				}
+				frames <- frame
+			}
+		}
+	}
+	if len(u.history) == webHistory {
+		// keep every other generation, so the chart still covers the run
+		for i := range len(u.history) / 2 {
+			u.history[i] = u.history[2*i+1]
+		}
+		u.history = u.history[:len(u.history)/2]
+	}
+	u.history = append(u.history, [3]float64{float64(stats.Generations), float64(stats.Best), stats.Mean})
+	if len(u.clients) == 0 {
+		return
+	}
+	var b bytes.Buffer
+	err := png.Encode(&b, best)
+	if err != nil {
+		return
+	}
+	m, err := json.Marshal(webGeneration{
+		Type:         "generation",
+		eventStats:   newEventStats(stats),
+		Generation:   stats.Generations,
+		MutationRate: mutationRate,
+		Image:        base64.StdEncoding.EncodeToString(b.Bytes()),
+	})
+	if err != nil {
+		return
+	}
+	for messages := range u.clients {
+		// replace a message the browser hasn't been sent yet
+		select {
+		case <-messages:
+		default:
+		}
+		messages <- m
+	}
+}
@@ -0,0 +1,40 @@
+package ga
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// LoadWeights loads a grayscale weight mask for the target, brighter pixels
+// in the mask count more when finding the difference between images. The
+// weights are between 0 and 1, one for every pixel in the same order as the
+// pixels in Pix. A mask made for the target before it was shrunk with
+// Downscale is shrunk to fit it.
+func LoadWeights(filePath string, target *image.RGBA) ([]float64, error) {
+	mask, err := getImage(filePath)
+	if err != nil {
+		return nil, err
+	}
+	tw, th := target.Rect.Dx(), target.Rect.Dy()
+	if bounds := mask.Bounds(); bounds.Dx() > tw || bounds.Dy() > th {
+		scaled := Downscale(ToRGBA(mask), max(tw, th))
+		if scaled.Rect.Dx() == tw && scaled.Rect.Dy() == th {
+			mask = scaled
+		}
+	}
+	bounds := mask.Bounds()
+	if bounds.Dx() != tw || bounds.Dy() != th {
+		return nil, fmt.Errorf("weight mask is %dx%d but the target is %dx%d",
+			bounds.Dx(), bounds.Dy(), target.Rect.Dx(), target.Rect.Dy())
+	}
+
+	weights := make([]float64, 0, bounds.Dx()*bounds.Dy())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			gray := color.GrayModel.Convert(mask.At(x, y)).(color.Gray)
+			weights = append(weights, float64(gray.Y)/255)
+		}
+	}
+	return weights, nil
+}
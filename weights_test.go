@@ -0,0 +1,68 @@
+package ga
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+// a copy of img with random bytes in the pixels of r
+func scramble(rng *rand.Rand, img *image.RGBA, r image.Rectangle) *image.RGBA {
+	scrambled := image.NewRGBA(img.Rect)
+	copy(scrambled.Pix, img.Pix)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		i := scrambled.PixOffset(r.Min.X, y)
+		rng.Read(scrambled.Pix[i : i+r.Dx()*4])
+	}
+	return scrambled
+}
+
+func TestWeightMaskHidesHalf(t *testing.T) {
+	w, h := 20, 10
+	left, right := image.Rect(0, 0, w/2, h), image.Rect(w/2, 0, w, h)
+	// the mask is black on the left so it counts for nothing there
+	mask := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := w / 2; x < w; x++ {
+			mask.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+	maskPath := filepath.Join(t.TempDir(), "mask.png")
+	err := Save(maskPath, ToRGBA(mask))
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := gradientImage(w, h, 0)
+	weights, err := LoadWeights(maskPath, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	hidden, shown := scramble(rng, target, left), scramble(rng, target, right)
+	for _, f := range []Fitness{DiffFitness{}.Weighted(weights), LabFitness{}.Weighted(weights)} {
+		if d := f.Score(hidden, target); d != 0 {
+			t.Errorf("%T scores differences under the black half of the mask %d, want 0", f, d)
+		}
+		if d := f.Score(shown, target); d == 0 {
+			t.Errorf("%T scores differences under the white half of the mask 0", f)
+		}
+	}
+	if d, ok := BoundedDiff(hidden, target, weights, 0); d != 0 || !ok {
+		t.Errorf("bounded difference under the black half of the mask is %d, want 0", d)
+	}
+}
+
+func TestWeightMaskSize(t *testing.T) {
+	maskPath := filepath.Join(t.TempDir(), "mask.png")
+	err := Save(maskPath, gradientImage(20, 10, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = LoadWeights(maskPath, gradientImage(10, 20, 0))
+	if err == nil {
+		t.Error("loaded a 20x10 mask for a 10x20 target")
+	}
+}